@@ -103,3 +103,21 @@ func (zo *zoneOverlap) registerAndCheck(z zoneAddr) (existingZone *zoneAddr, ove
 	zo.unboundOverlap[uz] = z
 	return nil, nil
 }
+
+// check returns the already registered zoneAddr that z overlaps with, without registering z. It's used for
+// configs in a view: those may use the same zone and address as a registered zoneAddr, but they should not
+// mix bound and unbound addresses for the same zone and port.
+func (zo *zoneOverlap) check(z zoneAddr) *zoneAddr {
+	uz := zoneAddr{Zone: z.Zone, Address: "", Port: z.Port, Transport: z.Transport}
+	already, ok := zo.unboundOverlap[uz]
+	if !ok {
+		return nil
+	}
+	if z.Address == "" && already.Address != "" {
+		return &already
+	}
+	if _, ok := zo.registeredAddr[uz]; ok && z.Address != "" {
+		return &uz
+	}
+	return nil
+}
@@ -1,10 +1,12 @@
 package dnsserver
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
 
 	"github.com/caddyserver/caddy"
 )
@@ -32,10 +34,14 @@ type Config struct {
 	// DNS-over-TLS or DNS-over-gRPC.
 	Transport string
 
-	// If this function is not nil it will be used to further filter access
-	// to this handler. The primary use is to limit access to a reverse zone
-	// on a non-octet boundary, i.e. /17
-	FilterFunc func(string) bool
+	// FilterFuncs are used to further filter access to this handler, all of them
+	// must return true for the handler to be used. They are used to limit access
+	// to a reverse zone on a non-octet boundary, i.e. /17, and to select a view.
+	FilterFuncs []FilterFunc
+
+	// ViewName is the name of the view this config is selected by, if any. Multiple
+	// configs for the same zone and address are allowed if they are in different views.
+	ViewName string
 
 	// TLSConfig when listening for encrypted connections (gRPC, DNS-over-TLS).
 	TLSConfig *tls.Config
@@ -46,12 +52,43 @@ type Config struct {
 	// Compiled plugin stack.
 	pluginChain plugin.Handler
 
+	// metaCollector collects the metadata before the FilterFuncs are called.
+	metaCollector MetadataCollector
+
 	// Plugin interested in announcing that they exist, so other plugin can call methods
 	// on them should register themselves here. The name should be the name as return by the
 	// Handler's Name method.
 	registry map[string]plugin.Handler
 }
 
+// FilterFunc is a function that returns true if the request may be handled by a config.
+type FilterFunc func(ctx context.Context, state *request.Request) bool
+
+// MetadataCollector is implemented by the metadata plugin. Because a view is selected before any
+// plugin sees the request, metadata used by the FilterFuncs is collected up front.
+type MetadataCollector interface {
+	// Collect adds the metadata of the request to the context and returns the new context. When the
+	// context is passed on to the collector's plugin chain, the metadata isn't collected a second time.
+	Collect(ctx context.Context, state request.Request) context.Context
+}
+
+// filter returns true if all FilterFuncs of c return true for the request. The returned context holds the metadata
+// collected for the FilterFuncs, it should be passed on to the plugin chain of c so the metadata isn't collected again.
+func (c *Config) filter(ctx context.Context, state *request.Request) (context.Context, bool) {
+	if len(c.FilterFuncs) == 0 {
+		return ctx, true
+	}
+	if c.metaCollector != nil {
+		ctx = c.metaCollector.Collect(ctx, *state)
+	}
+	for _, f := range c.FilterFuncs {
+		if !f(ctx, state) {
+			return ctx, false
+		}
+	}
+	return ctx, true
+}
+
 // keyForConfig build a key for identifying the configs during setup time
 func keyForConfig(blocIndex int, blocKeyIndex int) string {
 	return fmt.Sprintf("%d:%d", blocIndex, blocKeyIndex)
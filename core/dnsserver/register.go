@@ -1,16 +1,17 @@
 package dnsserver
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net"
-	"strings"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 	"github.com/coredns/coredns/plugin/pkg/parse"
 	"github.com/coredns/coredns/plugin/pkg/transport"
+	"github.com/coredns/coredns/request"
 
 	"github.com/caddyserver/caddy"
 	"github.com/caddyserver/caddy/caddyfile"
@@ -82,14 +83,13 @@ func (h *dnsContext) InspectServerBlocks(sourceFile string, serverBlocks []caddy
 
 			ones, bits := za.IPNet.Mask.Size()
 			if (bits-ones)%8 != 0 { // only do this for non-octet boundaries
-				cfg.FilterFunc = func(s string) bool {
-					// TODO(miek): strings.ToLower! Slow and allocates new string.
-					addr := dnsutil.ExtractAddressFromReverse(strings.ToLower(s))
+				cfg.FilterFuncs = []FilterFunc{func(ctx context.Context, state *request.Request) bool {
+					addr := dnsutil.ExtractAddressFromReverse(state.Name())
 					if addr == "" {
 						return true
 					}
 					return za.IPNet.Contains(net.ParseIP(addr))
-				}
+				}}
 			}
 			h.saveConfig(keyConfig, cfg)
 		}
@@ -200,6 +200,9 @@ func (h *dnsContext) validateZonesAndListeningAddresses() error {
 	//Validate Zone and addresses
 	checker := newOverlapZone()
 	for _, conf := range h.configs {
+		if conf.ViewName != "" {
+			continue
+		}
 		for _, h := range conf.ListenHosts {
 			// Validate the overlapping of ZoneAddr
 			akey := zoneAddr{Transport: conf.Transport, Zone: conf.Zone, Address: h, Port: conf.Port}
@@ -213,8 +216,27 @@ func (h *dnsContext) validateZonesAndListeningAddresses() error {
 
 		}
 	}
-	return nil
 
+	// Configs in a view may share a zone and address with other configs, but not with a config in the
+	// same view, and they must not overlap with the configs that are not in a view.
+	views := map[string]struct{}{}
+	for _, conf := range h.configs {
+		if conf.ViewName == "" {
+			continue
+		}
+		for _, h := range conf.ListenHosts {
+			akey := zoneAddr{Transport: conf.Transport, Zone: conf.Zone, Address: h, Port: conf.Port}
+			key := akey.String() + " " + conf.ViewName
+			if _, ok := views[key]; ok {
+				return fmt.Errorf("cannot serve %s in view %q - it is already defined", akey.String(), conf.ViewName)
+			}
+			views[key] = struct{}{}
+			if overlapZone := checker.check(akey); overlapZone != nil {
+				return fmt.Errorf("cannot serve %s - zone overlap listener capacity with %v", akey.String(), overlapZone.String())
+			}
+		}
+	}
+	return nil
 }
 
 // groupSiteConfigsByListenAddr groups site configs by their listen
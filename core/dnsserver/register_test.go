@@ -1,7 +1,10 @@
 package dnsserver
 
 import (
+	"context"
 	"testing"
+
+	"github.com/coredns/coredns/request"
 )
 
 func TestHandler(t *testing.T) {
@@ -119,3 +122,48 @@ func TestGroupingServers(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateViews(t *testing.T) {
+	view := func() []FilterFunc {
+		return []FilterFunc{func(ctx context.Context, state *request.Request) bool { return true }}
+	}
+	for i, test := range []struct {
+		configs []*Config
+		failing bool
+	}{
+		// same zone twice
+		{configs: []*Config{
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}},
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}},
+		}, failing: true},
+		// same zone in a view and as catch-all
+		{configs: []*Config{
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}, ViewName: "internal", FilterFuncs: view()},
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}},
+		}, failing: false},
+		// same zone in two views
+		{configs: []*Config{
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}, ViewName: "internal", FilterFuncs: view()},
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}, ViewName: "external", FilterFuncs: view()},
+		}, failing: false},
+		// same zone in the same view
+		{configs: []*Config{
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}, ViewName: "internal", FilterFuncs: view()},
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}, ViewName: "internal", FilterFuncs: view()},
+		}, failing: true},
+		// view bound to an address, catch-all isn't
+		{configs: []*Config{
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{"127.0.0.1"}, ViewName: "internal", FilterFuncs: view()},
+			{Transport: "dns", Zone: ".", Port: "53", ListenHosts: []string{""}},
+		}, failing: true},
+	} {
+		h := &dnsContext{configs: test.configs}
+		err := h.validateZonesAndListeningAddresses()
+		if test.failing && err == nil {
+			t.Errorf("Test %d: expected error, got none", i)
+		}
+		if !test.failing && err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+		}
+	}
+}
@@ -36,12 +36,12 @@ type Server struct {
 	server [2]*dns.Server // 0 is a net.Listener, 1 is a net.PacketConn (a *UDPConn) in our case.
 	m      sync.Mutex     // protects the servers
 
-	zones        map[string]*Config // zones keyed by their address
-	dnsWg        sync.WaitGroup     // used to wait on outstanding connections
-	graceTimeout time.Duration      // the maximum duration of a graceful shutdown
-	trace        trace.Trace        // the trace plugin for the server
-	debug        bool               // disable recover()
-	classChaos   bool               // allow non-INET class queries
+	zones        map[string][]*Config // zones keyed by their address
+	dnsWg        sync.WaitGroup       // used to wait on outstanding connections
+	graceTimeout time.Duration        // the maximum duration of a graceful shutdown
+	trace        trace.Trace          // the trace plugin for the server
+	debug        bool                 // disable recover()
+	classChaos   bool                 // allow non-INET class queries
 }
 
 // NewServer returns a new CoreDNS server and compiles all plugins in to it. By default CH class
@@ -50,7 +50,7 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 
 	s := &Server{
 		Addr:         addr,
-		zones:        make(map[string]*Config),
+		zones:        make(map[string][]*Config),
 		graceTimeout: 5 * time.Second,
 	}
 
@@ -71,8 +71,18 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 			s.debug = false
 			log.D.Clear()
 		}
-		// set the config per zone
-		s.zones[site.Zone] = site
+		// set the config per zone, configs in a view are tried in the order they are declared, before the
+		// catch-all config.
+		configs := s.zones[site.Zone]
+		if len(site.FilterFuncs) > 0 {
+			i := 0
+			for i < len(configs) && len(configs[i].FilterFuncs) > 0 {
+				i++
+			}
+			s.zones[site.Zone] = append(configs[:i], append([]*Config{site}, configs[i:]...)...)
+		} else {
+			s.zones[site.Zone] = append(configs, site)
+		}
 
 		// compile custom plugin for everything
 		var stack plugin.Handler
@@ -93,6 +103,9 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 			if _, ok := EnableChaos[stack.Name()]; ok {
 				s.classChaos = true
 			}
+			if mc, ok := stack.(MetadataCollector); ok {
+				site.metaCollector = mc
+			}
 		}
 		site.pluginChain = stack
 	}
@@ -235,34 +248,31 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		off       int
 		end       bool
 		dshandler *Config
+		dsctx     context.Context
 	)
 
+	state := &request.Request{W: w, Req: r}
 	for {
-		if h, ok := s.zones[q[off:]]; ok {
+		for _, h := range s.zones[q[off:]] {
+			// Call the FilterFuncs to see if we should use this handler.
+			hctx, ok := h.filter(ctx, state)
+			if !ok {
+				continue
+			}
 			if r.Question[0].Qtype != dns.TypeDS {
-				if h.FilterFunc == nil {
-					rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-					if !plugin.ClientWrite(rcode) {
-						errorFunc(s.Addr, w, r, rcode)
-					}
-					return
-				}
-				// FilterFunc is set, call it to see if we should use this handler.
-				// This is given to full query name.
-				if h.FilterFunc(q) {
-					rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-					if !plugin.ClientWrite(rcode) {
-						errorFunc(s.Addr, w, r, rcode)
-					}
-					return
+				rcode, _ := h.pluginChain.ServeDNS(hctx, w, r)
+				if !plugin.ClientWrite(rcode) {
+					errorFunc(s.Addr, w, r, rcode)
 				}
+				return
 			}
 			// The type is DS, keep the handler, but keep on searching as maybe we are serving
 			// the parent as well and the DS should be routed to it - this will probably *misroute* DS
 			// queries to a possibly grand parent, but there is no way for us to know at this point
 			// if there is an actual delegation from grandparent -> parent -> zone.
 			// In all fairness: direct DS queries should not be needed.
-			dshandler = h
+			dshandler, dsctx = h, hctx
+			break
 		}
 		off, end = dns.NextLabel(q, off)
 		if end {
@@ -272,7 +282,7 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 	if r.Question[0].Qtype == dns.TypeDS && dshandler != nil && dshandler.pluginChain != nil {
 		// DS request, and we found a zone, use the handler for the query.
-		rcode, _ := dshandler.pluginChain.ServeDNS(ctx, w, r)
+		rcode, _ := dshandler.pluginChain.ServeDNS(dsctx, w, r)
 		if !plugin.ClientWrite(rcode) {
 			errorFunc(s.Addr, w, r, rcode)
 		}
@@ -280,8 +290,15 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 	}
 
 	// Wildcard match, if we have found nothing try the root zone as a last resort.
-	if h, ok := s.zones["."]; ok && h.pluginChain != nil {
-		rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
+	for _, h := range s.zones["."] {
+		if h.pluginChain == nil {
+			continue
+		}
+		hctx, ok := h.filter(ctx, state)
+		if !ok {
+			continue
+		}
+		rcode, _ := h.pluginChain.ServeDNS(hctx, w, r)
 		if !plugin.ClientWrite(rcode) {
 			errorFunc(s.Addr, w, r, rcode)
 		}
@@ -345,6 +362,9 @@ const (
 // Key is the context key for the current server added to the context.
 type Key struct{}
 
+// HTTPRequestKey is the context key for the *http.Request of a DNS over HTTPS request.
+type HTTPRequestKey struct{}
+
 // EnableChaos is a map with plugin names for which we should open CH class queries as we block these by default.
 var EnableChaos = map[string]struct{}{
 	"chaos":   {},
@@ -2,10 +2,13 @@ package dnsserver
 
 import (
 	"context"
+	"net"
 	"testing"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
 )
@@ -65,3 +68,114 @@ func BenchmarkCoreServeDNS(b *testing.B) {
 		s.ServeDNS(ctx, w, m)
 	}
 }
+
+// namedPlugin writes an empty response, with its name as the only TXT record.
+type namedPlugin string
+
+func (np namedPlugin) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Answer = []dns.RR{test.TXT(r.Question[0].Name + " 0 IN TXT " + string(np))}
+	w.WriteMsg(m)
+	return 0, nil
+}
+
+func (np namedPlugin) Name() string { return string(np) }
+
+func TestServeDNSView(t *testing.T) {
+	tcp := testConfig("dns", namedPlugin("tcp"))
+	tcp.ViewName = "tcp"
+	tcp.FilterFuncs = []FilterFunc{func(ctx context.Context, state *request.Request) bool { return state.Proto() == "tcp" }}
+
+	// The catch-all config comes first, the view should still be tried first.
+	s, err := NewServer("127.0.0.1:53", []*Config{testConfig("dns", namedPlugin("default")), tcp})
+	if err != nil {
+		t.Fatalf("Expected no error for NewServer, got %s", err)
+	}
+
+	for _, proto := range []string{"udp", "tcp"} {
+		m := new(dns.Msg)
+		m.SetQuestion("aaa.example.com.", dns.TypeTXT)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{TCP: proto == "tcp"})
+		s.ServeDNS(context.TODO(), rec, m)
+
+		want := "default"
+		if proto == "tcp" {
+			want = "tcp"
+		}
+		if rec.Msg == nil || len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].(*dns.TXT).Txt[0] != want {
+			t.Errorf("Expected query over %s to be answered by %s, got %v", proto, want, rec.Msg)
+		}
+	}
+}
+
+func TestServeDNSViewOrder(t *testing.T) {
+	inNetwork := func(network string) []FilterFunc {
+		_, n, _ := net.ParseCIDR(network)
+		return []FilterFunc{func(ctx context.Context, state *request.Request) bool { return n.Contains(net.ParseIP(state.IP())) }}
+	}
+	wide := testConfig("dns", namedPlugin("wide"))
+	wide.ViewName = "wide"
+	wide.FilterFuncs = inNetwork("10.0.0.0/8")
+	narrow := testConfig("dns", namedPlugin("narrow"))
+	narrow.ViewName = "narrow"
+	narrow.FilterFuncs = inNetwork("10.1.0.0/16")
+
+	tests := []struct {
+		configs []*Config
+		want    string
+	}{
+		{[]*Config{testConfig("dns", namedPlugin("default")), wide, narrow}, "wide"},
+		{[]*Config{narrow, testConfig("dns", namedPlugin("default")), wide}, "narrow"},
+	}
+	for i, tc := range tests {
+		s, err := NewServer("127.0.0.1:53", tc.configs)
+		if err != nil {
+			t.Fatalf("Expected no error for NewServer, got %s", err)
+		}
+		m := new(dns.Msg)
+		m.SetQuestion("aaa.example.com.", dns.TypeTXT)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "10.1.0.1"})
+		s.ServeDNS(context.TODO(), rec, m)
+		if rec.Msg == nil || len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].(*dns.TXT).Txt[0] != tc.want {
+			t.Errorf("Test %d: expected the first declared view %s to answer, got %v", i, tc.want, rec.Msg)
+		}
+	}
+}
+
+// testCollector adds its name to the context as metadata.
+type testCollector string
+
+func (tc testCollector) Collect(ctx context.Context, state request.Request) context.Context {
+	return context.WithValue(ctx, testCollector(""), string(tc))
+}
+
+// collectedPlugin writes an empty response, with the metadata of testCollector as the only TXT record.
+type collectedPlugin struct{}
+
+func (collectedPlugin) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	md, _ := ctx.Value(testCollector("")).(string)
+	return namedPlugin("collected:"+md).ServeDNS(ctx, w, r)
+}
+
+func (collectedPlugin) Name() string { return "collected" }
+
+func TestServeDNSViewMetadata(t *testing.T) {
+	view := testConfig("dns", collectedPlugin{})
+	view.ViewName = "view"
+	view.FilterFuncs = []FilterFunc{func(ctx context.Context, state *request.Request) bool { return ctx.Value(testCollector("")) == "md" }}
+	view.metaCollector = testCollector("md")
+
+	s, err := NewServer("127.0.0.1:53", []*Config{view})
+	if err != nil {
+		t.Fatalf("Expected no error for NewServer, got %s", err)
+	}
+	m := new(dns.Msg)
+	m.SetQuestion("aaa.example.com.", dns.TypeTXT)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	s.ServeDNS(context.TODO(), rec, m)
+	// The plugin chain of the view gets the metadata collected to select it.
+	if rec.Msg == nil || len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].(*dns.TXT).Txt[0] != "collected:md" {
+		t.Errorf("Expected the metadata collected for the view in its plugin chain, got %v", rec.Msg)
+	}
+}
@@ -11,6 +11,7 @@ package dnsserver
 // care what plugin above them are doing.
 var Directives = []string{
 	"metadata",
+	"geoip",
 	"cancel",
 	"tls",
 	"reload",
@@ -18,6 +19,7 @@ var Directives = []string{
 	"bufsize",
 	"root",
 	"bind",
+	"view",
 	"debug",
 	"trace",
 	"ready",
@@ -29,6 +31,7 @@ var Directives = []string{
 	"dnstap",
 	"dns64",
 	"acl",
+	"ratelimit",
 	"any",
 	"chaos",
 	"loadbalance",
@@ -38,10 +41,15 @@ var Directives = []string{
 	"autopath",
 	"template",
 	"transfer",
+	"gslb",
 	"hosts",
 	"route53",
 	"azure",
 	"clouddns",
+	"digitalocean",
+	"cloudflare",
+	"powerdns",
+	"sql",
 	"k8s_external",
 	"kubernetes",
 	"file",
@@ -88,3 +88,6 @@ func (a Auto) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 
 // Name implements the Handler interface.
 func (a Auto) Name() string { return "auto" }
+
+// CatalogZones implements the transfer.Cataloger interface.
+func (a Auto) CatalogZones() []string { return a.Zones.Names() }
@@ -4,37 +4,24 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
-	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
 	"github.com/coredns/coredns/plugin/pkg/fall"
-	"github.com/coredns/coredns/plugin/pkg/upstream"
-	"github.com/coredns/coredns/request"
 
 	publicdns "github.com/Azure/azure-sdk-for-go/profiles/latest/dns/mgmt/dns"
 	privatedns "github.com/Azure/azure-sdk-for-go/profiles/latest/privatedns/mgmt/privatedns"
 	"github.com/miekg/dns"
 )
 
-type zone struct {
-	id      string
-	z       *file.Zone
-	zone    string
-	private bool
-}
-
-type zones map[string][]*zone
-
 // Azure is the core struct of the azure plugin.
 type Azure struct {
-	zoneNames     []string
 	publicClient  publicdns.RecordSetsClient
 	privateClient privatedns.RecordSetsClient
-	upstream      *upstream.Upstream
-	zMu           sync.RWMutex
-	zones         zones
+	private       map[string]bool // hosted zone ids of private zones
+	zones         *cloud.Zones
 
 	Next plugin.Handler
 	Fall fall.F
@@ -42,97 +29,60 @@ type Azure struct {
 
 // New validates the input DNS zones and initializes the Azure struct.
 func New(ctx context.Context, publicClient publicdns.RecordSetsClient, privateClient privatedns.RecordSetsClient, keys map[string][]string, accessMap map[string]string) (*Azure, error) {
-	zones := make(map[string][]*zone, len(keys))
-	names := make([]string, len(keys))
-	var private bool
+	h := &Azure{
+		publicClient:  publicClient,
+		privateClient: privateClient,
+		private:       make(map[string]bool),
+	}
+	h.zones = cloud.New("azure", h, time.Minute)
 
 	for resourceGroup, znames := range keys {
 		for _, name := range znames {
+			id := resourceGroup + ":" + name
 			switch accessMap[resourceGroup+name] {
 			case "public":
 				if _, err := publicClient.ListAllByDNSZone(context.Background(), resourceGroup, name, nil, ""); err != nil {
 					return nil, err
 				}
-				private = false
 			case "private":
 				if _, err := privateClient.ListComplete(context.Background(), resourceGroup, name, nil, ""); err != nil {
 					return nil, err
 				}
-				private = true
-			}
-
-			fqdn := dns.Fqdn(name)
-			if _, ok := zones[fqdn]; !ok {
-				names = append(names, fqdn)
+				h.private[id] = true
 			}
-			zones[fqdn] = append(zones[fqdn], &zone{id: resourceGroup, zone: name, private: private, z: file.NewZone(fqdn, "")})
+			h.zones.Add(name, id)
 		}
 	}
-
-	return &Azure{
-		publicClient:  publicClient,
-		privateClient: privateClient,
-		zones:         zones,
-		zoneNames:     names,
-		upstream:      upstream.New(),
-	}, nil
+	return h, nil
 }
 
 // Run updates the zone from azure.
-func (h *Azure) Run(ctx context.Context) error {
-	if err := h.updateZones(ctx); err != nil {
-		return err
+func (h *Azure) Run(ctx context.Context) error { return h.zones.Run(ctx) }
+
+// Records implements cloud.Provider, it lists the record sets of the hosted zone with id, which is the resource
+// group and the zone name, separated by a colon.
+func (h *Azure) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	ss := strings.SplitN(id, ":", 2)
+	if len(ss) != 2 {
+		return nil, fmt.Errorf("invalid resource group/zone: %q", id)
 	}
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				log.Infof("Breaking out of Azure update loop: %v", ctx.Err())
-				return
-			case <-time.After(1 * time.Minute):
-				if err := h.updateZones(ctx); err != nil && ctx.Err() == nil {
-					log.Errorf("Failed to update zones: %v", err)
-				}
-			}
-		}
-	}()
-	return nil
-}
-
-func (h *Azure) updateZones(ctx context.Context) error {
-	var err error
-	var publicSet publicdns.RecordSetListResultPage
-	var privateSet privatedns.RecordSetListResultPage
-	var newZ *file.Zone
-	errs := make([]string, 0)
-	for zName, z := range h.zones {
-		for i, hostedZone := range z {
-			if hostedZone.private {
-				privateSet, err = h.privateClient.List(ctx, hostedZone.id, hostedZone.zone, nil, "")
-				newZ = updateZoneFromPrivateResourceSet(privateSet, zName)
-			} else {
-				publicSet, err = h.publicClient.ListByDNSZone(ctx, hostedZone.id, hostedZone.zone, nil, "")
-				newZ = updateZoneFromPublicResourceSet(publicSet, zName)
-			}
-			if err != nil {
-				errs = append(errs, fmt.Sprintf("failed to list resource records for %v from azure: %v", hostedZone.zone, err))
-			}
-			newZ.Upstream = h.upstream
-			h.zMu.Lock()
-			(*z[i]).z = newZ
-			h.zMu.Unlock()
+	if h.private[id] {
+		set, err := h.privateClient.List(ctx, ss[0], ss[1], nil, "")
+		if err != nil {
+			return nil, err
 		}
+		return privateRecords(set), nil
 	}
-
-	if len(errs) != 0 {
-		return fmt.Errorf("errors updating zones: %v", errs)
+	set, err := h.publicClient.ListByDNSZone(ctx, ss[0], ss[1], nil, "")
+	if err != nil {
+		return nil, err
 	}
-	return nil
-
+	return publicRecords(set), nil
 }
 
-func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage, zName string) *file.Zone {
-	newZ := file.NewZone(zName, "")
+// publicRecords returns the records in the record sets of recordSet.
+func publicRecords(recordSet publicdns.RecordSetListResultPage) []dns.RR {
+	var rrs []dns.RR
 
 	for _, result := range *(recordSet.Response().Value) {
 		resultFqdn := *(result.RecordSetProperties.Fqdn)
@@ -141,7 +91,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 			for _, A := range *(result.RecordSetProperties.ARecords) {
 				a := &dns.A{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: resultTTL},
 					A: net.ParseIP(*(A.Ipv4Address))}
-				newZ.Insert(a)
+				rrs = append(rrs, a)
 			}
 		}
 
@@ -149,7 +99,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 			for _, AAAA := range *(result.RecordSetProperties.AaaaRecords) {
 				aaaa := &dns.AAAA{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: resultTTL},
 					AAAA: net.ParseIP(*(AAAA.Ipv6Address))}
-				newZ.Insert(aaaa)
+				rrs = append(rrs, aaaa)
 			}
 		}
 
@@ -158,7 +108,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 				mx := &dns.MX{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeMX, Class: dns.ClassINET, Ttl: resultTTL},
 					Preference: uint16(*(MX.Preference)),
 					Mx:         dns.Fqdn(*(MX.Exchange))}
-				newZ.Insert(mx)
+				rrs = append(rrs, mx)
 			}
 		}
 
@@ -166,7 +116,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 			for _, PTR := range *(result.RecordSetProperties.PtrRecords) {
 				ptr := &dns.PTR{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: resultTTL},
 					Ptr: dns.Fqdn(*(PTR.Ptrdname))}
-				newZ.Insert(ptr)
+				rrs = append(rrs, ptr)
 			}
 		}
 
@@ -177,7 +127,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 					Weight:   uint16(*(SRV.Weight)),
 					Port:     uint16(*(SRV.Port)),
 					Target:   dns.Fqdn(*(SRV.Target))}
-				newZ.Insert(srv)
+				rrs = append(rrs, srv)
 			}
 		}
 
@@ -185,7 +135,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 			for _, TXT := range *(result.RecordSetProperties.TxtRecords) {
 				txt := &dns.TXT{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: resultTTL},
 					Txt: *(TXT.Value)}
-				newZ.Insert(txt)
+				rrs = append(rrs, txt)
 			}
 		}
 
@@ -193,7 +143,7 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 			for _, NS := range *(result.RecordSetProperties.NsRecords) {
 				ns := &dns.NS{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: resultTTL},
 					Ns: *(NS.Nsdname)}
-				newZ.Insert(ns)
+				rrs = append(rrs, ns)
 			}
 		}
 
@@ -207,21 +157,22 @@ func updateZoneFromPublicResourceSet(recordSet publicdns.RecordSetListResultPage
 				Serial:  uint32(*(SOA.SerialNumber)),
 				Mbox:    dns.Fqdn(*(SOA.Email)),
 				Ns:      *(SOA.Host)}
-			newZ.Insert(soa)
+			rrs = append(rrs, soa)
 		}
 
 		if result.RecordSetProperties.CnameRecord != nil {
 			CNAME := result.RecordSetProperties.CnameRecord.Cname
 			cname := &dns.CNAME{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: resultTTL},
 				Target: dns.Fqdn(*CNAME)}
-			newZ.Insert(cname)
+			rrs = append(rrs, cname)
 		}
 	}
-	return newZ
+	return rrs
 }
 
-func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPage, zName string) *file.Zone {
-	newZ := file.NewZone(zName, "")
+// privateRecords returns the records in the record sets of recordSet.
+func privateRecords(recordSet privatedns.RecordSetListResultPage) []dns.RR {
+	var rrs []dns.RR
 
 	for _, result := range *(recordSet.Response().Value) {
 		resultFqdn := *(result.RecordSetProperties.Fqdn)
@@ -230,14 +181,14 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 			for _, A := range *(result.RecordSetProperties.ARecords) {
 				a := &dns.A{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: resultTTL},
 					A: net.ParseIP(*(A.Ipv4Address))}
-				newZ.Insert(a)
+				rrs = append(rrs, a)
 			}
 		}
 		if result.RecordSetProperties.AaaaRecords != nil {
 			for _, AAAA := range *(result.RecordSetProperties.AaaaRecords) {
 				aaaa := &dns.AAAA{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: resultTTL},
 					AAAA: net.ParseIP(*(AAAA.Ipv6Address))}
-				newZ.Insert(aaaa)
+				rrs = append(rrs, aaaa)
 			}
 		}
 
@@ -246,7 +197,7 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 				mx := &dns.MX{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeMX, Class: dns.ClassINET, Ttl: resultTTL},
 					Preference: uint16(*(MX.Preference)),
 					Mx:         dns.Fqdn(*(MX.Exchange))}
-				newZ.Insert(mx)
+				rrs = append(rrs, mx)
 			}
 		}
 
@@ -254,7 +205,7 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 			for _, PTR := range *(result.RecordSetProperties.PtrRecords) {
 				ptr := &dns.PTR{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: resultTTL},
 					Ptr: dns.Fqdn(*(PTR.Ptrdname))}
-				newZ.Insert(ptr)
+				rrs = append(rrs, ptr)
 			}
 		}
 
@@ -265,7 +216,7 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 					Weight:   uint16(*(SRV.Weight)),
 					Port:     uint16(*(SRV.Port)),
 					Target:   dns.Fqdn(*(SRV.Target))}
-				newZ.Insert(srv)
+				rrs = append(rrs, srv)
 			}
 		}
 
@@ -273,7 +224,7 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 			for _, TXT := range *(result.RecordSetProperties.TxtRecords) {
 				txt := &dns.TXT{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: resultTTL},
 					Txt: *(TXT.Value)}
-				newZ.Insert(txt)
+				rrs = append(rrs, txt)
 			}
 		}
 
@@ -287,67 +238,23 @@ func updateZoneFromPrivateResourceSet(recordSet privatedns.RecordSetListResultPa
 				Serial:  uint32(*(SOA.SerialNumber)),
 				Mbox:    dns.Fqdn(*(SOA.Email)),
 				Ns:      *(SOA.Host)}
-			newZ.Insert(soa)
+			rrs = append(rrs, soa)
 		}
 
 		if result.RecordSetProperties.CnameRecord != nil {
 			CNAME := result.RecordSetProperties.CnameRecord.Cname
 			cname := &dns.CNAME{Hdr: dns.RR_Header{Name: resultFqdn, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: resultTTL},
 				Target: dns.Fqdn(*CNAME)}
-			newZ.Insert(cname)
+			rrs = append(rrs, cname)
 		}
 
 	}
-	return newZ
+	return rrs
 }
 
 // ServeDNS implements the plugin.Handler interface.
 func (h *Azure) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
-	state := request.Request{W: w, Req: r}
-	qname := state.Name()
-
-	zone := plugin.Zones(h.zoneNames).Matches(qname)
-	if zone == "" {
-		return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
-	}
-
-	zones, ok := h.zones[zone] // ok true if we are authoritative for the zone.
-	if !ok || zones == nil {
-		return dns.RcodeServerFailure, nil
-	}
-
-	m := new(dns.Msg)
-	m.SetReply(r)
-	m.Authoritative = true
-	var result file.Result
-	for _, z := range zones {
-		h.zMu.RLock()
-		m.Answer, m.Ns, m.Extra, result = z.z.Lookup(ctx, state, qname)
-		h.zMu.RUnlock()
-
-		// record type exists for this name (NODATA).
-		if len(m.Answer) != 0 || result == file.NoData {
-			break
-		}
-	}
-
-	if len(m.Answer) == 0 && result != file.NoData && h.Fall.Through(qname) {
-		return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
-	}
-
-	switch result {
-	case file.Success:
-	case file.NoData:
-	case file.NameError:
-		m.Rcode = dns.RcodeNameError
-	case file.Delegation:
-		m.Authoritative = false
-	case file.ServerFailure:
-		return dns.RcodeServerFailure, nil
-	}
-
-	w.WriteMsg(m)
-	return dns.RcodeSuccess, nil
+	return h.zones.ServeDNS(ctx, w, r, h.Next, h.Fall)
 }
 
 // Name implements plugin.Handler.Name.
@@ -4,8 +4,9 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
-	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	"github.com/coredns/coredns/plugin/test"
@@ -14,19 +15,9 @@ import (
 	"github.com/miekg/dns"
 )
 
-var demoAzure = Azure{
-	Next:      testHandler(),
-	Fall:      fall.Zero,
-	zoneNames: []string{"example.org.", "www.example.org.", "example.org.", "sample.example.org."},
-	zones:     testZones(),
-}
-
-func testZones() zones {
-	zones := make(map[string][]*zone)
-	zones["example.org."] = append(zones["example.org."], &zone{zone: "example.org."})
-	newZ := file.NewZone("example.org.", "")
-
-	for _, rr := range []string{
+func demoAzure(t *testing.T) *Azure {
+	p := cloud.NewFake()
+	err := p.Set("rg:example.org",
 		"example.org.  300 IN  A   1.2.3.4",
 		"example.org.  300 IN  AAAA   2001:db8:85a3::8a2e:370:7334",
 		"www.example.org.  300 IN  A   1.2.3.4",
@@ -39,12 +30,16 @@ func testZones() zones {
 		"example.org. 300 IN SRV 1 10 5269 srv-1.example.com.",
 		"example.org. 300 IN SRV 1 10 5269 srv-2.example.com.",
 		"txt.example.org. 300 IN TXT \"TXT for example.org\"",
-	} {
-		r, _ := dns.NewRR(rr)
-		newZ.Insert(r)
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zones := cloud.New("azure", p, time.Minute)
+	zones.Add("example.org.", "rg:example.org")
+	if err := zones.Run(context.Background()); err != nil {
+		t.Fatal(err)
 	}
-	zones["example.org."][0].z = newZ
-	return zones
+	return &Azure{Next: testHandler(), Fall: fall.Zero, zones: zones}
 }
 
 func testHandler() test.HandlerFunc {
@@ -67,6 +62,7 @@ func testHandler() test.HandlerFunc {
 }
 
 func TestAzure(t *testing.T) {
+	h := demoAzure(t)
 	tests := []struct {
 		qname        string
 		qtype        uint16
@@ -139,7 +135,7 @@ func TestAzure(t *testing.T) {
 		req.SetQuestion(dns.Fqdn(tc.qname), tc.qtype)
 
 		rec := dnstest.NewRecorder(&test.ResponseWriter{})
-		code, err := demoAzure.ServeDNS(context.Background(), rec, req)
+		code, err := h.ServeDNS(context.Background(), rec, req)
 
 		if err != tc.expectedErr {
 			t.Fatalf("Test %d: Expected error %v, but got %v", ti, tc.expectedErr, err)
@@ -0,0 +1,4 @@
+package azure
+
+// Ready implements the ready.Readiness interface.
+func (h *Azure) Ready() bool { return h.zones.Ready() }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
 	"github.com/coredns/coredns/plugin/pkg/fall"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 
@@ -42,7 +43,7 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("azure", err)
 	}
 	h.Fall = fall
-	if err := h.Run(ctx); err != nil {
+	if err := cloud.Run(c, h.Run); err != nil {
 		return plugin.Error("azure", err)
 	}
 
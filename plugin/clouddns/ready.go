@@ -0,0 +1,4 @@
+package clouddns
+
+// Ready implements the ready.Readiness interface.
+func (h *CloudDNS) Ready() bool { return h.zones.Ready() }
@@ -0,0 +1,75 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultEndpoint = "https://api.cloudflare.com/client/v4"
+
+// client is a client for the Cloudflare v4 API.
+type client struct {
+	endpoint string
+	token    string
+	http     *http.Client
+}
+
+func newClient(endpoint, token string) *client {
+	return &client{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		token:    token,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// response is the envelope of all API responses.
+type response struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		TotalPages int `json:"total_pages"`
+	} `json:"result_info"`
+}
+
+// get gets path from the API and decodes the result in the response into v.
+func (c *client) get(ctx context.Context, path string, v interface{}) (*response, error) {
+	req, err := http.NewRequest(http.MethodGet, c.endpoint+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := &response{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, fmt.Errorf("%s: %v", resp.Status, err)
+	}
+	if !r.Success || resp.StatusCode != http.StatusOK {
+		msgs := make([]string, len(r.Errors))
+		for i, e := range r.Errors {
+			msgs[i] = fmt.Sprintf("%d: %s", e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.Join(msgs, ", "))
+	}
+	if len(r.Result) == 0 {
+		return nil, errors.New("no result in response")
+	}
+	return r, json.Unmarshal(r.Result, v)
+}
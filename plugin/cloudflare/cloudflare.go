@@ -0,0 +1,142 @@
+// Package cloudflare implements a plugin that returns resource records
+// from Cloudflare DNS.
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
+)
+
+// Cloudflare is a plugin that returns RR from Cloudflare DNS.
+type Cloudflare struct {
+	Next plugin.Handler
+	Fall fall.F
+
+	client *client
+	zones  *cloud.Zones
+	byID   map[string]zone
+}
+
+// zone is a zone as it is returned by the Cloudflare API.
+type zone struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	NameServers []string `json:"name_servers"`
+}
+
+// New looks up the id of each of the zones in the Cloudflare account of token, whose API is at endpoint, and returns
+// a new *Cloudflare that serves them. The zones are synced every refresh. Returns error if any zone doesn't exist.
+func New(ctx context.Context, endpoint, token string, zones []string, refresh time.Duration) (*Cloudflare, error) {
+	c := newClient(endpoint, token)
+	h := &Cloudflare{client: c, byID: make(map[string]zone)}
+	h.zones = cloud.New("cloudflare", h, refresh)
+	for _, name := range zones {
+		name = strings.TrimSuffix(strings.ToLower(name), ".")
+		result := []zone{}
+		if _, err := c.get(ctx, "/zones?name="+url.QueryEscape(name), &result); err != nil {
+			return nil, fmt.Errorf("failed to get zone %s: %v", name, err)
+		}
+		if len(result) == 0 {
+			return nil, fmt.Errorf("zone %s not found", name)
+		}
+		h.byID[result[0].ID] = result[0]
+		h.zones.Add(name, result[0].ID)
+	}
+	return h, nil
+}
+
+// Run executes first update, spins up an update forever-loop.
+// Returns error if first update fails.
+func (h *Cloudflare) Run(ctx context.Context) error { return h.zones.Run(ctx) }
+
+// ServeDNS implements the plugin.Handler interface.
+func (h *Cloudflare) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return h.zones.ServeDNS(ctx, w, r, h.Next, h.Fall)
+}
+
+// record is a DNS record as it is returned by the Cloudflare API.
+type record struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	TTL      uint32 `json:"ttl"`
+	Priority uint16 `json:"priority"`
+	Proxied  bool   `json:"proxied"`
+}
+
+// autoTTL is the TTL of records with an automatic TTL, which the API returns as a TTL of 1.
+const autoTTL = 300
+
+// Records implements cloud.Provider, it lists the DNS records of the zone with id. Proxied records are skipped, as
+// their content is the origin behind Cloudflare, not what clients should connect to.
+func (h *Cloudflare) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	var rrs []dns.RR
+	for page := 1; ; page++ {
+		records := []record{}
+		resp, err := h.client.get(ctx, fmt.Sprintf("/zones/%s/dns_records?per_page=100&page=%d", url.PathEscape(id), page), &records)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if r.Proxied {
+				continue
+			}
+			rr, err := r.rr()
+			if err != nil {
+				// Maybe unsupported record type. Log and carry on.
+				log.Warningf("Failed to process DNS record: %v", err)
+				continue
+			}
+			rrs = append(rrs, rr)
+		}
+		if page >= resp.ResultInfo.TotalPages {
+			break
+		}
+	}
+
+	z := h.byID[id]
+	ns := ""
+	if len(z.NameServers) > 0 {
+		ns = z.NameServers[0]
+	}
+	return append(rrs, cloud.SOA(dns.Fqdn(strings.ToLower(z.Name)), ns, autoTTL)), nil
+}
+
+// rr returns r as a dns.RR.
+func (r record) rr() (dns.RR, error) {
+	name := dns.Fqdn(r.Name)
+	ttl := r.TTL
+	if ttl == 1 {
+		ttl = autoTTL
+	}
+	var rfc1035 string
+	switch r.Type {
+	case "TXT":
+		return cloud.TXT(name, ttl, r.Content), nil
+	case "MX", "SRV", "URI":
+		// The priority isn't part of the content of these.
+		rfc1035 = fmt.Sprintf("%s %d IN %s %d %s", name, ttl, r.Type, r.Priority, r.Content)
+	default:
+		rfc1035 = fmt.Sprintf("%s %d IN %s %s", name, ttl, r.Type, r.Content)
+	}
+	rr, err := dns.NewRR(rfc1035)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse resource record: %v", err)
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("empty resource record: %s", rfc1035)
+	}
+	return rr, nil
+}
+
+// Name implements the Handler interface.
+func (h *Cloudflare) Name() string { return "cloudflare" }
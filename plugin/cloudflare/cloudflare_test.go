@@ -0,0 +1,170 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// fakeCloudflare is a stand-in for the Cloudflare v4 API, it serves the zones and DNS records it is given. Records
+// are returned in pages of two.
+type fakeCloudflare struct {
+	token   string
+	zones   []zone
+	records map[string][]record // by zone id
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		reply(w, http.StatusForbidden, nil, nil, map[string]interface{}{"code": 9109, "message": "Invalid access token"})
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(path) == 1 && path[0] == "zones":
+		zones := []zone{}
+		for _, z := range f.zones {
+			if z.Name == r.URL.Query().Get("name") {
+				zones = append(zones, z)
+			}
+		}
+		reply(w, http.StatusOK, zones, nil, nil)
+	case len(path) == 3 && path[0] == "zones" && path[2] == "dns_records":
+		records, ok := f.records[path[1]]
+		if !ok {
+			reply(w, http.StatusNotFound, nil, nil, map[string]interface{}{"code": 7003, "message": "Could not route to /zones/" + path[1]})
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start, end := (page-1)*2, page*2
+		if end > len(records) {
+			end = len(records)
+		}
+		reply(w, http.StatusOK, records[start:end], map[string]int{"page": page, "total_pages": (len(records) + 1) / 2}, nil)
+	default:
+		reply(w, http.StatusNotFound, nil, nil, map[string]interface{}{"code": 7000, "message": "No route for that URI"})
+	}
+}
+
+func reply(w http.ResponseWriter, code int, result, info, err interface{}) {
+	resp := map[string]interface{}{"success": err == nil, "errors": []interface{}{}, "result": result}
+	if err != nil {
+		resp["errors"] = []interface{}{err}
+	}
+	if info != nil {
+		resp["result_info"] = info
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newFakeCloudflare() *httptest.Server {
+	return httptest.NewServer(&fakeCloudflare{
+		token: "secret",
+		zones: []zone{{ID: "023e105f4ecef8ad9ca31a8372d0c353", Name: "example.org", NameServers: []string{"tom.ns.cloudflare.com", "lucy.ns.cloudflare.com"}}},
+		records: map[string][]record{
+			"023e105f4ecef8ad9ca31a8372d0c353": {
+				{Type: "NS", Name: "example.org", Content: "tom.ns.cloudflare.com", TTL: 86400},
+				{Type: "A", Name: "example.org", Content: "1.2.3.4", TTL: 1},
+				{Type: "AAAA", Name: "example.org", Content: "2001:db8::1", TTL: 300},
+				{Type: "A", Name: "www.example.org", Content: "1.2.3.5", TTL: 120},
+				{Type: "A", Name: "proxied.example.org", Content: "10.0.0.1", TTL: 1, Proxied: true},
+				{Type: "CNAME", Name: "alias.example.org", Content: "example.org", TTL: 300},
+				{Type: "MX", Name: "example.org", Content: "mail.example.org", Priority: 10, TTL: 300},
+				{Type: "TXT", Name: "txt.example.org", Content: "v=spf1 -all", TTL: 300},
+				{Type: "SRV", Name: "_sip._tcp.example.org", Content: "20 5060 sip.example.org", Priority: 10, TTL: 300},
+				{Type: "CAA", Name: "example.org", Content: `0 issue "letsencrypt.org"`, TTL: 300},
+				// Unsupported type should be ignored.
+				{Type: "YOLO", Name: "swag.example.org", Content: "foobar", TTL: 300},
+			},
+		},
+	})
+}
+
+func TestCloudflare(t *testing.T) {
+	s := newFakeCloudflare()
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := New(ctx, s.URL, "secret", []string{"example.com"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a zone that doesn't exist")
+	}
+	if _, err := New(ctx, s.URL, "wrong", []string{"example.org"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a wrong token")
+	}
+
+	h, err := New(ctx, s.URL, "secret", []string{"example.org."}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create Cloudflare: %v", err)
+	}
+	h.Fall = fall.Zero
+	h.Fall.SetZonesFromArgs([]string{"example.org."})
+	h.Next = test.NextHandler(dns.RcodeRefused, nil)
+	if err := h.Run(ctx); err != nil {
+		t.Fatalf("Failed to initialize Cloudflare: %v", err)
+	}
+	if !h.Ready() {
+		t.Errorf("Expected plugin to be ready")
+	}
+
+	tests := []struct {
+		qname      string
+		qtype      uint16
+		wantRcode  int
+		wantAnswer []string
+	}{
+		{"example.org.", dns.TypeA, dns.RcodeSuccess, []string{"example.org.	300	IN	A	1.2.3.4"}},
+		{"example.org.", dns.TypeAAAA, dns.RcodeSuccess, []string{"example.org.	300	IN	AAAA	2001:db8::1"}},
+		{"www.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"www.example.org.	120	IN	A	1.2.3.5"}},
+		{"alias.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"alias.example.org.	300	IN	CNAME	example.org.", "example.org.	300	IN	A	1.2.3.4"}},
+		{"example.org.", dns.TypeMX, dns.RcodeSuccess, []string{"example.org.	300	IN	MX	10 mail.example.org."}},
+		{"txt.example.org.", dns.TypeTXT, dns.RcodeSuccess, []string{"txt.example.org.	300	IN	TXT	\"v=spf1 -all\""}},
+		{"_sip._tcp.example.org.", dns.TypeSRV, dns.RcodeSuccess, []string{"_sip._tcp.example.org.	300	IN	SRV	10 20 5060 sip.example.org."}},
+		{"example.org.", dns.TypeCAA, dns.RcodeSuccess, []string{"example.org.	300	IN	CAA	0 issue \"letsencrypt.org\""}},
+		{"example.org.", dns.TypeSOA, dns.RcodeSuccess, []string{"example.org.	300	IN	SOA	tom.ns.cloudflare.com. hostmaster.example.org. 1 7200 1800 1209600 300"}},
+		{"proxied.example.org.", dns.TypeA, dns.RcodeRefused, nil}, // fallthrough
+		{"swag.example.org.", dns.TypeA, dns.RcodeRefused, nil},
+		{"example.net.", dns.TypeA, dns.RcodeRefused, nil},
+	}
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		code, err := h.ServeDNS(ctx, rec, req)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg != nil {
+			code = rec.Msg.Rcode
+		}
+		if code != tc.wantRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.wantRcode], dns.RcodeToString[code])
+			continue
+		}
+		if rec.Msg == nil {
+			continue
+		}
+		if len(rec.Msg.Answer) != len(tc.wantAnswer) {
+			t.Errorf("Test %d: expected %d answers, got %v", i, len(tc.wantAnswer), rec.Msg.Answer)
+			continue
+		}
+		for j, rr := range rec.Msg.Answer {
+			if rr.String() != tc.wantAnswer[j] {
+				t.Errorf("Test %d: expected answer %q, got %q", i, tc.wantAnswer[j], rr.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package cloudflare
+
+// Ready implements the ready.Readiness interface.
+func (h *Cloudflare) Ready() bool { return h.zones.Ready() }
@@ -0,0 +1,71 @@
+package cloudflare
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("cloudflare")
+
+func init() { plugin.Register("cloudflare", setup) }
+
+func setup(c *caddy.Controller) error {
+	for c.Next() {
+		zones := c.RemainingArgs()
+		if len(zones) == 0 {
+			return plugin.Error("cloudflare", c.ArgErr())
+		}
+		endpoint := defaultEndpoint
+		token := os.Getenv("CLOUDFLARE_API_TOKEN")
+		opts := cloud.Options{Refresh: time.Minute}
+
+		for c.NextBlock() {
+			if ok, err := opts.Parse(c); ok {
+				if err != nil {
+					return plugin.Error("cloudflare", err)
+				}
+				continue
+			}
+			switch c.Val() {
+			case "token":
+				if !c.NextArg() {
+					return plugin.Error("cloudflare", c.ArgErr())
+				}
+				token = c.Val()
+			case "endpoint":
+				if !c.NextArg() {
+					return plugin.Error("cloudflare", c.ArgErr())
+				}
+				endpoint = c.Val()
+			default:
+				return plugin.Error("cloudflare", c.Errf("unknown property '%s'", c.Val()))
+			}
+		}
+		if token == "" {
+			return plugin.Error("cloudflare", c.Err("no token given and CLOUDFLARE_API_TOKEN not set"))
+		}
+
+		h, err := New(context.Background(), endpoint, token, zones, opts.Refresh)
+		if err != nil {
+			return plugin.Error("cloudflare", c.Errf("failed to create Cloudflare plugin: %v", err))
+		}
+		h.Fall = opts.Fall
+		if err := cloud.Run(c, h.Run); err != nil {
+			return plugin.Error("cloudflare", c.Errf("failed to initialize Cloudflare plugin: %v", err))
+		}
+
+		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+			h.Next = next
+			return h
+		})
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package cloudflare
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetupCloudflare(t *testing.T) {
+	s := newFakeCloudflare()
+	defer s.Close()
+
+	tests := []struct {
+		body          string
+		expectedError bool
+	}{
+		{`cloudflare example.org {
+    token secret
+    endpoint %s
+}`, false},
+		{`cloudflare example.org {
+    token secret
+    endpoint %s
+    fallthrough
+    refresh 90
+}`, false},
+		{`cloudflare {
+    token secret
+    endpoint %s
+}`, true},
+		{`cloudflare example.org {
+    endpoint %s
+}`, true},
+		{`cloudflare example.org {
+    token wrong
+    endpoint %s
+}`, true},
+		{`cloudflare example.com {
+    token secret
+    endpoint %s
+}`, true},
+		{`cloudflare example.org {
+    token secret
+    endpoint %s
+    refresh -1m
+}`, true},
+		{`cloudflare example.org {
+    token secret
+    endpoint %s
+    wat
+}`, true},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", fmt.Sprintf(tc.body, s.URL))
+		if err := setup(c); (err == nil) == tc.expectedError {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedError, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Package digitalocean implements a plugin that returns resource records
+// from DigitalOcean DNS.
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
+)
+
+// DigitalOcean is a plugin that returns RR from DigitalOcean DNS.
+type DigitalOcean struct {
+	Next plugin.Handler
+	Fall fall.F
+
+	client *client
+	zones  *cloud.Zones
+}
+
+// New validates that each of the domains exists in the DigitalOcean account of token, whose API is at endpoint, and
+// returns a new *DigitalOcean that serves them. The zones are synced every refresh.
+func New(ctx context.Context, endpoint, token string, domains []string, refresh time.Duration) (*DigitalOcean, error) {
+	c := newClient(endpoint, token)
+	h := &DigitalOcean{client: c}
+	h.zones = cloud.New("digitalocean", h, refresh)
+	for _, domain := range domains {
+		domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+		resp := struct {
+			Domain struct {
+				Name string `json:"name"`
+			} `json:"domain"`
+		}{}
+		if err := c.get(ctx, "/v2/domains/"+url.PathEscape(domain), &resp); err != nil {
+			return nil, fmt.Errorf("failed to get domain %s: %v", domain, err)
+		}
+		h.zones.Add(domain, domain)
+	}
+	return h, nil
+}
+
+// Run executes first update, spins up an update forever-loop.
+// Returns error if first update fails.
+func (h *DigitalOcean) Run(ctx context.Context) error { return h.zones.Run(ctx) }
+
+// ServeDNS implements the plugin.Handler interface.
+func (h *DigitalOcean) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return h.zones.ServeDNS(ctx, w, r, h.Next, h.Fall)
+}
+
+// record is a domain record as it is returned by the DigitalOcean API.
+type record struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Data     string `json:"data"`
+	Priority int    `json:"priority"`
+	Port     int    `json:"port"`
+	TTL      uint32 `json:"ttl"`
+	Weight   int    `json:"weight"`
+	Flags    int    `json:"flags"`
+	Tag      string `json:"tag"`
+}
+
+// Records implements cloud.Provider, it lists the records of the domain id.
+func (h *DigitalOcean) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	origin := dns.Fqdn(id)
+	var (
+		rrs []dns.RR
+		ns  string
+		min uint32 = 1800
+	)
+	path := "/v2/domains/" + url.PathEscape(id) + "/records?per_page=200"
+	for path != "" {
+		resp := struct {
+			Records []record `json:"domain_records"`
+			Links   struct {
+				Pages struct {
+					Next string `json:"next"`
+				} `json:"pages"`
+			} `json:"links"`
+		}{}
+		if err := h.client.get(ctx, path, &resp); err != nil {
+			return nil, err
+		}
+		for _, r := range resp.Records {
+			switch r.Type {
+			case "SOA":
+				// The API only returns the negative caching TTL of the SOA record.
+				if r.TTL > 0 {
+					min = r.TTL
+				}
+				continue
+			case "NS":
+				if ns == "" && (r.Name == "@" || r.Name == "") {
+					ns = absolute(r.Data, origin)
+				}
+			}
+			rr, err := r.rr(origin)
+			if err != nil {
+				// Maybe unsupported record type. Log and carry on.
+				log.Warningf("Failed to process domain record: %v", err)
+				continue
+			}
+			rrs = append(rrs, rr)
+		}
+
+		path = ""
+		if next := resp.Links.Pages.Next; next != "" {
+			u, err := url.Parse(next)
+			if err != nil {
+				return nil, fmt.Errorf("invalid next page %q: %v", next, err)
+			}
+			path = u.RequestURI()
+		}
+	}
+	return append(rrs, cloud.SOA(origin, ns, min)), nil
+}
+
+// rr returns r as a dns.RR, names in the record are relative to origin.
+func (r record) rr(origin string) (dns.RR, error) {
+	name := origin
+	if r.Name != "@" && r.Name != "" {
+		name = r.Name + "." + origin
+	}
+	var rfc1035 string
+	switch r.Type {
+	case "A", "AAAA":
+		rfc1035 = fmt.Sprintf("%s %d IN %s %s", name, r.TTL, r.Type, r.Data)
+	case "CNAME", "NS":
+		rfc1035 = fmt.Sprintf("%s %d IN %s %s", name, r.TTL, r.Type, absolute(r.Data, origin))
+	case "MX":
+		rfc1035 = fmt.Sprintf("%s %d IN MX %d %s", name, r.TTL, r.Priority, absolute(r.Data, origin))
+	case "SRV":
+		rfc1035 = fmt.Sprintf("%s %d IN SRV %d %d %d %s", name, r.TTL, r.Priority, r.Weight, r.Port, absolute(r.Data, origin))
+	case "CAA":
+		rfc1035 = fmt.Sprintf("%s %d IN CAA %d %s %q", name, r.TTL, r.Flags, r.Tag, r.Data)
+	case "TXT":
+		return cloud.TXT(name, r.TTL, r.Data), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s for %s", r.Type, name)
+	}
+	rr, err := dns.NewRR(rfc1035)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse resource record: %v", err)
+	}
+	return rr, nil
+}
+
+// absolute returns the hostname name in the data of a record as a fully qualified name. The API uses "@" for origin,
+// and returns hostnames fully qualified, with or without the trailing dot, or relative to the domain.
+func absolute(name, origin string) string {
+	switch {
+	case name == "@" || name == "":
+		return origin
+	case dns.IsFqdn(name):
+		return name
+	case strings.Contains(name, "."):
+		return dns.Fqdn(name)
+	}
+	return name + "." + origin
+}
+
+// Name implements the Handler interface.
+func (h *DigitalOcean) Name() string { return "digitalocean" }
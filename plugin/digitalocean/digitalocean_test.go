@@ -0,0 +1,159 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// fakeDigitalOcean is a stand-in for the DigitalOcean API, it serves the domains and domain records it is given.
+// Records are returned in pages of two.
+type fakeDigitalOcean struct {
+	token   string
+	domains map[string][]record
+}
+
+func (f *fakeDigitalOcean) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"id":"unauthorized","message":"Unable to authenticate you"}`)
+		return
+	}
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/v2/domains/"), "/")
+	records, ok := f.domains[path[0]]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"id":"not_found","message":"The resource you were accessing could not be found."}`)
+		return
+	}
+	if len(path) == 1 {
+		json.NewEncoder(w).Encode(map[string]interface{}{"domain": map[string]interface{}{"name": path[0], "ttl": 1800}})
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	start, end := (page-1)*2, page*2
+	if end > len(records) {
+		end = len(records)
+	}
+	resp := map[string]interface{}{"domain_records": records[start:end], "meta": map[string]int{"total": len(records)}}
+	if end < len(records) {
+		next := fmt.Sprintf("https://api.digitalocean.com/v2/domains/%s/records?page=%d&per_page=2", path[0], page+1)
+		resp["links"] = map[string]interface{}{"pages": map[string]string{"next": next}}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func newFakeDigitalOcean() *httptest.Server {
+	return httptest.NewServer(&fakeDigitalOcean{
+		token: "secret",
+		domains: map[string][]record{
+			"example.org": {
+				{Type: "SOA", Name: "@", Data: "1800", TTL: 1800},
+				{Type: "NS", Name: "@", Data: "ns1.digitalocean.com", TTL: 1800},
+				{Type: "A", Name: "@", Data: "1.2.3.4", TTL: 300},
+				{Type: "AAAA", Name: "@", Data: "2001:db8::1", TTL: 300},
+				{Type: "A", Name: "www", Data: "1.2.3.5", TTL: 300},
+				{Type: "CNAME", Name: "alias", Data: "@", TTL: 300},
+				{Type: "CNAME", Name: "ext", Data: "www.example.net.", TTL: 300},
+				{Type: "MX", Name: "@", Data: "mail", Priority: 10, TTL: 300},
+				{Type: "TXT", Name: "txt", Data: "v=spf1 -all", TTL: 300},
+				{Type: "SRV", Name: "_sip._tcp", Data: "sip.example.org", Priority: 10, Weight: 20, Port: 5060, TTL: 300},
+				{Type: "CAA", Name: "@", Data: "letsencrypt.org", Flags: 0, Tag: "issue", TTL: 300},
+				// Unsupported type should be ignored.
+				{Type: "YOLO", Name: "swag", Data: "foobar", TTL: 300},
+			},
+		},
+	})
+}
+
+func TestDigitalOcean(t *testing.T) {
+	s := newFakeDigitalOcean()
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := New(ctx, s.URL, "secret", []string{"example.com"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a domain that doesn't exist")
+	}
+	if _, err := New(ctx, s.URL, "wrong", []string{"example.org"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a wrong token")
+	}
+
+	h, err := New(ctx, s.URL, "secret", []string{"example.org."}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create DigitalOcean: %v", err)
+	}
+	h.Fall = fall.Zero
+	h.Fall.SetZonesFromArgs([]string{"example.org."})
+	h.Next = test.NextHandler(dns.RcodeRefused, nil)
+	if err := h.Run(ctx); err != nil {
+		t.Fatalf("Failed to initialize DigitalOcean: %v", err)
+	}
+	if !h.Ready() {
+		t.Errorf("Expected plugin to be ready")
+	}
+
+	tests := []struct {
+		qname      string
+		qtype      uint16
+		wantRcode  int
+		wantAnswer []string
+	}{
+		{"example.org.", dns.TypeA, dns.RcodeSuccess, []string{"example.org.	300	IN	A	1.2.3.4"}},
+		{"example.org.", dns.TypeAAAA, dns.RcodeSuccess, []string{"example.org.	300	IN	AAAA	2001:db8::1"}},
+		{"www.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"www.example.org.	300	IN	A	1.2.3.5"}},
+		{"alias.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"alias.example.org.	300	IN	CNAME	example.org.", "example.org.	300	IN	A	1.2.3.4"}},
+		{"ext.example.org.", dns.TypeCNAME, dns.RcodeSuccess, []string{"ext.example.org.	300	IN	CNAME	www.example.net."}},
+		{"example.org.", dns.TypeMX, dns.RcodeSuccess, []string{"example.org.	300	IN	MX	10 mail.example.org."}},
+		{"txt.example.org.", dns.TypeTXT, dns.RcodeSuccess, []string{"txt.example.org.	300	IN	TXT	\"v=spf1 -all\""}},
+		{"_sip._tcp.example.org.", dns.TypeSRV, dns.RcodeSuccess, []string{"_sip._tcp.example.org.	300	IN	SRV	10 20 5060 sip.example.org."}},
+		{"example.org.", dns.TypeCAA, dns.RcodeSuccess, []string{"example.org.	300	IN	CAA	0 issue \"letsencrypt.org\""}},
+		{"example.org.", dns.TypeSOA, dns.RcodeSuccess, []string{"example.org.	1800	IN	SOA	ns1.digitalocean.com. hostmaster.example.org. 1 7200 1800 1209600 1800"}},
+		{"swag.example.org.", dns.TypeA, dns.RcodeRefused, nil}, // fallthrough
+		{"example.net.", dns.TypeA, dns.RcodeRefused, nil},
+	}
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		code, err := h.ServeDNS(ctx, rec, req)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg != nil {
+			code = rec.Msg.Rcode
+		}
+		if code != tc.wantRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.wantRcode], dns.RcodeToString[code])
+			continue
+		}
+		if rec.Msg == nil {
+			continue
+		}
+		if len(rec.Msg.Answer) != len(tc.wantAnswer) {
+			t.Errorf("Test %d: expected %d answers, got %v", i, len(tc.wantAnswer), rec.Msg.Answer)
+			continue
+		}
+		for j, rr := range rec.Msg.Answer {
+			if rr.String() != tc.wantAnswer[j] {
+				t.Errorf("Test %d: expected answer %q, got %q", i, tc.wantAnswer[j], rr.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+package digitalocean
+
+// Ready implements the ready.Readiness interface.
+func (h *DigitalOcean) Ready() bool { return h.zones.Ready() }
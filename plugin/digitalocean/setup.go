@@ -0,0 +1,71 @@
+package digitalocean
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("digitalocean")
+
+func init() { plugin.Register("digitalocean", setup) }
+
+func setup(c *caddy.Controller) error {
+	for c.Next() {
+		domains := c.RemainingArgs()
+		if len(domains) == 0 {
+			return plugin.Error("digitalocean", c.ArgErr())
+		}
+		endpoint := defaultEndpoint
+		token := os.Getenv("DIGITALOCEAN_TOKEN")
+		opts := cloud.Options{Refresh: time.Minute}
+
+		for c.NextBlock() {
+			if ok, err := opts.Parse(c); ok {
+				if err != nil {
+					return plugin.Error("digitalocean", err)
+				}
+				continue
+			}
+			switch c.Val() {
+			case "token":
+				if !c.NextArg() {
+					return plugin.Error("digitalocean", c.ArgErr())
+				}
+				token = c.Val()
+			case "endpoint":
+				if !c.NextArg() {
+					return plugin.Error("digitalocean", c.ArgErr())
+				}
+				endpoint = c.Val()
+			default:
+				return plugin.Error("digitalocean", c.Errf("unknown property '%s'", c.Val()))
+			}
+		}
+		if token == "" {
+			return plugin.Error("digitalocean", c.Err("no token given and DIGITALOCEAN_TOKEN not set"))
+		}
+
+		h, err := New(context.Background(), endpoint, token, domains, opts.Refresh)
+		if err != nil {
+			return plugin.Error("digitalocean", c.Errf("failed to create DigitalOcean plugin: %v", err))
+		}
+		h.Fall = opts.Fall
+		if err := cloud.Run(c, h.Run); err != nil {
+			return plugin.Error("digitalocean", c.Errf("failed to initialize DigitalOcean plugin: %v", err))
+		}
+
+		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+			h.Next = next
+			return h
+		})
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package digitalocean
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetupDigitalOcean(t *testing.T) {
+	s := newFakeDigitalOcean()
+	defer s.Close()
+
+	tests := []struct {
+		body          string
+		expectedError bool
+	}{
+		{`digitalocean example.org {
+    token secret
+    endpoint %s
+}`, false},
+		{`digitalocean example.org {
+    token secret
+    endpoint %s
+    fallthrough
+    refresh 90
+}`, false},
+		{`digitalocean {
+    token secret
+    endpoint %s
+}`, true},
+		{`digitalocean example.org {
+    endpoint %s
+}`, true},
+		{`digitalocean example.org {
+    token wrong
+    endpoint %s
+}`, true},
+		{`digitalocean example.com {
+    token secret
+    endpoint %s
+}`, true},
+		{`digitalocean example.org {
+    token secret
+    endpoint %s
+    refresh -1m
+}`, true},
+		{`digitalocean example.org {
+    token secret
+    endpoint %s
+    wat
+}`, true},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", fmt.Sprintf(tc.body, s.URL))
+		if err := setup(c); (err == nil) == tc.expectedError {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedError, err)
+		}
+	}
+}
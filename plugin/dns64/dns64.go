@@ -11,6 +11,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 	"github.com/coredns/coredns/plugin/pkg/nonwriter"
 	"github.com/coredns/coredns/plugin/pkg/response"
 	"github.com/coredns/coredns/request"
@@ -29,12 +30,30 @@ type DNS64 struct {
 	Prefix       *net.IPNet
 	TranslateAll bool // Not comply with 5.1.1
 	Upstream     UpstreamInt
+
+	// ClientPrefixes are used instead of Prefix for the clients in their networks.
+	ClientPrefixes []ClientPrefix
+	// ExcludeV4 holds the IPv4 networks whose addresses are not synthesized from.
+	ExcludeV4 []*net.IPNet
+	// ExcludeV6 holds the IPv6 networks whose AAAA records are treated as absent, see RFC 6147 5.1.4.
+	ExcludeV6 []*net.IPNet
+}
+
+// ClientPrefix is a prefix that is used for the clients in the Clients networks.
+type ClientPrefix struct {
+	Prefix  *net.IPNet
+	Clients []*net.IPNet
 }
 
 // ServeDNS implements the plugin.Handler interface.
 func (d *DNS64) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	if prefix, addr := d.reverseInPrefix(state); prefix != nil {
+		return d.servePTR(ctx, w, r, prefix, addr)
+	}
+
 	// Don't proxy if we don't need to.
-	if !requestShouldIntercept(&request.Request{W: w, Req: r}) {
+	if !requestShouldIntercept(&state) {
 		return d.Next.ServeDNS(ctx, w, r)
 	}
 
@@ -104,9 +123,9 @@ func (d *DNS64) responseShouldDNS64(origResponse *dns.Msg) bool {
 		return true
 	}
 
-	// if response includes AAAA record, no need to rewrite
+	// if response includes AAAA record, no need to rewrite, unless it is excluded. See RFC 6147 5.1.4
 	for _, rr := range origResponse.Answer {
-		if rr.Header().Rrtype == dns.TypeAAAA {
+		if rr.Header().Rrtype == dns.TypeAAAA && !contains(d.ExcludeV6, rr.(*dns.AAAA).AAAA) {
 			return false
 		}
 	}
@@ -116,17 +135,22 @@ func (d *DNS64) responseShouldDNS64(origResponse *dns.Msg) bool {
 // DoDNS64 takes an (empty) response to an AAAA question, issues the A request,
 // and synthesizes the answer. Returns the response message, or error on internal failure.
 func (d *DNS64) DoDNS64(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, origResponse *dns.Msg) (*dns.Msg, error) {
-	req := request.Request{W: w, Req: r} // req is unused
+	req := request.Request{W: w, Req: r}
 	resp, err := d.Upstream.Lookup(ctx, req, req.Name(), dns.TypeA)
 	if err != nil {
 		return nil, err
 	}
-	out := d.Synthesize(r, origResponse, resp)
+	out := d.synthesize(d.prefixFor(req), r, origResponse, resp)
 	return out, nil
 }
 
 // Synthesize merges the AAAA response and the records from the A response
 func (d *DNS64) Synthesize(origReq, origResponse, resp *dns.Msg) *dns.Msg {
+	return d.synthesize(d.Prefix, origReq, origResponse, resp)
+}
+
+// synthesize merges the AAAA response and the records from the A response, using prefix.
+func (d *DNS64) synthesize(prefix *net.IPNet, origReq, origResponse, resp *dns.Msg) *dns.Msg {
 	ret := dns.Msg{}
 	ret.SetReply(origReq)
 
@@ -153,7 +177,12 @@ func (d *DNS64) Synthesize(origReq, origResponse, resp *dns.Msg) *dns.Msg {
 			continue
 		}
 
-		aaaa, _ := to6(d.Prefix, rr.(*dns.A).A)
+		// 5.1.4: Addresses that are excluded MUST NOT be synthesized from
+		if contains(d.ExcludeV4, rr.(*dns.A).A) {
+			continue
+		}
+
+		aaaa, _ := to6(prefix, rr.(*dns.A).A)
 
 		// ttl is min of SOA TTL and A TTL
 		ttl := SOATtl
@@ -175,6 +204,97 @@ func (d *DNS64) Synthesize(origReq, origResponse, resp *dns.Msg) *dns.Msg {
 	return &ret
 }
 
+// prefixFor returns the prefix to synthesize with for the client of state.
+func (d *DNS64) prefixFor(state request.Request) *net.IPNet {
+	ip := net.ParseIP(state.IP())
+	for _, cp := range d.ClientPrefixes {
+		if contains(cp.Clients, ip) {
+			return cp.Prefix
+		}
+	}
+	return d.Prefix
+}
+
+// reverseInPrefix returns the prefix and the address of a PTR query for an address in the ip6.arpa zone that is in
+// one of the prefixes. It returns nil if the query isn't for such an address.
+func (d *DNS64) reverseInPrefix(state request.Request) (*net.IPNet, net.IP) {
+	if state.QType() != dns.TypePTR || state.QClass() != dns.ClassINET || !dns.IsSubDomain(ip6arpa, state.Name()) {
+		return nil, nil
+	}
+	addr := net.ParseIP(dnsutil.ExtractAddressFromReverse(state.Name()))
+	if addr == nil {
+		return nil, nil
+	}
+	for _, cp := range d.ClientPrefixes {
+		if cp.Prefix.Contains(addr) {
+			return cp.Prefix, addr
+		}
+	}
+	if d.Prefix != nil && d.Prefix.Contains(addr) {
+		return d.Prefix, addr
+	}
+	return nil, nil
+}
+
+// servePTR answers a PTR query for an address in prefix with a CNAME to the name of the IPv4 address in the
+// in-addr.arpa zone, followed by the PTR records of that name. See RFC 6147 5.3.1. If the rest of the plugin chain has
+// PTR records for the address, these are used instead.
+func (d *DNS64) servePTR(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, prefix *net.IPNet, addr net.IP) (int, error) {
+	nw := nonwriter.New(w)
+	origRc, origErr := d.Next.ServeDNS(ctx, nw, r)
+	if nw.Msg != nil {
+		for _, rr := range nw.Msg.Answer {
+			if rr.Header().Rrtype == dns.TypePTR {
+				w.WriteMsg(nw.Msg)
+				return origRc, origErr
+			}
+		}
+	}
+
+	state := request.Request{W: w, Req: r}
+	target, err := dns.ReverseAddr(to4(prefix, addr).String())
+	if err != nil {
+		return dns.RcodeServerFailure, err
+	}
+	resp, err := d.Upstream.Lookup(ctx, state, target, dns.TypePTR)
+	if err != nil {
+		return dns.RcodeServerFailure, err
+	}
+
+	// The TTL of the CNAME is the minimum of the TTL of the PTR records and 600, like for AAAA records.
+	ttl := uint32(600)
+	for _, rr := range resp.Answer {
+		if rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+		}
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Rcode = resp.Rcode
+	m.Answer = append([]dns.RR{&dns.CNAME{
+		Hdr:    dns.RR_Header{Name: state.QName(), Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: ttl},
+		Target: target,
+	}}, resp.Answer...)
+	m.Ns = resp.Ns
+
+	RequestsTranslatedCount.WithLabelValues(metrics.WithServer(ctx)).Inc()
+	w.WriteMsg(m)
+	return m.Rcode, nil
+}
+
+// contains returns true if ip is in one of the networks.
+func contains(networks []*net.IPNet, ip net.IP) bool {
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+const ip6arpa = "ip6.arpa."
+
 // to6 takes a prefix and IPv4 address and returns an IPv6 address according to RFC 6052.
 func to6(prefix *net.IPNet, addr net.IP) (net.IP, error) {
 	addr = addr.To4()
@@ -202,3 +322,25 @@ func to6(prefix *net.IPNet, addr net.IP) (net.IP, error) {
 
 	return v6, nil
 }
+
+// to4 takes a prefix and an IPv6 address in it and returns the embedded IPv4 address according to RFC 6052, it's the
+// opposite of to6.
+func to4(prefix *net.IPNet, addr net.IP) net.IP {
+	addr = addr.To16()
+	n, _ := prefix.Mask.Size()
+	// Assumes prefix has been validated during setup
+	v4 := make(net.IP, 4)
+	i, j := n/8, 0
+
+	for ; i < 8 && j < 4; i, j = i+1, j+1 {
+		v4[j] = addr[i]
+	}
+	if i == 8 {
+		i++
+	}
+	for ; j < 4; i, j = i+1, j+1 {
+		v4[j] = addr[i]
+	}
+
+	return v4
+}
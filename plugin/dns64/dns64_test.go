@@ -56,6 +56,31 @@ func TestTo6(t *testing.T) {
 	}
 }
 
+func TestTo4(t *testing.T) {
+	tests := []struct {
+		prefix string
+		v6     string
+		v4     string
+	}{
+		{"64:ff9b::/96", "64:ff9b::4040:4040", "64.64.64.64"},
+		{"64:ff9b::/64", "64:ff9b::40:4040:4000:0", "64.64.64.64"},
+		{"64:ff9b::/56", "64:ff9b:0:40:40:4040::", "64.64.64.64"},
+		{"64::/32", "64:0:4040:4040::", "64.64.64.64"},
+		{"64::/40", "64:0:c0:a801:2::", "192.168.1.2"},
+	}
+	for i, tc := range tests {
+		_, pref, _ := net.ParseCIDR(tc.prefix)
+		v4 := to4(pref, net.ParseIP(tc.v6))
+		if v4.String() != tc.v4 {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.v4, v4)
+		}
+		v6, _ := to6(pref, v4)
+		if !v6.Equal(net.ParseIP(tc.v6)) {
+			t.Errorf("Test %d: expected %s back, got %s", i, tc.v6, v6)
+		}
+	}
+}
+
 func TestResponseShould(t *testing.T) {
 	var tests = []struct {
 		resp         dns.Msg
@@ -448,3 +473,153 @@ func (fu *fakeUpstream) Lookup(_ context.Context, _ request.Request, name string
 
 	return fu.resp, nil
 }
+
+func TestDNS64Exclude(t *testing.T) {
+	_, pfx, _ := net.ParseCIDR("64:ff9b::/96")
+	_, private, _ := net.ParseCIDR("10.0.0.0/8")
+	_, mapped, _ := net.ParseCIDR("::ffff:0:0/96")
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeAAAA)
+	initResp := new(dns.Msg)
+	initResp.SetReply(req)
+	initResp.Answer = []dns.RR{test.AAAA("example.com. 60 IN AAAA ::ffff:192.0.2.1")}
+	aResp := new(dns.Msg)
+	aResp.SetReply(req)
+	aResp.Answer = []dns.RR{
+		test.A("example.com. 60 IN A 10.0.0.1"),
+		test.A("example.com. 60 IN A 192.0.2.1"),
+	}
+
+	d := DNS64{
+		Next:      &fakeHandler{t, initResp},
+		Prefix:    pfx,
+		Upstream:  &fakeUpstream{t, "example.com.", aResp},
+		ExcludeV4: []*net.IPNet{private},
+		ExcludeV6: []*net.IPNet{mapped},
+	}
+
+	rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "::1"})
+	if _, err := d.ServeDNS(context.Background(), rec, req); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Msg.Answer) != 1 {
+		t.Fatalf("Expected 1 answer, got %v", rec.Msg.Answer)
+	}
+	if aaaa := rec.Msg.Answer[0].(*dns.AAAA).AAAA.String(); aaaa != "64:ff9b::c000:201" {
+		t.Errorf("Expected 64:ff9b::c000:201, got %s", aaaa)
+	}
+}
+
+func TestDNS64ClientPrefix(t *testing.T) {
+	_, pfx, _ := net.ParseCIDR("64:ff9b::/96")
+	_, clientPfx, _ := net.ParseCIDR("2001:db8:64::/96")
+	_, clients, _ := net.ParseCIDR("2001:db8:1::/48")
+
+	tests := []struct {
+		remote string
+		aaaa   string
+	}{
+		{"2001:db8:1::53", "2001:db8:64::c000:201"},
+		{"2001:db8:2::53", "64:ff9b::c000:201"},
+	}
+
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion("example.com.", dns.TypeAAAA)
+		initResp := new(dns.Msg)
+		initResp.SetReply(req)
+		aResp := new(dns.Msg)
+		aResp.SetReply(req)
+		aResp.Answer = []dns.RR{test.A("example.com. 60 IN A 192.0.2.1")}
+
+		d := DNS64{
+			Next:           &fakeHandler{t, initResp},
+			Prefix:         pfx,
+			Upstream:       &fakeUpstream{t, "example.com.", aResp},
+			ClientPrefixes: []ClientPrefix{{Prefix: clientPfx, Clients: []*net.IPNet{clients}}},
+		}
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.remote})
+		if _, err := d.ServeDNS(context.Background(), rec, req); err != nil {
+			t.Fatalf("Test %d: %v", i, err)
+		}
+		if len(rec.Msg.Answer) != 1 {
+			t.Fatalf("Test %d: expected 1 answer, got %v", i, rec.Msg.Answer)
+		}
+		if aaaa := rec.Msg.Answer[0].(*dns.AAAA).AAAA.String(); aaaa != tc.aaaa {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.aaaa, aaaa)
+		}
+	}
+}
+
+func TestDNS64PTR(t *testing.T) {
+	_, pfx, _ := net.ParseCIDR("64:ff9b::/96")
+
+	tests := []struct {
+		qname    string
+		nextResp []dns.RR
+		answer   []dns.RR
+	}{
+		{
+			// synthesized from the PTR of the IPv4 address
+			qname: "1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa.",
+			answer: []dns.RR{
+				test.CNAME("1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa. 300 IN CNAME 1.2.0.192.in-addr.arpa."),
+				test.PTR("1.2.0.192.in-addr.arpa. 300 IN PTR example.com."),
+			},
+		},
+		{
+			// the rest of the chain has a PTR record
+			qname:    "1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa.",
+			nextResp: []dns.RR{test.PTR("1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa. 60 IN PTR nat64.example.com.")},
+			answer:   []dns.RR{test.PTR("1.0.2.0.0.0.0.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.b.9.f.f.4.6.0.0.ip6.arpa. 60 IN PTR nat64.example.com.")},
+		},
+		{
+			// not in the prefix
+			qname:    "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+			nextResp: []dns.RR{test.PTR("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 60 IN PTR host.example.org.")},
+			answer:   []dns.RR{test.PTR("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. 60 IN PTR host.example.org.")},
+		},
+	}
+
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, dns.TypePTR)
+		nextResp := new(dns.Msg)
+		nextResp.SetReply(req)
+		nextResp.Answer = tc.nextResp
+		ptrResp := new(dns.Msg)
+		ptrResp.Answer = []dns.RR{test.PTR("1.2.0.192.in-addr.arpa. 300 IN PTR example.com.")}
+
+		d := DNS64{
+			Next:     &fakeHandler{t, nextResp},
+			Prefix:   pfx,
+			Upstream: &fakePTRUpstream{t, "1.2.0.192.in-addr.arpa.", ptrResp},
+		}
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: "::1"})
+		if _, err := d.ServeDNS(context.Background(), rec, req); err != nil {
+			t.Fatalf("Test %d: %v", i, err)
+		}
+		if err := test.Section(test.Case{Answer: tc.answer}, test.Answer, rec.Msg.Answer); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+type fakePTRUpstream struct {
+	t     *testing.T
+	qname string
+	resp  *dns.Msg
+}
+
+func (fu *fakePTRUpstream) Lookup(_ context.Context, _ request.Request, name string, typ uint16) (*dns.Msg, error) {
+	if name != fu.qname {
+		fu.t.Fatalf("Wrong PTR lookup for %s, expected %s", name, fu.qname)
+	}
+	if typ != dns.TypePTR {
+		fu.t.Fatalf("Wrong lookup type %d, expected %d", typ, dns.TypePTR)
+	}
+	return fu.resp, nil
+}
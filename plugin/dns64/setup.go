@@ -1,7 +1,9 @@
 package dns64
 
 import (
+	"fmt"
 	"net"
+	"strings"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -40,9 +42,12 @@ func setup(c *caddy.Controller) error {
 
 func dns64Parse(c *caddy.Controller) (*DNS64, error) {
 	_, defaultPref, _ := net.ParseCIDR("64:ff9b::/96")
+	// IPv4-mapped addresses are excluded by default, see RFC 6147 5.1.4.
+	_, mapped, _ := net.ParseCIDR("::ffff:0:0/96")
 	dns64 := &DNS64{
-		Upstream: upstream.New(),
-		Prefix:   defaultPref,
+		Upstream:  upstream.New(),
+		Prefix:    defaultPref,
+		ExcludeV6: []*net.IPNet{mapped},
 	}
 
 	for c.Next() {
@@ -71,7 +76,36 @@ func dns64Parse(c *caddy.Controller) (*DNS64, error) {
 				if err != nil {
 					return nil, err
 				}
-				dns64.Prefix = pref
+				clients := c.RemainingArgs()
+				if len(clients) == 0 {
+					dns64.Prefix = pref
+					continue
+				}
+				cp := ClientPrefix{Prefix: pref}
+				for _, client := range clients {
+					n, err := parseNetwork(client)
+					if err != nil {
+						return nil, c.Errf("invalid client network %q", client)
+					}
+					cp.Clients = append(cp.Clients, n)
+				}
+				dns64.ClientPrefixes = append(dns64.ClientPrefixes, cp)
+			case "exclude":
+				networks := c.RemainingArgs()
+				if len(networks) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, network := range networks {
+					n, err := parseNetwork(network)
+					if err != nil {
+						return nil, c.Errf("invalid network to exclude %q", network)
+					}
+					if n.IP.To4() != nil {
+						dns64.ExcludeV4 = append(dns64.ExcludeV4, n)
+					} else {
+						dns64.ExcludeV6 = append(dns64.ExcludeV6, n)
+					}
+				}
 			case "translate_all":
 				dns64.TranslateAll = true
 			default:
@@ -99,3 +133,19 @@ func parsePrefix(c *caddy.Controller, addr string) (*net.IPNet, error) {
 
 	return pref, nil
 }
+
+// parseNetwork parses a network in CIDR notation, or a single address.
+func parseNetwork(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	return n, err
+}
@@ -124,3 +124,51 @@ func TestSetupDns64(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupDns64Options(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		clientPrefixes int
+		excludeV4      int
+		excludeV6      int
+	}{
+		{`dns64`, false, 0, 0, 1},
+		{`dns64 {
+			prefix 2001:db8:64::/96 2001:db8:1::/48 192.0.2.1
+			prefix 2001:db8:65::/96 2001:db8:2::/48
+		}`, false, 2, 0, 1},
+		{`dns64 {
+			exclude 10.0.0.0/8 172.16.0.0/12 192.168.0.0/16 2001:db8::/32
+		}`, false, 0, 3, 2},
+		{`dns64 {
+			exclude
+		}`, true, 0, 0, 0},
+		{`dns64 {
+			exclude 10.0.0.0/33
+		}`, true, 0, 0, 0},
+		{`dns64 {
+			prefix 2001:db8:64::/96 foo
+		}`, true, 0, 0, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		dns64, err := dns64Parse(c)
+		if (err != nil) != test.shouldErr {
+			t.Errorf("Test %d expected %v error, got %v for %s", i+1, test.shouldErr, err, test.input)
+		}
+		if err != nil {
+			continue
+		}
+		if len(dns64.ClientPrefixes) != test.clientPrefixes {
+			t.Errorf("Test %d expected %d client prefixes, got %d", i+1, test.clientPrefixes, len(dns64.ClientPrefixes))
+		}
+		if len(dns64.ExcludeV4) != test.excludeV4 {
+			t.Errorf("Test %d expected %d IPv4 exclusions, got %d", i+1, test.excludeV4, len(dns64.ExcludeV4))
+		}
+		if len(dns64.ExcludeV6) != test.excludeV6 {
+			t.Errorf("Test %d expected %d IPv6 exclusions, got %d", i+1, test.excludeV6, len(dns64.ExcludeV6))
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package etcd
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/reuseport"
+
+	"github.com/miekg/dns"
+	etcdcv3 "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+)
+
+const (
+	apiTimeout     = 10 * time.Second // maximum duration of reading a request, or of writing a response after etcd replied
+	apiIdleTimeout = time.Minute      // maximum duration of an idle keep-alive connection
+)
+
+// api is an HTTP API that writes the service records of the plugin to etcd, so that clients don't need to know
+// how names map to keys. Records can be bound to an etcd lease, so they are removed when it expires.
+type api struct {
+	Addr  string
+	token string      // bearer token clients must present
+	tls   *tls.Config // if not nil, the API is served over HTTPS
+
+	zones  []string
+	prefix string
+	kv     etcdcv3.KV
+	lease  etcdcv3.Lease
+
+	ln      net.Listener
+	srv     *http.Server
+	nlSetup bool
+}
+
+// record is a service record as it is returned by the API.
+type record struct {
+	Name    string       `json:"name"`
+	Key     string       `json:"key"`
+	Lease   int64        `json:"lease,omitempty"`
+	Service *msg.Service `json:"service"`
+}
+
+// lease is an etcd lease as it is returned by, and given to, the API.
+type lease struct {
+	ID  int64 `json:"id,omitempty"`
+	TTL int64 `json:"ttl"`
+}
+
+// OnStartup starts serving the API.
+func (a *api) OnStartup() error {
+	ln, err := reuseport.Listen("tcp", a.Addr)
+	if err != nil {
+		return err
+	}
+	if a.tls != nil {
+		ln = tls.NewListener(ln, a.tls)
+	}
+	a.ln = ln
+	a.nlSetup = true
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/records/", a.authorized(a.records))
+	mux.HandleFunc("/v1/leases", a.authorized(a.grant))
+	mux.HandleFunc("/v1/leases/", a.authorized(a.leases))
+
+	// The timeouts keep slow clients from holding on to connections.
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: apiTimeout,
+		ReadTimeout:       apiTimeout,
+		WriteTimeout:      apiTimeout + etcdTimeout,
+		IdleTimeout:       apiIdleTimeout,
+	}
+	a.srv = srv
+	go func() { srv.Serve(ln) }()
+	return nil
+}
+
+// OnFinalShutdown stops serving the API.
+func (a *api) OnFinalShutdown() error {
+	if !a.nlSetup {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	a.srv.Shutdown(ctx)
+	a.ln.Close()
+	a.nlSetup = false
+	return nil
+}
+
+// authorized only calls f for requests with the bearer token of the API.
+func (a *api) authorized(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+a.token)) != 1 {
+			reply(w, r, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+		f(w, r)
+	}
+}
+
+// records handles GET, PUT and DELETE of /v1/records/NAME.
+func (a *api) records(w http.ResponseWriter, r *http.Request) {
+	name := dns.Fqdn(strings.ToLower(strings.TrimPrefix(r.URL.Path, "/v1/records/")))
+	if _, ok := dns.IsDomainName(name); !ok || name == "." {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("invalid name %q", name))
+		return
+	}
+	if plugin.Zones(a.zones).Matches(name) == "" {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("name %q is not in the zones of the plugin", name))
+		return
+	}
+	key := msg.Path(name, a.prefix)
+
+	ctx, cancel := context.WithTimeout(r.Context(), etcdTimeout)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet:
+		a.getRecords(ctx, w, r, key)
+	case http.MethodPut:
+		a.putRecord(ctx, w, r, name, key)
+	case http.MethodDelete:
+		a.deleteRecords(ctx, w, r, key)
+	default:
+		reply(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// getRecords returns the record at key and all records below it.
+func (a *api) getRecords(ctx context.Context, w http.ResponseWriter, r *http.Request, key string) {
+	kvs := []*etcdcv3.GetResponse{}
+	for _, get := range []struct {
+		key  string
+		opts []etcdcv3.OpOption
+	}{{key, nil}, {key + "/", []etcdcv3.OpOption{etcdcv3.WithPrefix()}}} {
+		resp, err := a.kv.Get(ctx, get.key, get.opts...)
+		if err != nil {
+			reply(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		kvs = append(kvs, resp)
+	}
+
+	records := []record{}
+	for _, resp := range kvs {
+		for _, kv := range resp.Kvs {
+			serv := new(msg.Service)
+			if err := json.Unmarshal(kv.Value, serv); err != nil {
+				reply(w, r, http.StatusInternalServerError, fmt.Errorf("%s: %s", kv.Key, err))
+				return
+			}
+			records = append(records, record{Name: msg.Domain(string(kv.Key)), Key: string(kv.Key), Lease: kv.Lease, Service: serv})
+		}
+	}
+	if len(records) == 0 {
+		reply(w, r, http.StatusNotFound, errKeyNotFound)
+		return
+	}
+	reply(w, r, http.StatusOK, records)
+}
+
+// putRecord creates or updates the record at key. With the lease query parameter the record is bound to that
+// lease, with the ttl query parameter a new lease with that TTL in seconds is granted for it. The TTL of the
+// record is capped to that of its lease.
+func (a *api) putRecord(ctx context.Context, w http.ResponseWriter, r *http.Request, name, key string) {
+	serv := new(msg.Service)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(serv); err != nil {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("invalid service: %s", err))
+		return
+	}
+	if serv.Host == "" && serv.Text == "" {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("service needs a host or a text"))
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("lease") != "" && q.Get("ttl") != "" {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("lease and ttl are mutually exclusive"))
+		return
+	}
+
+	var (
+		id  etcdcv3.LeaseID
+		ttl int64
+	)
+	switch {
+	case q.Get("lease") != "":
+		i, err := strconv.ParseInt(q.Get("lease"), 10, 64)
+		if err != nil {
+			reply(w, r, http.StatusBadRequest, fmt.Errorf("invalid lease %q", q.Get("lease")))
+			return
+		}
+		resp, err := a.lease.TimeToLive(ctx, etcdcv3.LeaseID(i))
+		if err != nil {
+			reply(w, r, leaseStatus(err), err)
+			return
+		}
+		if resp.TTL < 0 {
+			reply(w, r, http.StatusNotFound, rpctypes.ErrLeaseNotFound)
+			return
+		}
+		id, ttl = etcdcv3.LeaseID(i), resp.GrantedTTL
+	case q.Get("ttl") != "":
+		t, err := strconv.ParseInt(q.Get("ttl"), 10, 64)
+		if err != nil || t <= 0 {
+			reply(w, r, http.StatusBadRequest, fmt.Errorf("invalid ttl %q", q.Get("ttl")))
+			return
+		}
+		resp, err := a.lease.Grant(ctx, t)
+		if err != nil {
+			reply(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		id, ttl = resp.ID, resp.TTL
+	}
+	if ttl > 0 && (serv.TTL == 0 || int64(serv.TTL) > ttl) {
+		serv.TTL = uint32(ttl)
+	}
+
+	b, err := json.Marshal(serv)
+	if err != nil {
+		reply(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	opts := []etcdcv3.OpOption{}
+	if id != etcdcv3.NoLease {
+		opts = append(opts, etcdcv3.WithLease(id))
+	}
+	if _, err := a.kv.Put(ctx, key, string(b), opts...); err != nil {
+		reply(w, r, leaseStatus(err), err)
+		return
+	}
+	reply(w, r, http.StatusOK, record{Name: name, Key: key, Lease: int64(id), Service: serv})
+}
+
+// deleteRecords deletes the record at key. With the recursive query parameter set to true, all records below it
+// are deleted as well.
+func (a *api) deleteRecords(ctx context.Context, w http.ResponseWriter, r *http.Request, key string) {
+	recursive, _ := strconv.ParseBool(r.URL.Query().Get("recursive"))
+
+	resp, err := a.kv.Delete(ctx, key)
+	if err != nil {
+		reply(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	deleted := resp.Deleted
+	if recursive {
+		resp, err := a.kv.Delete(ctx, key+"/", etcdcv3.WithPrefix())
+		if err != nil {
+			reply(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		deleted += resp.Deleted
+	}
+	if deleted == 0 {
+		reply(w, r, http.StatusNotFound, errKeyNotFound)
+		return
+	}
+	reply(w, r, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// grant handles POST of /v1/leases, it grants a new lease.
+func (a *api) grant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		reply(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	l := lease{}
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil || l.TTL <= 0 {
+		reply(w, r, http.StatusBadRequest, fmt.Errorf("lease needs a positive ttl"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), etcdTimeout)
+	defer cancel()
+	resp, err := a.lease.Grant(ctx, l.TTL)
+	if err != nil {
+		reply(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	reply(w, r, http.StatusOK, lease{ID: int64(resp.ID), TTL: resp.TTL})
+}
+
+// leases handles POST of /v1/leases/ID/keepalive, which renews a lease, and DELETE of /v1/leases/ID, which revokes
+// it and deletes the records bound to it.
+func (a *api) leases(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/leases/")
+	keepalive := strings.HasSuffix(path, "/keepalive")
+	i, err := strconv.ParseInt(strings.TrimSuffix(path, "/keepalive"), 10, 64)
+	if err != nil {
+		reply(w, r, http.StatusNotFound, fmt.Errorf("invalid lease %q", path))
+		return
+	}
+	id := etcdcv3.LeaseID(i)
+
+	ctx, cancel := context.WithTimeout(r.Context(), etcdTimeout)
+	defer cancel()
+
+	switch {
+	case keepalive && r.Method == http.MethodPost:
+		resp, err := a.lease.KeepAliveOnce(ctx, id)
+		if err != nil {
+			reply(w, r, leaseStatus(err), err)
+			return
+		}
+		reply(w, r, http.StatusOK, lease{ID: int64(resp.ID), TTL: resp.TTL})
+	case !keepalive && r.Method == http.MethodDelete:
+		if _, err := a.lease.Revoke(ctx, id); err != nil {
+			reply(w, r, leaseStatus(err), err)
+			return
+		}
+		reply(w, r, http.StatusOK, lease{ID: int64(id)})
+	default:
+		reply(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	}
+}
+
+// leaseStatus returns the HTTP status for err, an error returned by etcd.
+func leaseStatus(err error) int {
+	if err == rpctypes.ErrLeaseNotFound {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// reply writes v as JSON with status code to w. If v is an error, it is written as {"error": "..."}.
+func reply(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	APIRequestCount.WithLabelValues(r.Method, strconv.Itoa(code)).Inc()
+	if err, ok := v.(error); ok {
+		if code == http.StatusInternalServerError {
+			log.Errorf("API request %s %s failed: %s", r.Method, r.URL.Path, err)
+		}
+		v = map[string]string{"error": err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,246 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+
+	etcdcv3 "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+func TestAPIAuthorization(t *testing.T) {
+	a := newTestAPI()
+	for _, auth := range []string{"", "Bearer", "Bearer wrong", "secret", "Basic secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/records/a.skydns.test", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		if code, _ := serve(a, req); code != http.StatusUnauthorized {
+			t.Errorf("Expected %d for Authorization %q, got %d", http.StatusUnauthorized, auth, code)
+		}
+	}
+}
+
+func TestAPIRecords(t *testing.T) {
+	a := newTestAPI()
+
+	tests := []struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string // substring of the expected response
+	}{
+		{http.MethodPut, "/v1/records/a.skydns.test", `{"host":"10.0.0.1","port":80}`, http.StatusOK, `"key":"/skydns/test/skydns/a"`},
+		{http.MethodPut, "/v1/records/x1.b.skydns.test", `{"host":"10.0.0.2"}`, http.StatusOK, `"name":"x1.b.skydns.test."`},
+		{http.MethodPut, "/v1/records/x2.b.skydns.test.", `{"text":"hello"}`, http.StatusOK, `"text":"hello"`},
+		{http.MethodGet, "/v1/records/A.skydns.test", "", http.StatusOK, `"host":"10.0.0.1","port":80`},
+		{http.MethodGet, "/v1/records/b.skydns.test", "", http.StatusOK, `"key":"/skydns/test/skydns/b/x2"`},
+		{http.MethodGet, "/v1/records/c.skydns.test", "", http.StatusNotFound, `"error"`},
+		// Invalid requests.
+		{http.MethodPut, "/v1/records/a.example.org", `{"host":"10.0.0.1"}`, http.StatusBadRequest, "not in the zones"},
+		{http.MethodPut, "/v1/records/a..skydns.test", `{"host":"10.0.0.1"}`, http.StatusBadRequest, "invalid name"},
+		{http.MethodPut, "/v1/records/a.skydns.test", `{"port":80}`, http.StatusBadRequest, "host or a text"},
+		{http.MethodPut, "/v1/records/a.skydns.test", `{"host":"10.0.0.1","hots":"x"}`, http.StatusBadRequest, "invalid service"},
+		{http.MethodPut, "/v1/records/a.skydns.test?ttl=0", `{"host":"10.0.0.1"}`, http.StatusBadRequest, "invalid ttl"},
+		{http.MethodPut, "/v1/records/a.skydns.test?ttl=10&lease=1", `{"host":"10.0.0.1"}`, http.StatusBadRequest, "mutually exclusive"},
+		{http.MethodPost, "/v1/records/a.skydns.test", `{"host":"10.0.0.1"}`, http.StatusMethodNotAllowed, "not allowed"},
+		// Delete.
+		{http.MethodDelete, "/v1/records/b.skydns.test", "", http.StatusNotFound, `"error"`},
+		{http.MethodDelete, "/v1/records/b.skydns.test?recursive=true", "", http.StatusOK, `"deleted":2`},
+		{http.MethodDelete, "/v1/records/a.skydns.test", "", http.StatusOK, `"deleted":1`},
+		{http.MethodGet, "/v1/records/a.skydns.test", "", http.StatusNotFound, `"error"`},
+	}
+
+	for i, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		code, body := serve(a, req)
+		if code != tc.expectedCode {
+			t.Errorf("Test %d: expected code %d, got %d: %s", i, tc.expectedCode, code, body)
+		}
+		if !strings.Contains(body, tc.expectedBody) {
+			t.Errorf("Test %d: expected body to contain %q, got %s", i, tc.expectedBody, body)
+		}
+	}
+}
+
+func TestAPILeases(t *testing.T) {
+	a := newTestAPI()
+
+	tests := []struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string // substring of the expected response
+	}{
+		{http.MethodPost, "/v1/leases", `{"ttl":30}`, http.StatusOK, `{"id":1,"ttl":30}`},
+		{http.MethodPost, "/v1/leases", `{"ttl":0}`, http.StatusBadRequest, "positive ttl"},
+		// The TTL of the record is capped to that of the lease.
+		{http.MethodPut, "/v1/records/a.skydns.test?lease=1", `{"host":"10.0.0.1","ttl":60}`, http.StatusOK, `"lease":1,"service":{"host":"10.0.0.1","ttl":30}`},
+		{http.MethodPut, "/v1/records/b.skydns.test?ttl=10", `{"host":"10.0.0.2"}`, http.StatusOK, `"lease":2,"service":{"host":"10.0.0.2","ttl":10}`},
+		{http.MethodPut, "/v1/records/c.skydns.test?ttl=10", `{"host":"10.0.0.3","ttl":5}`, http.StatusOK, `"lease":3,"service":{"host":"10.0.0.3","ttl":5}`},
+		{http.MethodPut, "/v1/records/a.skydns.test?lease=99", `{"host":"10.0.0.1"}`, http.StatusNotFound, "lease not found"},
+		{http.MethodPost, "/v1/leases/1/keepalive", "", http.StatusOK, `{"id":1,"ttl":30}`},
+		{http.MethodPost, "/v1/leases/99/keepalive", "", http.StatusNotFound, "lease not found"},
+		{http.MethodGet, "/v1/leases/1/keepalive", "", http.StatusMethodNotAllowed, "not allowed"},
+		{http.MethodDelete, "/v1/leases/1", "", http.StatusOK, `{"id":1,"ttl":0}`},
+		{http.MethodDelete, "/v1/leases/1", "", http.StatusNotFound, "lease not found"},
+		{http.MethodDelete, "/v1/leases/x", "", http.StatusNotFound, "invalid lease"},
+	}
+
+	for i, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Authorization", "Bearer secret")
+		code, body := serve(a, req)
+		if code != tc.expectedCode {
+			t.Errorf("Test %d: expected code %d, got %d: %s", i, tc.expectedCode, code, body)
+		}
+		if !strings.Contains(body, tc.expectedBody) {
+			t.Errorf("Test %d: expected body to contain %q, got %s", i, tc.expectedBody, body)
+		}
+	}
+
+	// The lease of the record is returned.
+	req := httptest.NewRequest(http.MethodGet, "/v1/records/b.skydns.test", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	_, body := serve(a, req)
+	records := []record{}
+	if err := json.Unmarshal([]byte(body), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Lease != 2 || *records[0].Service != (msg.Service{Host: "10.0.0.2", TTL: 10}) {
+		t.Errorf("Unexpected records: %s", body)
+	}
+}
+
+func newTestAPI() *api {
+	leases := &fakeLease{leases: map[etcdcv3.LeaseID]int64{}}
+	return &api{
+		token:  "secret",
+		zones:  []string{"skydns.test."},
+		prefix: "skydns",
+		kv:     &fakeKV{kvs: map[string]*mvccpb.KeyValue{}, leases: leases},
+		lease:  leases,
+	}
+}
+
+func serve(a *api, req *http.Request) (int, string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/records/", a.authorized(a.records))
+	mux.HandleFunc("/v1/leases", a.authorized(a.grant))
+	mux.HandleFunc("/v1/leases/", a.authorized(a.leases))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+// fakeKV is an in-memory etcdcv3.KV, that only implements what the API uses.
+type fakeKV struct {
+	etcdcv3.KV
+	kvs    map[string]*mvccpb.KeyValue
+	leases *fakeLease
+}
+
+func (f *fakeKV) match(op etcdcv3.Op) []string {
+	key, end := string(op.KeyBytes()), string(op.RangeBytes())
+	keys := []string{}
+	for k := range f.kvs {
+		if k == key || (end != "" && k >= key && k < end) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeKV) Get(ctx context.Context, key string, opts ...etcdcv3.OpOption) (*etcdcv3.GetResponse, error) {
+	resp := &etcdcv3.GetResponse{}
+	for _, k := range f.match(etcdcv3.OpGet(key, opts...)) {
+		resp.Kvs = append(resp.Kvs, f.kvs[k])
+	}
+	resp.Count = int64(len(resp.Kvs))
+	return resp, nil
+}
+
+func (f *fakeKV) Put(ctx context.Context, key, val string, opts ...etcdcv3.OpOption) (*etcdcv3.PutResponse, error) {
+	kv := &mvccpb.KeyValue{Key: []byte(key), Value: []byte(val)}
+	// The only option the API uses is WithLease, which can't be inspected. It is always the lease that was last
+	// granted or looked up.
+	if len(opts) > 0 {
+		kv.Lease = int64(f.leases.last)
+	}
+	f.kvs[key] = kv
+	return &etcdcv3.PutResponse{}, nil
+}
+
+func (f *fakeKV) Delete(ctx context.Context, key string, opts ...etcdcv3.OpOption) (*etcdcv3.DeleteResponse, error) {
+	keys := f.match(etcdcv3.OpDelete(key, opts...))
+	// Not using delete, as the tests with the etcd build tag redefine it.
+	remaining := map[string]*mvccpb.KeyValue{}
+Keys:
+	for k, kv := range f.kvs {
+		for _, d := range keys {
+			if k == d {
+				continue Keys
+			}
+		}
+		remaining[k] = kv
+	}
+	f.kvs = remaining
+	return &etcdcv3.DeleteResponse{Deleted: int64(len(keys))}, nil
+}
+
+// fakeLease is an in-memory etcdcv3.Lease, that only implements what the API uses.
+type fakeLease struct {
+	etcdcv3.Lease
+	leases map[etcdcv3.LeaseID]int64
+	last   etcdcv3.LeaseID
+}
+
+func (f *fakeLease) Grant(ctx context.Context, ttl int64) (*etcdcv3.LeaseGrantResponse, error) {
+	f.last++
+	f.leases[f.last] = ttl
+	return &etcdcv3.LeaseGrantResponse{ID: f.last, TTL: ttl}, nil
+}
+
+func (f *fakeLease) TimeToLive(ctx context.Context, id etcdcv3.LeaseID, opts ...etcdcv3.LeaseOption) (*etcdcv3.LeaseTimeToLiveResponse, error) {
+	ttl, ok := f.leases[id]
+	if !ok {
+		return &etcdcv3.LeaseTimeToLiveResponse{ID: id, TTL: -1}, nil
+	}
+	f.last = id
+	return &etcdcv3.LeaseTimeToLiveResponse{ID: id, TTL: ttl, GrantedTTL: ttl}, nil
+}
+
+func (f *fakeLease) KeepAliveOnce(ctx context.Context, id etcdcv3.LeaseID) (*etcdcv3.LeaseKeepAliveResponse, error) {
+	ttl, ok := f.leases[id]
+	if !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	return &etcdcv3.LeaseKeepAliveResponse{ID: id, TTL: ttl}, nil
+}
+
+func (f *fakeLease) Revoke(ctx context.Context, id etcdcv3.LeaseID) (*etcdcv3.LeaseRevokeResponse, error) {
+	if _, ok := f.leases[id]; !ok {
+		return nil, rpctypes.ErrLeaseNotFound
+	}
+	leases := map[etcdcv3.LeaseID]int64{}
+	for i, ttl := range f.leases {
+		if i != id {
+			leases[i] = ttl
+		}
+	}
+	f.leases = leases
+	return &etcdcv3.LeaseRevokeResponse{}, nil
+}
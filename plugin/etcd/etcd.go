@@ -37,7 +37,10 @@ type Etcd struct {
 	Upstream   *upstream.Upstream
 	Client     *etcdcv3.Client
 
-	endpoints []string // Stored here as well, to aid in testing.
+	endpoints []string      // Stored here as well, to aid in testing.
+	index     *index        // In-memory copy of the key space, nil if the key space isn't watched.
+	maxStale  time.Duration // Maximum staleness of the index for the plugin to be ready, 0 for no maximum.
+	api       *api          // Write API, nil if it isn't enabled.
 }
 
 // Services implements the ServiceBackend interface.
@@ -72,12 +75,27 @@ func (e *Etcd) Records(ctx context.Context, state request.Request, exact bool) (
 	name := state.Name()
 
 	path, star := msg.PathWithWildcard(name, e.PathPrefix)
-	r, err := e.get(ctx, path, !exact)
+	kvs, err := e.getKVs(ctx, path, !exact)
 	if err != nil {
 		return nil, err
 	}
 	segments := strings.Split(msg.Path(name, e.PathPrefix), "/")
-	return e.loopNodes(r.Kvs, segments, star, state.QType())
+	return e.loopNodes(kvs, segments, star, state.QType())
+}
+
+// getKVs returns the key-values for path from the index, or from etcd if the key space isn't watched or the index
+// isn't loaded yet.
+func (e *Etcd) getKVs(ctx context.Context, path string, recursive bool) ([]*mvccpb.KeyValue, error) {
+	if e.index != nil {
+		if kvs, ok, err := e.index.get(path, recursive); ok {
+			return kvs, err
+		}
+	}
+	r, err := e.get(ctx, path, recursive)
+	if err != nil {
+		return nil, err
+	}
+	return r.Kvs, nil
 }
 
 func (e *Etcd) get(ctx context.Context, path string, recursive bool) (*etcdcv3.GetResponse, error) {
@@ -156,22 +174,12 @@ Nodes:
 	return sx, nil
 }
 
-// TTL returns the smaller of the etcd TTL and the service's
-// TTL. If neither of these are set (have a zero value), a default is used.
+// TTL returns the service's TTL. If it isn't set (has a zero value), a default is used. The lease of kv is an ID,
+// not a TTL, so it isn't used; records without a lease are not affected by that. Records bound to a lease should
+// have a TTL that doesn't exceed that of the lease, the write API takes care of that.
 func (e *Etcd) TTL(kv *mvccpb.KeyValue, serv *msg.Service) uint32 {
-	etcdTTL := uint32(kv.Lease)
-
-	if etcdTTL == 0 && serv.TTL == 0 {
-		return ttl
-	}
-	if etcdTTL == 0 {
-		return serv.TTL
-	}
 	if serv.TTL == 0 {
-		return etcdTTL
-	}
-	if etcdTTL < serv.TTL {
-		return etcdTTL
+		return ttl
 	}
 	return serv.TTL
 }
@@ -0,0 +1,50 @@
+package etcd
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		lease    int64
+		ttl      uint32
+		expected uint32
+	}{
+		{0, 60, 60},
+		{0, 0, ttl},
+		// The lease is an ID, it must not be taken for a TTL.
+		{0x694d77d4c6b1b30a, 60, 60},
+		{30, 60, 60},
+		{0x694d77d4c6b1b30a, 0, ttl},
+	}
+	e := &Etcd{}
+	for i, tc := range tests {
+		kv := &mvccpb.KeyValue{Key: []byte("/skydns/test/skydns/a"), Lease: tc.lease}
+		if got := e.TTL(kv, &msg.Service{Host: "10.0.0.1", TTL: tc.ttl}); got != tc.expected {
+			t.Errorf("Test %d: expected TTL %d, got %d", i, tc.expected, got)
+		}
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"127.0.0.1:8053", true},
+		{"[::1]:8053", true},
+		{"localhost:8053", true},
+		{":8053", false},
+		{"10.0.0.1:8053", false},
+		{"8053", false},
+	}
+	for i, tc := range tests {
+		if got := isLoopback(tc.addr); got != tc.expected {
+			t.Errorf("Test %d: expected %t for %s, got %t", i, tc.expected, tc.addr, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package etcd
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	etcdcv3 "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+// index is an in-memory copy of the key space of the plugin, kept current with an etcd watch.
+type index struct {
+	sync.RWMutex
+	kvs      []*mvccpb.KeyValue // sorted on key
+	revision int64              // etcd revision the index is current with
+	loaded   bool
+	lastSync time.Time // last time the index was known to be current with etcd
+}
+
+// load replaces the contents of the index with kvs, as of revision.
+func (i *index) load(kvs []*mvccpb.KeyValue, revision int64, now time.Time) {
+	sorted := make([]*mvccpb.KeyValue, len(kvs))
+	copy(sorted, kvs)
+	sort.Slice(sorted, func(a, b int) bool { return bytes.Compare(sorted[a].Key, sorted[b].Key) < 0 })
+
+	i.Lock()
+	defer i.Unlock()
+	i.kvs, i.revision, i.loaded, i.lastSync = sorted, revision, true, now
+}
+
+// apply applies the watched events to the index, which is then current with revision.
+func (i *index) apply(events []*etcdcv3.Event, revision int64, now time.Time) {
+	i.Lock()
+	defer i.Unlock()
+	for _, ev := range events {
+		switch ev.Type {
+		case etcdcv3.EventTypePut:
+			i.put(ev.Kv)
+		case etcdcv3.EventTypeDelete:
+			i.delete(ev.Kv.Key)
+		}
+	}
+	if revision > i.revision {
+		i.revision = revision
+	}
+	i.lastSync = now
+}
+
+func (i *index) search(key []byte) int {
+	return sort.Search(len(i.kvs), func(j int) bool { return bytes.Compare(i.kvs[j].Key, key) >= 0 })
+}
+
+func (i *index) put(kv *mvccpb.KeyValue) {
+	j := i.search(kv.Key)
+	if j < len(i.kvs) && bytes.Equal(i.kvs[j].Key, kv.Key) {
+		i.kvs[j] = kv
+		return
+	}
+	i.kvs = append(i.kvs, nil)
+	copy(i.kvs[j+1:], i.kvs[j:])
+	i.kvs[j] = kv
+}
+
+func (i *index) delete(key []byte) {
+	j := i.search(key)
+	if j < len(i.kvs) && bytes.Equal(i.kvs[j].Key, key) {
+		i.kvs = append(i.kvs[:j], i.kvs[j+1:]...)
+	}
+}
+
+// get returns the key-values for path, like Etcd.get does, but from memory. If the index isn't loaded yet, ok is false.
+func (i *index) get(path string, recursive bool) (kvs []*mvccpb.KeyValue, ok bool, err error) {
+	i.RLock()
+	defer i.RUnlock()
+	if !i.loaded {
+		return nil, false, nil
+	}
+
+	if recursive {
+		if !strings.HasSuffix(path, "/") {
+			path = path + "/"
+		}
+		prefix := []byte(path)
+		for j := i.search(prefix); j < len(i.kvs) && bytes.HasPrefix(i.kvs[j].Key, prefix); j++ {
+			kvs = append(kvs, i.kvs[j])
+		}
+		if len(kvs) > 0 {
+			return kvs, true, nil
+		}
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	key := []byte(path)
+	if j := i.search(key); j < len(i.kvs) && bytes.Equal(i.kvs[j].Key, key) {
+		return []*mvccpb.KeyValue{i.kvs[j]}, true, nil
+	}
+	return nil, true, errKeyNotFound
+}
+
+// stale returns for how long the index hasn't been known to be current with etcd. If the index isn't loaded yet,
+// ok is false.
+func (i *index) stale(now time.Time) (d time.Duration, ok bool) {
+	i.RLock()
+	defer i.RUnlock()
+	if !i.loaded {
+		return 0, false
+	}
+	return now.Sub(i.lastSync), true
+}
+
+// size returns the number of keys in the index and the revision it is current with.
+func (i *index) size() (int, int64) {
+	i.RLock()
+	defer i.RUnlock()
+	return len(i.kvs), i.revision
+}
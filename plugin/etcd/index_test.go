@@ -0,0 +1,119 @@
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	etcdcv3 "go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+func TestIndexGet(t *testing.T) {
+	i := new(index)
+	if _, ok, _ := i.get("/skydns/test/a", false); ok {
+		t.Fatalf("Expected index that isn't loaded not to be used")
+	}
+
+	i.load([]*mvccpb.KeyValue{
+		kv("/skydns/test/skydns/b"),
+		kv("/skydns/test/skydns/a/x"),
+		kv("/skydns/test/skydns/a/y"),
+		kv("/skydns/test/skydns/a1"),
+	}, 10, time.Now())
+
+	tests := []struct {
+		path      string
+		recursive bool
+		expected  []string
+	}{
+		{"/skydns/test/skydns/a", true, []string{"/skydns/test/skydns/a/x", "/skydns/test/skydns/a/y"}},
+		{"/skydns/test/skydns/a/", true, []string{"/skydns/test/skydns/a/x", "/skydns/test/skydns/a/y"}},
+		{"/skydns/test/skydns/a1", true, []string{"/skydns/test/skydns/a1"}},
+		{"/skydns/test/skydns/a", false, nil},
+		{"/skydns/test/skydns/b", false, []string{"/skydns/test/skydns/b"}},
+		{"/skydns/test/skydns/c", true, nil},
+	}
+	for j, tc := range tests {
+		kvs, ok, err := i.get(tc.path, tc.recursive)
+		if !ok {
+			t.Fatalf("Test %d: expected loaded index to be used", j)
+		}
+		if tc.expected == nil {
+			if err != errKeyNotFound {
+				t.Errorf("Test %d: expected %s, got %v", j, errKeyNotFound, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", j, err)
+			continue
+		}
+		if len(kvs) != len(tc.expected) {
+			t.Errorf("Test %d: expected %d keys, got %d", j, len(tc.expected), len(kvs))
+			continue
+		}
+		for k := range kvs {
+			if string(kvs[k].Key) != tc.expected[k] {
+				t.Errorf("Test %d: expected key %s, got %s", j, tc.expected[k], kvs[k].Key)
+			}
+		}
+	}
+}
+
+func TestIndexApply(t *testing.T) {
+	i := new(index)
+	i.load([]*mvccpb.KeyValue{kv("/skydns/test/b"), kv("/skydns/test/d")}, 10, time.Now())
+
+	i.apply([]*etcdcv3.Event{
+		{Type: etcdcv3.EventTypePut, Kv: kv("/skydns/test/c")},
+		{Type: etcdcv3.EventTypePut, Kv: kv("/skydns/test/a")},
+		{Type: etcdcv3.EventTypeDelete, Kv: kv("/skydns/test/d")},
+		{Type: etcdcv3.EventTypeDelete, Kv: kv("/skydns/test/e")},
+		{Type: etcdcv3.EventTypePut, Kv: &mvccpb.KeyValue{Key: []byte("/skydns/test/b"), Value: []byte("new")}},
+	}, 12, time.Now())
+
+	expected := []string{"/skydns/test/a", "/skydns/test/b", "/skydns/test/c"}
+	kvs, _, _ := i.get("/skydns/test", true)
+	if len(kvs) != len(expected) {
+		t.Fatalf("Expected %d keys, got %d", len(expected), len(kvs))
+	}
+	for j := range kvs {
+		if string(kvs[j].Key) != expected[j] {
+			t.Errorf("Expected key %s, got %s", expected[j], kvs[j].Key)
+		}
+	}
+	if string(kvs[1].Value) != "new" {
+		t.Errorf("Expected updated value for %s, got %s", kvs[1].Key, kvs[1].Value)
+	}
+	if keys, revision := i.size(); keys != 3 || revision != 12 {
+		t.Errorf("Expected 3 keys at revision 12, got %d keys at revision %d", keys, revision)
+	}
+}
+
+func TestReady(t *testing.T) {
+	e := &Etcd{}
+	if !e.Ready() {
+		t.Errorf("Expected plugin without watch to be ready")
+	}
+
+	e = &Etcd{index: new(index), maxStale: time.Minute}
+	if e.Ready() {
+		t.Errorf("Expected plugin to not be ready before the index is loaded")
+	}
+	e.index.load(nil, 1, time.Now())
+	if !e.Ready() {
+		t.Errorf("Expected plugin to be ready after the index is loaded")
+	}
+	e.index.load(nil, 1, time.Now().Add(-2*time.Minute))
+	if e.Ready() {
+		t.Errorf("Expected plugin to not be ready when the index is stale")
+	}
+	e.maxStale = 0
+	if !e.Ready() {
+		t.Errorf("Expected plugin to be ready without a maximum staleness")
+	}
+}
+
+func kv(key string) *mvccpb.KeyValue {
+	return &mvccpb.KeyValue{Key: []byte(key), Value: []byte(`{"host":"10.0.0.1"}`)}
+}
@@ -0,0 +1,47 @@
+package etcd
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics for the in-memory index, only exported when the key space is watched.
+var (
+	// IndexKeys is the number of keys in the index.
+	IndexKeys = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "index_keys",
+		Help:      "The number of keys in the in-memory index.",
+	})
+	// IndexRevision is the etcd revision the index is current with.
+	IndexRevision = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "index_revision",
+		Help:      "The etcd revision the in-memory index is current with.",
+	})
+	// IndexLastSync is the last time the index was known to be current with etcd.
+	IndexLastSync = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "index_last_sync_timestamp_seconds",
+		Help:      "The last time the in-memory index was known to be current with etcd.",
+	})
+	// IndexReloads is the number of times the key space was loaded again after the watch failed.
+	IndexReloads = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "etcd",
+		Name:      "index_reloads_total",
+		Help:      "Counter of reloads of the in-memory index after the watch failed.",
+	})
+)
+
+// APIRequestCount is the number of requests to the write API, by method and HTTP status code.
+var APIRequestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "etcd",
+	Name:      "api_requests_total",
+	Help:      "Counter of requests to the write API per method and status code.",
+}, []string{"method", "code"})
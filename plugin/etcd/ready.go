@@ -0,0 +1,17 @@
+package etcd
+
+import "time"
+
+// Ready implements the ready.Readiness interface. Without watch the plugin is always ready. With watch it is ready
+// once the key space is loaded, and stops being ready when the index hasn't been current with etcd for longer than
+// the maximum staleness.
+func (e *Etcd) Ready() bool {
+	if e.index == nil {
+		return true
+	}
+	stale, ok := e.index.stale(time.Now())
+	if !ok {
+		return false
+	}
+	return e.maxStale == 0 || stale <= e.maxStale
+}
@@ -1,10 +1,14 @@
 package etcd
 
 import (
+	"context"
 	"crypto/tls"
+	"net"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
 	mwtls "github.com/coredns/coredns/plugin/pkg/tls"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 
@@ -20,6 +24,31 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("etcd", err)
 	}
 
+	if e.index != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.OnStartup(func() error {
+			metrics.MustRegister(c, IndexKeys, IndexRevision, IndexLastSync, IndexReloads)
+			go e.watch(ctx)
+			return nil
+		})
+		c.OnShutdown(func() error {
+			cancel()
+			return nil
+		})
+	}
+
+	if e.api != nil {
+		e.api.kv, e.api.lease = e.Client.KV, e.Client.Lease
+		c.OnStartup(func() error {
+			metrics.MustRegister(c, APIRequestCount)
+			return nil
+		})
+		c.OnStartup(e.api.OnStartup)
+		c.OnRestart(e.api.OnFinalShutdown)
+		c.OnFinalShutdown(e.api.OnFinalShutdown)
+		c.OnRestartFailed(e.api.OnStartup)
+	}
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		e.Next = next
 		return e
@@ -87,12 +116,81 @@ func etcdParse(c *caddy.Controller) (*Etcd, error) {
 					return &Etcd{}, c.Errf("credentials requires 2 arguments, username and password")
 				}
 				username, password = args[0], args[1]
+			case "watch":
+				args := c.RemainingArgs()
+				if len(args) > 1 {
+					return &Etcd{}, c.ArgErr()
+				}
+				etc.index = new(index)
+				etc.maxStale = defaultMaxStale
+				if len(args) == 1 {
+					d, err := time.ParseDuration(args[0])
+					if err != nil {
+						return &Etcd{}, c.Errf("invalid maximum staleness %q: %s", args[0], err)
+					}
+					if d < 0 {
+						return &Etcd{}, c.Errf("maximum staleness can not be negative: %s", d)
+					}
+					etc.maxStale = d
+				}
+			case "api":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return &Etcd{}, c.ArgErr()
+				}
+				if _, _, err := net.SplitHostPort(args[0]); err != nil {
+					return &Etcd{}, c.Errf("invalid api address %q: %s", args[0], err)
+				}
+				if etc.api == nil {
+					etc.api = &api{}
+				}
+				etc.api.Addr = args[0]
+			case "api_token":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return &Etcd{}, c.ArgErr()
+				}
+				if etc.api == nil {
+					etc.api = &api{}
+				}
+				etc.api.token = args[0]
+			case "api_tls": // cert key [cacert]
+				args := c.RemainingArgs()
+				if len(args) < 2 || len(args) > 3 {
+					return &Etcd{}, c.ArgErr()
+				}
+				tc, err := mwtls.NewTLSConfigFromArgs(args...)
+				if err != nil {
+					return &Etcd{}, err
+				}
+				if len(args) == 3 {
+					// Clients need a certificate signed by the CA.
+					tc.ClientCAs = tc.RootCAs
+					tc.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+				if etc.api == nil {
+					etc.api = &api{}
+				}
+				etc.api.tls = tc
 			default:
 				if c.Val() != "}" {
 					return &Etcd{}, c.Errf("unknown property '%s'", c.Val())
 				}
 			}
 		}
+		if etc.api != nil {
+			if etc.api.Addr == "" {
+				return &Etcd{}, c.Errf("api_token and api_tls need an api address")
+			}
+			if etc.api.token == "" {
+				return &Etcd{}, c.Errf("api needs an api_token")
+			}
+			if etc.api.tls == nil && !isLoopback(etc.api.Addr) {
+				log.Warningf("The write API on %s is served without api_tls, its token is sent in cleartext", etc.api.Addr)
+			}
+			etc.api.zones, etc.api.prefix = etc.Zones, etc.PathPrefix
+		}
+
 		client, err := newEtcdClient(endpoints, tlsConfig, username, password)
 		if err != nil {
 			return &Etcd{}, err
@@ -121,4 +219,20 @@ func newEtcdClient(endpoints []string, cc *tls.Config, username, password string
 	return cli, nil
 }
 
-const defaultEndpoint = "http://localhost:2379"
+const (
+	defaultEndpoint = "http://localhost:2379"
+	defaultMaxStale = time.Minute
+)
+
+// isLoopback returns true if the host of addr is a loopback address.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -5,6 +5,7 @@ package etcd
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/caddyserver/caddy"
 )
@@ -116,3 +117,114 @@ func TestSetupEtcd(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupWatch(t *testing.T) {
+	tests := []struct {
+		input            string
+		shouldErr        bool
+		expectedWatch    bool
+		expectedMaxStale time.Duration
+	}{
+		{`etcd`, false, false, 0},
+		{`etcd {
+			watch
+		}`, false, true, time.Minute},
+		{`etcd {
+			watch 10s
+		}`, false, true, 10 * time.Second},
+		{`etcd {
+			watch 0
+		}`, false, true, 0},
+		{`etcd {
+			watch -1s
+		}`, true, false, 0},
+		{`etcd {
+			watch forever
+		}`, true, false, 0},
+		{`etcd {
+			watch 10s 20s
+		}`, true, false, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		etcd, err := etcdParse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+		if watch := etcd.index != nil; watch != test.expectedWatch {
+			t.Errorf("Test %d: Expected watch to be %t, got %t", i, test.expectedWatch, watch)
+		}
+		if etcd.maxStale != test.expectedMaxStale {
+			t.Errorf("Test %d: Expected maximum staleness %s, got %s", i, test.expectedMaxStale, etcd.maxStale)
+		}
+	}
+}
+
+func TestSetupAPI(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		addr      string
+	}{
+		{`etcd`, false, ""},
+		{`etcd skydns.test {
+			api :8053
+			api_token secret
+		}`, false, ":8053"},
+		{`etcd {
+			api :8053
+		}`, true, ""},
+		{`etcd {
+			api_token secret
+		}`, true, ""},
+		{`etcd {
+			api 8053
+			api_token secret
+		}`, true, ""},
+		{`etcd {
+			api :8053 :8054
+			api_token secret
+		}`, true, ""},
+		{`etcd {
+			api :8053
+			api_token secret
+			api_tls cert.pem
+		}`, true, ""},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		etcd, err := etcdParse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+		if test.addr == "" {
+			if etcd.api != nil {
+				t.Errorf("Test %d: Expected no api, got one on %s", i, etcd.api.Addr)
+			}
+			continue
+		}
+		if etcd.api == nil || etcd.api.Addr != test.addr {
+			t.Errorf("Test %d: Expected api on %s, got %+v", i, test.addr, etcd.api)
+			continue
+		}
+		if len(etcd.api.zones) != 1 || etcd.api.zones[0] != "skydns.test." || etcd.api.prefix != "skydns" {
+			t.Errorf("Test %d: Expected api for zone skydns.test. and path skydns, got %v and %s", i, etcd.api.zones, etcd.api.prefix)
+		}
+	}
+}
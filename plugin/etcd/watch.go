@@ -0,0 +1,95 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	etcdcv3 "go.etcd.io/etcd/clientv3"
+)
+
+var log = clog.NewWithPlugin("etcd")
+
+var (
+	// progressInterval is how often progress is requested from etcd, to find out if the index is still current.
+	progressInterval = 5 * time.Second
+	// reloadDelay is how long to wait before loading the key space again after a failure.
+	reloadDelay = time.Second
+)
+
+var errWatchClosed = errors.New("watch channel closed")
+
+// prefix returns the prefix of all keys of the plugin.
+func (e *Etcd) prefix() string { return msg.Path(".", e.PathPrefix) + "/" }
+
+// watch keeps the index current until ctx is canceled. It loads the key space and then follows the changes to it
+// with a watch. When the watch fails, for instance because the revision it follows has been compacted, the key
+// space is loaded again.
+func (e *Etcd) watch(ctx context.Context) {
+	for {
+		err := e.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warningf("Failed to watch %q, reloading: %s", e.prefix(), err)
+		IndexReloads.Inc()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reloadDelay):
+		}
+	}
+}
+
+// follow loads the key space in the index, and applies the changes since then until the watch fails.
+func (e *Etcd) follow(ctx context.Context) error {
+	// Without a leader the watch is canceled, instead of silently not receiving changes.
+	ctx, cancel := context.WithCancel(etcdcv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	getCtx, getCancel := context.WithTimeout(ctx, etcdTimeout)
+	r, err := e.Client.Get(getCtx, e.prefix(), etcdcv3.WithPrefix())
+	getCancel()
+	if err != nil {
+		return err
+	}
+	e.index.load(r.Kvs, r.Header.Revision, time.Now())
+	e.updateIndexMetrics()
+
+	wch := e.Client.Watch(ctx, e.prefix(), etcdcv3.WithPrefix(), etcdcv3.WithRev(r.Header.Revision+1), etcdcv3.WithProgressNotify())
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			// The response arrives on the watch channel as a progress notify.
+			progCtx, progCancel := context.WithTimeout(ctx, etcdTimeout)
+			if err := e.Client.RequestProgress(progCtx); err != nil {
+				log.Debugf("Failed to request progress: %s", err)
+			}
+			progCancel()
+		case wr, ok := <-wch:
+			if !ok {
+				return errWatchClosed
+			}
+			if err := wr.Err(); err != nil {
+				return err
+			}
+			e.index.apply(wr.Events, wr.Header.Revision, time.Now())
+			e.updateIndexMetrics()
+		}
+	}
+}
+
+func (e *Etcd) updateIndexMetrics() {
+	keys, revision := e.index.size()
+	IndexKeys.Set(float64(keys))
+	IndexRevision.Set(float64(revision))
+	IndexLastSync.SetToCurrentTime()
+}
@@ -0,0 +1,72 @@
+// +build etcd
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func TestLookupWatch(t *testing.T) {
+	etc := newEtcdPlugin()
+	etc.index = new(index)
+	for _, serv := range services {
+		set(t, etc, serv.Key, 0, serv)
+		defer delete(t, etc, serv.Key)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go etc.watch(ctx)
+	waitFor(t, func() bool { return etc.Ready() })
+
+	for _, tc := range dnsTestCases {
+		m := tc.Msg()
+
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		etc.ServeDNS(ctxt, rec, m)
+
+		resp := rec.Msg
+		if err := test.SortAndCheck(resp, tc); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Changes are picked up by the watch.
+	serv := &msg.Service{Host: "10.0.0.9", Key: "watch.skydns.test."}
+	set(t, etc, serv.Key, 0, serv)
+	defer delete(t, etc, serv.Key)
+	waitFor(t, func() bool {
+		_, err := etc.Records(ctxt, stateFor(serv.Key), true)
+		return err == nil
+	})
+	delete(t, etc, serv.Key)
+	waitFor(t, func() bool {
+		_, err := etc.Records(ctxt, stateFor(serv.Key), true)
+		return err == errKeyNotFound
+	})
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	t.Helper()
+	for i := 0; !f(); i++ {
+		if i == 100 {
+			t.Fatalf("Condition not met in time")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func stateFor(name string) request.Request {
+	m := new(dns.Msg)
+	m.SetQuestion(name, dns.TypeA)
+	return request.Request{W: &test.ResponseWriter{}, Req: m}
+}
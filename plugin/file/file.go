@@ -47,22 +47,14 @@ func (f File) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 
 	// This is only for when we are a secondary zones.
 	if r.Opcode == dns.OpcodeNotify {
-		if z.isNotify(state) {
+		if primary := z.notifier(state); primary != "" {
 			m := new(dns.Msg)
 			m.SetReply(r)
 			m.Authoritative = true
 			w.WriteMsg(m)
 
 			log.Infof("Notify from %s for %s: checking transfer", state.IP(), zone)
-			ok, err := z.shouldTransfer()
-			if ok {
-				z.TransferIn()
-			} else {
-				log.Infof("Notify from %s for %s: no SOA serial increase seen", state.IP(), zone)
-			}
-			if err != nil {
-				log.Warningf("Notify from %s for %s: failed primary check: %s", state.IP(), zone, err)
-			}
+			z.notified(primary)
 			return dns.RcodeSuccess, nil
 		}
 		log.Infof("Dropping notify from %s for %s", state.IP(), zone)
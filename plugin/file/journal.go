@@ -0,0 +1,260 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// Delta holds the differences between two versions of a zone, as identified by their SOA records.
+type Delta struct {
+	From    *dns.SOA
+	To      *dns.SOA
+	Deleted []dns.RR
+	Added   []dns.RR
+}
+
+// RRs returns the delta in the format used in IXFR responses (RFC 1995, Section 4): the old SOA, the
+// deleted records, the new SOA and the added records.
+func (d Delta) RRs() []dns.RR {
+	rrs := make([]dns.RR, 0, len(d.Deleted)+len(d.Added)+2)
+	rrs = append(rrs, d.From)
+	rrs = append(rrs, d.Deleted...)
+	rrs = append(rrs, d.To)
+	rrs = append(rrs, d.Added...)
+	return rrs
+}
+
+// Journal keeps the differences between successive versions of a zone, so that IXFR requests can be answered
+// with just the changes. If path is not empty the journal is also written to disk, so it survives restarts.
+// A journal is protected by the lock of the zone it belongs to.
+type Journal struct {
+	path   string
+	max    int
+	deltas []Delta
+}
+
+// DefaultJournalSize is the number of deltas a journal keeps.
+const DefaultJournalSize = 100
+
+// NewJournal returns a new journal that is stored in path. If path is empty the journal is only kept in memory.
+func NewJournal(path string) *Journal {
+	if path != "" {
+		path = filepath.Clean(path)
+	}
+	return &Journal{path: path, max: DefaultJournalSize}
+}
+
+// Path returns the path of the on-disk journal.
+func (j *Journal) Path() string { return j.path }
+
+// Len returns the number of deltas in the journal.
+func (j *Journal) Len() int { return len(j.deltas) }
+
+// Add appends d to the journal, deltas that don't change the serial are ignored. The oldest deltas are
+// dropped if the journal is full. If configured, the journal is written to disk.
+func (j *Journal) Add(d ...Delta) error {
+	for i := range d {
+		if d[i].From.Serial == d[i].To.Serial {
+			continue
+		}
+		j.deltas = append(j.deltas, d[i])
+	}
+	if len(j.deltas) > j.max {
+		j.deltas = j.deltas[len(j.deltas)-j.max:]
+	}
+	return j.save()
+}
+
+// Reset removes all deltas from the journal.
+func (j *Journal) Reset() error {
+	j.deltas = nil
+	return j.save()
+}
+
+// Since returns the deltas that bring a zone from serial to current. If the journal doesn't
+// contain an unbroken chain of deltas between those serials nil is returned.
+func (j *Journal) Since(serial, current uint32) []Delta {
+	for i := range j.deltas {
+		if j.deltas[i].From.Serial != serial {
+			continue
+		}
+		chain := j.deltas[i:]
+		for k := 1; k < len(chain); k++ {
+			if chain[k].From.Serial != chain[k-1].To.Serial {
+				return nil
+			}
+		}
+		if chain[len(chain)-1].To.Serial != current {
+			return nil
+		}
+		return chain
+	}
+	return nil
+}
+
+// Load reads the journal from disk. It is not an error if the file does not exist. If the last delta
+// in the journal doesn't end at serial, the on-disk history doesn't match the zone and is discarded.
+func (j *Journal) Load(origin string, serial uint32) error {
+	if j.path == "" {
+		return nil
+	}
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	zp := dns.NewZoneParser(f, origin, j.path)
+	rrs := []dns.RR{}
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		rrs = append(rrs, rr)
+	}
+	if err := zp.Err(); err != nil {
+		return err
+	}
+	deltas, err := parseDeltas(rrs)
+	if err != nil {
+		return fmt.Errorf("journal %q: %s", j.path, err)
+	}
+	if len(deltas) > 0 && deltas[len(deltas)-1].To.Serial != serial {
+		log.Warningf("Journal %q for %s does not end at serial %d, discarding", j.path, origin, serial)
+		return j.Reset()
+	}
+	j.deltas = deltas
+	return nil
+}
+
+// save writes the journal to disk, it's a noop when the journal is memory only.
+func (j *Journal) save() error {
+	if j.path == "" {
+		return nil
+	}
+	sb := &strings.Builder{}
+	for _, d := range j.deltas {
+		fmt.Fprintf(sb, "; serial %d -> %d\n", d.From.Serial, d.To.Serial)
+		for _, rr := range d.RRs() {
+			sb.WriteString(rr.String())
+			sb.WriteByte('\n')
+		}
+	}
+	tmp := j.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// parseDeltas parses rrs, formatted as the body of an IXFR response (without the leading and trailing
+// SOA records of the current version), into deltas.
+func parseDeltas(rrs []dns.RR) ([]Delta, error) {
+	deltas := []Delta{}
+	var d *Delta
+	for _, rr := range rrs {
+		soa, ok := rr.(*dns.SOA)
+		switch {
+		case ok && (d == nil || d.To != nil):
+			if d != nil {
+				deltas = append(deltas, *d)
+			}
+			d = &Delta{From: soa}
+		case ok:
+			d.To = soa
+		case d == nil:
+			return nil, fmt.Errorf("record before SOA: %s", rr)
+		case d.To == nil:
+			d.Deleted = append(d.Deleted, rr)
+		default:
+			d.Added = append(d.Added, rr)
+		}
+	}
+	if d != nil {
+		if d.To == nil {
+			return nil, fmt.Errorf("incomplete delta from serial %d", d.From.Serial)
+		}
+		deltas = append(deltas, *d)
+	}
+	return deltas, nil
+}
+
+// Diff returns the differences between the zones a and b.
+func Diff(a, b *Zone) Delta {
+	d := Delta{From: a.Apex.SOA, To: b.Apex.SOA}
+
+	diffRRs(&d, a.Apex.SIGSOA, b.Apex.SIGSOA)
+	diffRRs(&d, a.Apex.NS, b.Apex.NS)
+	diffRRs(&d, a.Apex.SIGNS, b.Apex.SIGNS)
+
+	// Both trees are sorted in canonical order, so walk them side by side.
+	ea, eb := a.Tree.All(), b.Tree.All()
+	i, k := 0, 0
+	for i < len(ea) || k < len(eb) {
+		switch {
+		case k == len(eb):
+			d.Deleted = append(d.Deleted, ea[i].All()...)
+			i++
+		case i == len(ea):
+			d.Added = append(d.Added, eb[k].All()...)
+			k++
+		default:
+			switch c := tree.Less(ea[i], eb[k].Name()); {
+			case c == 0:
+				diffElem(&d, ea[i], eb[k])
+				i++
+				k++
+			case c > 0: // b's name sorts after a's name
+				d.Deleted = append(d.Deleted, ea[i].All()...)
+				i++
+			default:
+				d.Added = append(d.Added, eb[k].All()...)
+				k++
+			}
+		}
+	}
+	return d
+}
+
+// diffElem adds the differences between the records of a and b, which have the same owner name, to d.
+func diffElem(d *Delta, a, b *tree.Elem) {
+	types := map[uint16]struct{}{}
+	for _, t := range a.Types() {
+		types[t] = struct{}{}
+	}
+	for _, t := range b.Types() {
+		types[t] = struct{}{}
+	}
+	for t := range types {
+		diffRRs(d, a.Type(t), b.Type(t))
+	}
+}
+
+// diffRRs adds the records from a that are not in b to the deleted records of d, and the records from b
+// that are not in a to the added records.
+func diffRRs(d *Delta, a, b []dns.RR) {
+	seen := make(map[string]struct{}, len(a))
+	for _, rr := range a {
+		seen[rr.String()] = struct{}{}
+	}
+	inB := make(map[string]struct{}, len(b))
+	for _, rr := range b {
+		s := rr.String()
+		inB[s] = struct{}{}
+		if _, ok := seen[s]; !ok {
+			d.Added = append(d.Added, rr)
+		}
+	}
+	for _, rr := range a {
+		if _, ok := inB[rr.String()]; !ok {
+			d.Deleted = append(d.Deleted, rr)
+		}
+	}
+}
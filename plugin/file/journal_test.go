@@ -0,0 +1,291 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const dbJournalV1 = `
+$TTL    1M
+$ORIGIN example.org.
+@       IN      SOA     ns1 admin 1 1200 180 1209600 30
+        IN      NS      ns1
+ns1     IN      A       127.0.0.1
+a       IN      A       127.0.0.1
+        IN      A       127.0.0.2
+b       IN      TXT     "b"
+`
+
+const dbJournalV2 = `
+$TTL    1M
+$ORIGIN example.org.
+@       IN      SOA     ns1 admin 2 1200 180 1209600 30
+        IN      NS      ns1
+ns1     IN      A       127.0.0.1
+a       IN      A       127.0.0.1
+        IN      A       127.0.0.3
+c       IN      TXT     "c"
+`
+
+const dbJournalV3 = `
+$TTL    1M
+$ORIGIN example.org.
+@       IN      SOA     ns1 admin 3 1200 180 1209600 30
+        IN      NS      ns1
+ns1     IN      A       127.0.0.1
+a       IN      A       127.0.0.3
+c       IN      TXT     "c"
+`
+
+func parseJournalZone(t *testing.T, db string) *Zone {
+	z, err := Parse(strings.NewReader(db), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatalf("Failed to parse zone: %s", err)
+	}
+	return z
+}
+
+func TestDiff(t *testing.T) {
+	d := Diff(parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2))
+	if d.From.Serial != 1 || d.To.Serial != 2 {
+		t.Errorf("Expected delta from 1 to 2, got %d to %d", d.From.Serial, d.To.Serial)
+	}
+
+	deleted := map[string]bool{"a.example.org.\t60\tIN\tA\t127.0.0.2": true, "b.example.org.\t60\tIN\tTXT\t\"b\"": true}
+	added := map[string]bool{"a.example.org.\t60\tIN\tA\t127.0.0.3": true, "c.example.org.\t60\tIN\tTXT\t\"c\"": true}
+	if len(d.Deleted) != len(deleted) {
+		t.Errorf("Expected %d deleted records, got %d", len(deleted), len(d.Deleted))
+	}
+	for _, rr := range d.Deleted {
+		if !deleted[rr.String()] {
+			t.Errorf("Unexpected deleted record: %s", rr)
+		}
+	}
+	if len(d.Added) != len(added) {
+		t.Errorf("Expected %d added records, got %d", len(added), len(d.Added))
+	}
+	for _, rr := range d.Added {
+		if !added[rr.String()] {
+			t.Errorf("Unexpected added record: %s", rr)
+		}
+	}
+}
+
+func TestZoneApply(t *testing.T) {
+	z1, z2 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2)
+	if err := z1.apply(Diff(z1, z2)); err != nil {
+		t.Fatalf("Failed to apply delta: %s", err)
+	}
+	if d := Diff(z1, z2); len(d.Added) != 0 || len(d.Deleted) != 0 {
+		t.Errorf("Expected no differences after applying delta, got %v", d.RRs())
+	}
+	if z1.Apex.SOA.Serial != 2 {
+		t.Errorf("Expected serial %d, got %d", 2, z1.Apex.SOA.Serial)
+	}
+}
+
+func TestJournalSince(t *testing.T) {
+	v1, v2, v3 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2), parseJournalZone(t, dbJournalV3)
+	j := NewJournal("")
+	j.Add(Diff(v1, v2), Diff(v2, v3), Diff(v3, v3))
+
+	if j.Len() != 2 {
+		t.Fatalf("Expected %d deltas, got %d", 2, j.Len())
+	}
+	tests := []struct {
+		serial uint32
+		deltas int
+	}{
+		{1, 2},
+		{2, 1},
+		{0, 0},
+		{3, 0},
+	}
+	for i, tc := range tests {
+		if x := len(j.Since(tc.serial, 3)); x != tc.deltas {
+			t.Errorf("Test %d: expected %d deltas since %d, got %d", i, tc.deltas, tc.serial, x)
+		}
+	}
+	if x := j.Since(1, 4); x != nil {
+		t.Errorf("Expected no deltas when not ending at current serial, got %d", len(x))
+	}
+}
+
+func TestJournalSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "example.org.jnl")
+
+	v1, v2, v3 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2), parseJournalZone(t, dbJournalV3)
+	j := NewJournal(path)
+	if err := j.Add(Diff(v1, v2), Diff(v2, v3)); err != nil {
+		t.Fatalf("Failed to write journal: %s", err)
+	}
+
+	j1 := NewJournal(path)
+	if err := j1.Load("example.org.", 3); err != nil {
+		t.Fatalf("Failed to load journal: %s", err)
+	}
+	if j1.Len() != 2 {
+		t.Fatalf("Expected %d deltas, got %d", 2, j1.Len())
+	}
+	if x := len(j1.Since(1, 3)); x != 2 {
+		t.Errorf("Expected %d deltas since 1, got %d", 2, x)
+	}
+
+	// Zone has moved on without us, journal must be discarded.
+	j2 := NewJournal(path)
+	if err := j2.Load("example.org.", 4); err != nil {
+		t.Fatalf("Failed to load journal: %s", err)
+	}
+	if j2.Len() != 0 {
+		t.Errorf("Expected empty journal, got %d deltas", j2.Len())
+	}
+}
+
+func TestServeIxfr(t *testing.T) {
+	v1, v2, v3 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2), parseJournalZone(t, dbJournalV3)
+	v3.Journal = NewJournal("")
+	v3.Journal.Add(Diff(v1, v2), Diff(v2, v3))
+	v3.TransferTo = []string{"*"}
+
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		Xfr{v3}.ServeDNS(context.TODO(), w, r)
+	})
+	defer s.Close()
+
+	m := new(dns.Msg)
+	m.SetIxfr("example.org.", 1, "ns1.example.org.", "admin.example.org.")
+	rrs := transferRRs(t, m, s.Addr)
+
+	// current SOA, 2 deltas (each 2 SOAs + records) and closing SOA
+	d1, d2 := Diff(v1, v2), Diff(v2, v3)
+	if x := 2 + len(d1.RRs()) + len(d2.RRs()); len(rrs) != x {
+		t.Fatalf("Expected %d records in IXFR, got %d", x, len(rrs))
+	}
+	if soa, ok := rrs[1].(*dns.SOA); !ok || soa.Serial != 1 {
+		t.Errorf("Expected second record to be SOA with serial 1, got %s", rrs[1])
+	}
+
+	// Unknown serial, falls back to AXFR.
+	m.SetIxfr("example.org.", 100, "ns1.example.org.", "admin.example.org.")
+	rrs = transferRRs(t, m, s.Addr)
+	if _, ok := rrs[1].(*dns.SOA); ok {
+		t.Errorf("Expected full transfer, got %s as second record", rrs[1])
+	}
+}
+
+func TestFileTransferIxfr(t *testing.T) {
+	v1, v2 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2)
+	v2.Journal = NewJournal("")
+	v2.Journal.Add(Diff(v1, v2))
+	f := File{Zones: Zones{Z: map[string]*Zone{"example.org.": v2}, Names: []string{"example.org."}}}
+
+	tests := []struct {
+		serial uint32
+		rrs    int
+	}{
+		{2, 1},                           // up to date
+		{1, 1 + len(Diff(v1, v2).RRs())}, // incremental, closing SOA is added by transfer
+	}
+	for i, tc := range tests {
+		ch, err := f.Transfer("example.org.", tc.serial)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %s", i, err)
+		}
+		n := 0
+		for rrs := range ch {
+			n += len(rrs)
+		}
+		if n != tc.rrs {
+			t.Errorf("Test %d: expected %d records, got %d", i, tc.rrs, n)
+		}
+	}
+
+	if _, err := f.Transfer("example.com.", 0); err == nil {
+		t.Errorf("Expected error for zone we are not authoritative for")
+	}
+}
+
+func TestTransferInIxfr(t *testing.T) {
+	v1, v2, v3 := parseJournalZone(t, dbJournalV1), parseJournalZone(t, dbJournalV2), parseJournalZone(t, dbJournalV3)
+	v3.Journal = NewJournal("")
+	v3.Journal.Add(Diff(v1, v2), Diff(v2, v3))
+	v3.TransferTo = []string{"*"}
+
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		Xfr{v3}.ServeDNS(context.TODO(), w, r)
+	})
+	defer s.Close()
+
+	z := parseJournalZone(t, dbJournalV1)
+	z.TransferFrom = []string{s.Addr}
+	z.Journal = NewJournal("")
+
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Failed to transfer zone: %s", err)
+	}
+	if z.Apex.SOA.Serial != 3 {
+		t.Errorf("Expected serial %d, got %d", 3, z.Apex.SOA.Serial)
+	}
+	if d := Diff(z, v3); len(d.Added) != 0 || len(d.Deleted) != 0 {
+		t.Errorf("Expected no differences after incremental transfer, got %v", d.RRs())
+	}
+	if z.Journal.Len() != 2 {
+		t.Errorf("Expected %d deltas in journal, got %d", 2, z.Journal.Len())
+	}
+
+	// Up to date.
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Failed to transfer zone: %s", err)
+	}
+	if z.Apex.SOA.Serial != 3 {
+		t.Errorf("Expected serial %d, got %d", 3, z.Apex.SOA.Serial)
+	}
+}
+
+func transferRRs(t *testing.T, m *dns.Msg, addr string) []dns.RR {
+	tr := new(dns.Transfer)
+	c, err := tr.In(m, addr)
+	if err != nil {
+		t.Fatalf("Failed to transfer: %s", err)
+	}
+	rrs := []dns.RR{}
+	for env := range c {
+		if env.Error != nil {
+			t.Fatalf("Failed to transfer: %s", env.Error)
+		}
+		rrs = append(rrs, env.RR...)
+	}
+	return rrs
+}
+
+func ExampleDelta_RRs() {
+	d := Delta{
+		From:    test.SOA("example.org. 60 IN SOA ns1.example.org. admin.example.org. 1 1200 180 1209600 30"),
+		To:      test.SOA("example.org. 60 IN SOA ns1.example.org. admin.example.org. 2 1200 180 1209600 30"),
+		Deleted: []dns.RR{test.A("a.example.org. 60 IN A 127.0.0.2")},
+		Added:   []dns.RR{test.A("a.example.org. 60 IN A 127.0.0.3")},
+	}
+	for _, rr := range d.RRs() {
+		fmt.Println(rr)
+	}
+	// Output:
+	// example.org.	60	IN	SOA	ns1.example.org. admin.example.org. 1 1200 180 1209600 30
+	// a.example.org.	60	IN	A	127.0.0.2
+	// example.org.	60	IN	SOA	ns1.example.org. admin.example.org. 2 1200 180 1209600 30
+	// a.example.org.	60	IN	A	127.0.0.3
+}
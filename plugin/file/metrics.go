@@ -0,0 +1,52 @@
+package file
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics for secondary zones, i.e. zones that are transferred from a primary.
+var (
+	// TransferCount is the number of incoming zone transfers, by zone, type and result.
+	TransferCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "secondary",
+		Name:      "transfers_total",
+		Help:      "Counter of incoming zone transfers per zone, type and result.",
+	}, []string{"zone", "type", "result"})
+	// LastRefresh is the time of the last successful refresh of a zone.
+	LastRefresh = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "secondary",
+		Name:      "last_refresh_timestamp_seconds",
+		Help:      "The time of the last successful refresh or transfer per zone.",
+	}, []string{"zone"})
+	// SOASerial is the SOA serial of a zone.
+	SOASerial = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "secondary",
+		Name:      "soa_serial",
+		Help:      "The SOA serial of the zone after the last successful refresh.",
+	}, []string{"zone"})
+	// ExpiredZone is 1 when the zone is expired and not served.
+	ExpiredZone = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "secondary",
+		Name:      "expired",
+		Help:      "Whether the zone is expired (1) because it was not refreshed within the SOA expire interval, or not (0).",
+	}, []string{"zone"})
+)
+
+// DeleteZoneMetrics removes the secondary metrics of the zone origin, it should be called when a zone is
+// removed at runtime.
+func DeleteZoneMetrics(origin string) {
+	for _, typ := range []string{"axfr", "ixfr"} {
+		for _, result := range []string{"success", "failure"} {
+			TransferCount.DeleteLabelValues(origin, typ, result)
+		}
+	}
+	LastRefresh.DeleteLabelValues(origin)
+	SOASerial.DeleteLabelValues(origin)
+	ExpiredZone.DeleteLabelValues(origin)
+}
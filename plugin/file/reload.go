@@ -33,8 +33,21 @@ func (z *Zone) Reload() error {
 					continue
 				}
 
+				var delta *Delta
+				z.RLock()
+				if z.Journal != nil && z.Apex.SOA != nil {
+					d := Diff(z, zone)
+					delta = &d
+				}
+				z.RUnlock()
+
 				// copy elements we need
 				z.Lock()
+				if delta != nil {
+					if err := z.Journal.Add(*delta); err != nil {
+						log.Warningf("Failed to write journal for %q: %v", z.origin, err)
+					}
+				}
 				z.Apex = zone.Apex
 				z.Tree = zone.Tree
 				z.Unlock()
@@ -1,100 +1,329 @@
 package file
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/miekg/dns"
 )
 
-// TransferIn retrieves the zone from the masters, parses it and sets it live.
+// TransferIn retrieves the zone from the masters, parses it and sets it live. If we already have a
+// version of the zone an IXFR is requested, the primary may answer with just the changes since our
+// serial, which are then applied to the zone. If that fails we fall back to AXFR.
 func (z *Zone) TransferIn() error {
-	if len(z.TransferFrom) == 0 {
+	return z.transferFrom(z.TransferFrom)
+}
+
+// transferFrom transfers the zone from the first primary in primaries that works.
+func (z *Zone) transferFrom(primaries []string) error {
+	if len(primaries) == 0 {
 		return nil
 	}
-	m := new(dns.Msg)
-	m.SetAxfr(z.origin)
+	z.RLock()
+	soa := z.Apex.SOA
+	z.RUnlock()
+
+	var Err error
+	for _, tr := range primaries {
+		if soa != nil {
+			m := new(dns.Msg)
+			m.SetIxfr(z.origin, soa.Serial, soa.Ns, soa.Mbox)
+			Err = z.transferIn(m, tr)
+			z.transferred("ixfr", Err)
+			if Err == nil {
+				return nil
+			}
+			log.Warningf("Failed incremental transfer `%s' from %q, trying full transfer: %v", z.origin, tr, Err)
+		}
+
+		m := new(dns.Msg)
+		m.SetAxfr(z.origin)
+		Err = z.transferIn(m, tr)
+		z.transferred("axfr", Err)
+		if Err == nil {
+			return nil
+		}
+	}
+	return Err
+}
+
+// transferred updates the metrics after a transfer of type typ, which failed if err is not nil.
+func (z *Zone) transferred(typ string, err error) {
+	if err != nil {
+		TransferCount.WithLabelValues(z.origin, typ, "failure").Inc()
+		return
+	}
+	TransferCount.WithLabelValues(z.origin, typ, "success").Inc()
+	z.refreshed()
+}
+
+// transferIn performs the transfer in m from the primary tr. The response may contain the full zone (for
+// AXFR, or IXFR when the primary doesn't have the history), the changes since our serial or just the
+// primary's SOA if we are up to date.
+func (z *Zone) transferIn(m *dns.Msg, tr string) error {
+	t := new(dns.Transfer)
+	c, err := t.In(m, tr)
+	if err != nil {
+		log.Errorf("Failed to setup transfer `%s' with `%q': %v", z.origin, tr, err)
+		return err
+	}
 
-	z1 := z.CopyWithoutApex()
 	var (
-		Err error
-		tr  string
+		serial      uint32 // serial of the primary
+		n           int
+		incremental bool
+		body        []dns.RR // the records of an incremental transfer
+		Err         error
 	)
-
-Transfer:
-	for _, tr = range z.TransferFrom {
-		t := new(dns.Transfer)
-		c, err := t.In(m, tr)
-		if err != nil {
-			log.Errorf("Failed to setup transfer `%s' with `%q': %v", z.origin, tr, err)
-			Err = err
-			continue Transfer
+	z1 := z.CopyWithoutApex()
+	for env := range c {
+		if env.Error != nil {
+			log.Errorf("Failed to transfer `%s' from %q: %v", z.origin, tr, env.Error)
+			return env.Error
 		}
-		for env := range c {
-			if env.Error != nil {
-				log.Errorf("Failed to transfer `%s' from %q: %v", z.origin, tr, env.Error)
-				Err = env.Error
-				continue Transfer
-			}
-			for _, rr := range env.RR {
-				if err := z1.Insert(rr); err != nil {
-					log.Errorf("Failed to parse transfer `%s' from: %q: %v", z.origin, tr, err)
-					Err = err
-					continue Transfer
+		if Err != nil { // drain the channel
+			continue
+		}
+		for _, rr := range env.RR {
+			n++
+			switch n {
+			case 1:
+				soa, ok := rr.(*dns.SOA)
+				if !ok {
+					Err = fmt.Errorf("first record is not a SOA: %s", rr)
+					break
 				}
+				serial = soa.Serial
+			case 2:
+				soa, ok := rr.(*dns.SOA)
+				incremental = m.Question[0].Qtype == dns.TypeIXFR && ok && soa.Serial != serial
+			}
+			if Err != nil {
+				break
+			}
+			if incremental {
+				body = append(body, rr)
+				continue
+			}
+			if err := z1.Insert(rr); err != nil {
+				log.Errorf("Failed to parse transfer `%s' from: %q: %v", z.origin, tr, err)
+				Err = err
+				break
 			}
 		}
-		Err = nil
-		break
 	}
 	if Err != nil {
 		return Err
 	}
 
+	if n == 1 && m.Question[0].Qtype == dns.TypeIXFR {
+		log.Infof("Transfer of %s from %s: up to date with %d SOA serial", z.origin, tr, serial)
+		z.Lock()
+		z.Expired = false
+		z.Unlock()
+		return nil
+	}
+
+	if incremental {
+		return z.applyTransfer(body, tr, serial)
+	}
+
+	var delta *Delta
+	z.RLock()
+	if z.Journal != nil && z.Apex.SOA != nil {
+		d := Diff(z, z1)
+		delta = &d
+	}
+	z.RUnlock()
+
 	z.Lock()
+	if delta != nil {
+		if err := z.Journal.Add(*delta); err != nil {
+			log.Warningf("Failed to write journal for %s: %s", z.origin, err)
+		}
+	}
 	z.Tree = z1.Tree
 	z.Apex = z1.Apex
 	z.Expired = false
 	z.Unlock()
 	log.Infof("Transferred: %s from %s", z.origin, tr)
+	if z.OnTransfer != nil {
+		z.OnTransfer()
+	}
+	return nil
+}
+
+// applyTransfer applies the changes in body, the records of an IXFR response after the leading SOA,
+// to z. The zone must end up at serial.
+func (z *Zone) applyTransfer(body []dns.RR, tr string, serial uint32) error {
+	deltas, err := parseDeltas(body[:len(body)-1]) // strip the closing SOA
+	if err != nil {
+		return err
+	}
+	if len(deltas) == 0 {
+		return fmt.Errorf("incremental transfer without changes")
+	}
+
+	z.RLock()
+	z1 := z.CopyWithoutApex()
+	z1.Apex = z.Apex
+	z1.Tree = z.Tree.Copy()
+	z.RUnlock()
+
+	if z1.Apex.SOA == nil || deltas[0].From.Serial != z1.Apex.SOA.Serial {
+		return fmt.Errorf("incremental transfer does not start at our serial")
+	}
+	for _, d := range deltas {
+		if d.From.Serial != z1.Apex.SOA.Serial {
+			return fmt.Errorf("incremental transfer has gap at serial %d", z1.Apex.SOA.Serial)
+		}
+		if err := z1.apply(d); err != nil {
+			return err
+		}
+	}
+	if z1.Apex.SOA.Serial != serial {
+		return fmt.Errorf("incremental transfer ends at serial %d, expected %d", z1.Apex.SOA.Serial, serial)
+	}
+
+	z.Lock()
+	z.Tree = z1.Tree
+	z.Apex = z1.Apex
+	z.Expired = false
+	if z.Journal != nil {
+		if err := z.Journal.Add(deltas...); err != nil {
+			log.Warningf("Failed to write journal for %s: %s", z.origin, err)
+		}
+	}
+	z.Unlock()
+	log.Infof("Transferred: %s from %s with %d incremental changes to %d SOA serial", z.origin, tr, len(deltas), serial)
+	if z.OnTransfer != nil {
+		z.OnTransfer()
+	}
 	return nil
 }
 
 // shouldTransfer checks the primaries of zone, retrieves the SOA record, checks the current serial
 // and the remote serial and will return true if the remote one is higher than the locally configured one.
 func (z *Zone) shouldTransfer() (bool, error) {
+	_, ok, err := z.newestPrimary("")
+	return ok, err
+}
+
+// newestPrimary queries all primaries of z for their SOA and returns the primary with the highest serial and
+// whether that serial is higher than ours. If preferred is not empty, it is returned when its serial is as high as
+// the highest serial. An error is only returned when no primary could be queried.
+func (z *Zone) newestPrimary(preferred string) (string, bool, error) {
 	c := new(dns.Client)
 	c.Net = "tcp" // do this query over TCP to minimize spoofing
 	m := new(dns.Msg)
 	m.SetQuestion(z.origin, dns.TypeSOA)
 
-	var Err error
-	serial := -1
+	var (
+		Err     error
+		primary string
+		serial  uint32
+	)
 
-Transfer:
 	for _, tr := range z.TransferFrom {
-		Err = nil
 		ret, _, err := c.Exchange(m, tr)
-		if err != nil || ret.Rcode != dns.RcodeSuccess {
+		if err != nil {
 			Err = err
 			continue
 		}
+		if ret.Rcode != dns.RcodeSuccess {
+			Err = fmt.Errorf("primary %q returned rcode %s for SOA query", tr, dns.RcodeToString[ret.Rcode])
+			continue
+		}
 		for _, a := range ret.Answer {
-			if a.Header().Rrtype == dns.TypeSOA {
-				serial = int(a.(*dns.SOA).Serial)
-				break Transfer
+			soa, ok := a.(*dns.SOA)
+			if !ok {
+				continue
+			}
+			if primary == "" || less(serial, soa.Serial) || (soa.Serial == serial && tr == preferred) {
+				primary, serial = tr, soa.Serial
 			}
+			break
 		}
 	}
-	if serial == -1 {
-		return false, Err
+	if primary == "" {
+		if Err == nil {
+			Err = fmt.Errorf("no SOA record returned by the primaries")
+		}
+		return "", false, Err
 	}
+
+	z.RLock()
+	defer z.RUnlock()
 	if z.Apex.SOA == nil {
-		return true, Err
+		return primary, true, nil
 	}
-	return less(z.Apex.SOA.Serial, uint32(serial)), Err
+	return primary, less(z.Apex.SOA.Serial, serial), nil
 }
 
+// refresh checks the primaries for a newer version of the zone and transfers it when there is one. The primary
+// with the newest version is tried first, then the other primaries.
+func (z *Zone) refresh(preferred string) error {
+	primary, ok, err := z.newestPrimary(preferred)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		z.refreshed()
+		return nil
+	}
+
+	primaries := []string{primary}
+	for _, tr := range z.TransferFrom {
+		if tr != primary {
+			primaries = append(primaries, tr)
+		}
+	}
+	return z.transferFrom(primaries)
+}
+
+// refreshed records that the zone was successfully refreshed, i.e. it is up to date with the primaries.
+func (z *Zone) refreshed() {
+	z.Lock()
+	z.Expired = false
+	serial := -1.0
+	if z.Apex.SOA != nil {
+		serial = float64(z.Apex.SOA.Serial)
+	}
+	z.Unlock()
+
+	LastRefresh.WithLabelValues(z.origin).SetToCurrentTime()
+	ExpiredZone.WithLabelValues(z.origin).Set(0)
+	if serial >= 0 {
+		SOASerial.WithLabelValues(z.origin).Set(serial)
+	}
+}
+
+// expire marks the zone as expired, queries for it will return SERVFAIL until it is refreshed.
+func (z *Zone) expire() {
+	z.Lock()
+	z.Expired = true
+	z.Unlock()
+	ExpiredZone.WithLabelValues(z.origin).Set(1)
+	log.Errorf("Zone %s expired, no successful refresh within the SOA expire interval", z.origin)
+}
+
+// timers returns the refresh, retry and expire timers from the SOA record of z. If z does not have a SOA record
+// yet, expire is 0 and the retry timer is defaultRetry.
+func (z *Zone) timers() (refresh, retry, expire time.Duration) {
+	z.RLock()
+	defer z.RUnlock()
+	if z.Apex.SOA == nil {
+		return defaultRetry, defaultRetry, 0
+	}
+	return time.Second * time.Duration(z.Apex.SOA.Refresh),
+		time.Second * time.Duration(z.Apex.SOA.Retry),
+		time.Second * time.Duration(z.Apex.SOA.Expire)
+}
+
+// defaultRetry is the retry interval used when we don't have a SOA record.
+const defaultRetry = 10 * time.Second
+
 // less return true of a is smaller than b when taking RFC 1982 serial arithmetic into account.
 func less(a, b uint32) bool {
 	if a < b {
@@ -103,87 +332,65 @@ func less(a, b uint32) bool {
 	return (a - b) > MaxSerialIncrement
 }
 
-// Update updates the secondary zone according to its SOA. It will run for the life time of the server
+// Update updates the secondary zone according to its SOA. It will run until the zone is shut down
 // and uses the SOA parameters. Every refresh it will check for a new SOA number. If that fails (for all
-// server) it will retry every retry interval. If the zone failed to transfer before the expire, the zone
-// will be marked expired.
+// primaries) it will retry every retry interval. If the zone isn't refreshed before the expire interval has
+// passed, the zone is marked expired and no longer served. A valid notify from one of the primaries triggers
+// an immediate refresh.
 func (z *Zone) Update() error {
-	// If we don't have a SOA, we don't have a zone, wait for it to appear.
-	for z.Apex.SOA == nil {
-		time.Sleep(1 * time.Second)
+	refresh, retry, expire := z.timers()
+	wait := retry + jitter(2000)
+	if z.SOASerialIfDefined() != -1 {
+		wait = refresh + jitter(5000)
 	}
-	retryActive := false
+	check := time.NewTimer(wait)
+	defer check.Stop()
 
-Restart:
-	refresh := time.Second * time.Duration(z.Apex.SOA.Refresh)
-	retry := time.Second * time.Duration(z.Apex.SOA.Retry)
-	expire := time.Second * time.Duration(z.Apex.SOA.Expire)
-
-	refreshTicker := time.NewTicker(refresh)
-	retryTicker := time.NewTicker(retry)
-	expireTicker := time.NewTicker(expire)
+	// The expire timer only runs when we have a zone.
+	expireTimer := time.NewTimer(expire)
+	defer expireTimer.Stop()
+	if expire == 0 {
+		stopTimer(expireTimer)
+	}
 
 	for {
+		preferred := ""
 		select {
-		case <-expireTicker.C:
-			if !retryActive {
-				break
-			}
-			z.Expired = true
-
-		case <-retryTicker.C:
-			if !retryActive {
-				break
-			}
-
-			time.Sleep(jitter(2000)) // 2s randomize
-
-			ok, err := z.shouldTransfer()
-			if err != nil {
-				log.Warningf("Failed retry check %s", err)
-				continue
-			}
-
-			if ok {
-				if err := z.TransferIn(); err != nil {
-					// transfer failed, leave retryActive true
-					break
-				}
-			}
-
-			// no errors, stop timers and restart
-			retryActive = false
-			refreshTicker.Stop()
-			retryTicker.Stop()
-			expireTicker.Stop()
-			goto Restart
+		case <-z.updateShutdown:
+			return nil
 
-		case <-refreshTicker.C:
+		case <-expireTimer.C:
+			z.expire()
+			continue
 
-			time.Sleep(jitter(5000)) // 5s randomize
+		case preferred = <-z.notify:
+			stopTimer(check)
 
-			ok, err := z.shouldTransfer()
-			if err != nil {
-				log.Warningf("Failed refresh check %s", err)
-				retryActive = true
-				continue
-			}
+		case <-check.C:
+		}
 
-			if ok {
-				if err := z.TransferIn(); err != nil {
-					// transfer failed
-					retryActive = true
-					break
-				}
-			}
+		err := z.refresh(preferred)
+		refresh, retry, expire = z.timers()
+		if err != nil {
+			log.Warningf("Failed to refresh %s: %s", z.origin, err)
+			check.Reset(retry + jitter(2000)) // 2s randomize
+			continue
+		}
+		check.Reset(refresh + jitter(5000)) // 5s randomize
 
-			// no errors, stop timers and restart
-			retryActive = false
-			refreshTicker.Stop()
-			retryTicker.Stop()
-			expireTicker.Stop()
-			goto Restart
+		stopTimer(expireTimer)
+		if expire > 0 {
+			expireTimer.Reset(expire)
+		}
+	}
+}
 
+// stopTimer stops t and drains its channel, so it can be reset.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
 		}
 	}
 }
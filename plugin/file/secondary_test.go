@@ -1,8 +1,12 @@
 package file
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
 	"github.com/coredns/coredns/plugin/test"
@@ -148,3 +152,136 @@ func newRequest(zone string, qtype uint16) request.Request {
 	m.SetEdns0(4097, true)
 	return request.Request{W: &test.ResponseWriter{}, Req: m}
 }
+
+// primary is a primary server that serves the zone with serial and SOA timers.
+type primary struct {
+	serial uint32
+	timers string
+}
+
+func (p *primary) Handler(w dns.ResponseWriter, req *dns.Msg) {
+	soa := test.SOA(fmt.Sprintf("%s IN SOA bla. bla. %d %s", testZone, atomic.LoadUint32(&p.serial), p.timers))
+	m := new(dns.Msg)
+	m.SetReply(req)
+	switch req.Question[0].Qtype {
+	case dns.TypeSOA:
+		m.Answer = []dns.RR{soa}
+	case dns.TypeAXFR, dns.TypeIXFR:
+		m.Answer = []dns.RR{soa, test.A(fmt.Sprintf("%s IN A 127.0.0.1", testZone)), soa}
+	}
+	w.WriteMsg(m)
+}
+
+// primaries dispatches to a primary based on the address the query was received on, dnstest servers share a
+// single handler.
+type primaries map[string]*primary
+
+func (p primaries) Handler(w dns.ResponseWriter, req *dns.Msg) {
+	_, port, _ := net.SplitHostPort(w.LocalAddr().String())
+	p[port].Handler(w, req)
+}
+
+func port(addr string) string {
+	_, p, _ := net.SplitHostPort(addr)
+	return p
+}
+
+func TestNewestPrimary(t *testing.T) {
+	p1, p2 := &primary{serial: 250, timers: "0 0 0 0"}, &primary{serial: 251, timers: "0 0 0 0"}
+	ps := primaries{}
+	s1 := dnstest.NewServer(ps.Handler)
+	defer s1.Close()
+	s2 := dnstest.NewServer(ps.Handler)
+	defer s2.Close()
+	ps[port(s1.Addr)], ps[port(s2.Addr)] = p1, p2
+
+	z := NewZone(testZone, "stdin")
+	z.TransferFrom = []string{"127.0.0.1:1", s1.Addr, s2.Addr}
+
+	tr, ok, err := z.newestPrimary("")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if !ok || tr != s2.Addr {
+		t.Errorf("Expected %s to be the newest primary, got %s", s2.Addr, tr)
+	}
+
+	atomic.StoreUint32(&p1.serial, 251)
+	if tr, _, _ := z.newestPrimary(s1.Addr); tr != s1.Addr {
+		t.Errorf("Expected preferred primary %s, got %s", s1.Addr, tr)
+	}
+
+	z.TransferFrom = []string{"127.0.0.1:1"}
+	if _, _, err := z.newestPrimary(""); err == nil {
+		t.Errorf("Expected error when no primary is reachable")
+	}
+}
+
+func TestTransferInFailover(t *testing.T) {
+	p := &primary{serial: 250, timers: "0 0 0 0"}
+	s := dnstest.NewServer(p.Handler)
+	defer s.Close()
+
+	z := NewZone(testZone, "stdin")
+	z.TransferFrom = []string{"127.0.0.1:1", s.Addr}
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Expected transfer from second primary, got %s", err)
+	}
+	if x := z.SOASerialIfDefined(); x != 250 {
+		t.Errorf("Expected serial %d, got %d", 250, x)
+	}
+}
+
+func TestUpdateExpire(t *testing.T) {
+	p := &primary{serial: 250, timers: "3600 3600 1 0"} // expire after 1s
+	s := dnstest.NewServer(p.Handler)
+
+	z := NewZone(testZone, "stdin")
+	z.TransferFrom = []string{s.Addr}
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Unable to run TransferIn: %v", err)
+	}
+	s.Close()
+
+	go z.Update()
+	defer z.OnShutdown()
+
+	// Primary is down, so after 1s the zone should be expired.
+	f := File{Zones: Zones{Z: map[string]*Zone{testZone: z}, Names: []string{testZone}}}
+	for i := 0; i < 30; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion(testZone, dns.TypeA)
+		rcode, _ := f.ServeDNS(context.TODO(), dnstest.NewRecorder(&test.ResponseWriter{}), m)
+		if rcode == dns.RcodeServerFailure {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Expected zone to be expired")
+}
+
+func TestUpdateNotify(t *testing.T) {
+	p := &primary{serial: 250, timers: "3600 3600 3600 0"}
+	s := dnstest.NewServer(p.Handler)
+	defer s.Close()
+
+	z := NewZone(testZone, "stdin")
+	z.TransferFrom = []string{s.Addr}
+	if err := z.TransferIn(); err != nil {
+		t.Fatalf("Unable to run TransferIn: %v", err)
+	}
+
+	go z.Update()
+	defer z.OnShutdown()
+
+	atomic.StoreUint32(&p.serial, 251)
+	z.notified(s.Addr)
+
+	for i := 0; i < 50; i++ {
+		if z.SOASerialIfDefined() == 251 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("Expected zone to be refreshed after notify, serial is %d", z.SOASerialIfDefined())
+}
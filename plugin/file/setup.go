@@ -95,6 +95,7 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 
 		t := []string{}
 		var e error
+		journal, journalPath := false, ""
 
 		for c.NextBlock() {
 			switch c.Val() {
@@ -111,6 +112,23 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 				}
 				reload = d
 
+			case "journal":
+				journal = true
+				args := c.RemainingArgs()
+				switch len(args) {
+				case 0:
+				case 1:
+					if len(origins) > 1 {
+						return Zones{}, c.Errf("journal file can only be used with a single zone")
+					}
+					journalPath = args[0]
+					if !filepath.IsAbs(journalPath) && config.Root != "" {
+						journalPath = filepath.Join(config.Root, journalPath)
+					}
+				default:
+					return Zones{}, c.ArgErr()
+				}
+
 			case "upstream":
 				// remove soon
 				c.RemainingArgs()
@@ -125,6 +143,18 @@ func fileParse(c *caddy.Controller) (Zones, error) {
 				}
 			}
 		}
+
+		if journal {
+			for _, origin := range origins {
+				j := NewJournal(journalPath)
+				if serial := z[origin].SOASerialIfDefined(); serial >= 0 {
+					if err := j.Load(origin, uint32(serial)); err != nil {
+						return Zones{}, plugin.Error("file", err)
+					}
+				}
+				z[origin].Journal = j
+			}
+		}
 	}
 
 	for origin := range z {
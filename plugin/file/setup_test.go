@@ -123,3 +123,53 @@ func TestParseReload(t *testing.T) {
 		}
 	}
 }
+
+func TestParseJournal(t *testing.T) {
+	name, rm, err := test.TempFile(".", dbMiekNL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rm()
+
+	tests := []struct {
+		input     string
+		shouldErr bool
+		journal   bool
+		path      string
+	}{
+		{`file ` + name + ` example.org.`, false, false, ""},
+		{`file ` + name + ` example.org. {
+			journal
+			}`, false, true, ""},
+		{`file ` + name + ` example.org. {
+			journal /tmp/example.org.jnl
+			}`, false, true, "/tmp/example.org.jnl"},
+		{`file ` + name + ` example.org. example.net. {
+			journal /tmp/example.org.jnl
+			}`, true, false, ""},
+		{`file ` + name + ` example.org. {
+			journal a b
+			}`, true, false, ""},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		z, err := fileParse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d expected errors, but got no error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		}
+		j := z.Z["example.org."].Journal
+		if (j != nil) != test.journal {
+			t.Fatalf("Test %d expected journal to be %t", i, test.journal)
+		}
+		if j != nil && j.Path() != test.path {
+			t.Errorf("Test %d expected journal path %q, but got %q", i, test.path, j.Path())
+		}
+	}
+}
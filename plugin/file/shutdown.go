@@ -5,5 +5,11 @@ func (z *Zone) OnShutdown() error {
 	if 0 < z.ReloadInterval {
 		z.reloadShutdown <- true
 	}
+	if len(z.TransferFrom) > 0 {
+		select {
+		case z.updateShutdown <- true:
+		default:
+		}
+	}
 	return nil
 }
@@ -15,6 +15,15 @@ func newElem(rr dns.RR) *Elem {
 	return &e
 }
 
+// copy returns a copy of e, the RRs themselves are shared.
+func (e *Elem) copy() *Elem {
+	e1 := &Elem{m: make(map[uint16][]dns.RR, len(e.m)), name: e.name}
+	for t, rrs := range e.m {
+		e1.m[t] = append([]dns.RR(nil), rrs...)
+	}
+	return e1
+}
+
 // Types returns the types of the records in e. The returned list is not sorted.
 func (e *Elem) Types() []uint16 {
 	t := make([]uint16, len(e.m))
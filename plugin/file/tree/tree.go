@@ -451,3 +451,16 @@ CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
 OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
 OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 */
+
+// Copy returns a copy of t. The copy shares the RRs with t, but not the nodes and elements, so
+// it can be modified without changing t.
+func (t *Tree) Copy() *Tree {
+	return &Tree{Root: t.Root.copy(), Count: t.Count}
+}
+
+func (n *Node) copy() *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{Elem: n.Elem.copy(), Left: n.Left.copy(), Right: n.Right.copy(), Color: n.Color}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file/tree"
+	"github.com/coredns/coredns/plugin/transfer"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -114,5 +115,96 @@ func (x Xfr) ServeIxfr(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 		w.WriteMsg(m)
 		return 0, nil
 	}
-	return dns.RcodeServerFailure, nil
+
+	rrs := x.ixfr(soa.Serial)
+	if rrs == nil { // no history, fallback to AXFR
+		return dns.RcodeServerFailure, nil
+	}
+
+	ch := make(chan *dns.Envelope)
+	tr := new(dns.Transfer)
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		tr.Out(w, r, ch)
+		wg.Done()
+	}()
+
+	for len(rrs) > 0 {
+		n := 500
+		if n > len(rrs) {
+			n = len(rrs)
+		}
+		ch <- &dns.Envelope{RR: rrs[:n]}
+		rrs = rrs[n:]
+	}
+
+	close(ch)
+	wg.Wait()
+
+	state := request.Request{W: w, Req: r}
+	log.Infof("Outgoing incremental transfer of zone %s to %s from %d to %d SOA serial", x.origin, state.IP(), soa.Serial, serial)
+	return 0, nil
+}
+
+// ixfr returns the records of an IXFR response (RFC 1995, Section 4) that brings a zone at serial to the current
+// version of z. The current SOA is the first and the last record. If the journal of z doesn't have those changes,
+// nil is returned.
+func (z *Zone) ixfr(serial uint32) []dns.RR {
+	z.RLock()
+	defer z.RUnlock()
+	if z.Journal == nil || z.Apex.SOA == nil {
+		return nil
+	}
+	deltas := z.Journal.Since(serial, z.Apex.SOA.Serial)
+	if deltas == nil {
+		return nil
+	}
+	rrs := []dns.RR{z.Apex.SOA}
+	for _, d := range deltas {
+		rrs = append(rrs, d.RRs()...)
+	}
+	return append(rrs, z.Apex.SOA)
+}
+
+// Transfer implements the transfer.Transferer interface.
+func (f File) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	z, ok := f.Z[zone]
+	if !ok || z == nil {
+		return nil, transfer.ErrNotAuthoritative
+	}
+	apex, err := z.ApexIfDefined()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []dns.RR)
+	if serial != 0 {
+		current := apex[0].(*dns.SOA).Serial
+		if serial == current || less(current, serial) {
+			go func() {
+				ch <- []dns.RR{apex[0]}
+				close(ch)
+			}()
+			return ch, nil
+		}
+		if rrs := z.ixfr(serial); rrs != nil {
+			go func() {
+				// The transfer plugin adds the closing SOA.
+				ch <- rrs[:len(rrs)-1]
+				close(ch)
+			}()
+			return ch, nil
+		}
+	}
+
+	go func() {
+		ch <- apex
+		z.Walk(func(e *tree.Elem, _ map[uint16][]dns.RR) error {
+			ch <- e.All()
+			return nil
+		})
+		close(ch)
+	}()
+	return ch, nil
 }
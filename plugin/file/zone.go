@@ -32,6 +32,12 @@ type Zone struct {
 
 	ReloadInterval time.Duration
 	reloadShutdown chan bool
+	updateShutdown chan bool
+	notify         chan string // primaries that sent a notify
+
+	OnTransfer func() // OnTransfer, if set, is called after a transfer changed the zone.
+
+	Journal *Journal // Journal records the changes between serials, if nil no history is kept and IXFR falls back to AXFR.
 
 	Upstream *upstream.Upstream // Upstream for looking up external names during the resolution process.
 }
@@ -52,6 +58,8 @@ func NewZone(name, file string) *Zone {
 		file:           filepath.Clean(file),
 		Tree:           &tree.Tree{},
 		reloadShutdown: make(chan bool),
+		updateShutdown: make(chan bool, 1),
+		notify:         make(chan string, 1),
 	}
 }
 
@@ -61,6 +69,7 @@ func (z *Zone) Copy() *Zone {
 	z1.TransferTo = z.TransferTo
 	z1.TransferFrom = z.TransferFrom
 	z1.Expired = z.Expired
+	z1.Journal = z.Journal
 
 	z1.Apex = z.Apex
 	return z1
@@ -120,6 +129,69 @@ func (z *Zone) Insert(r dns.RR) error {
 	return nil
 }
 
+// remove removes r from z. Unlike Insert, only the record that is equal to r is removed, other
+// records of the same type are left in place.
+func (z *Zone) remove(r dns.RR) {
+	switch h := r.Header().Rrtype; h {
+	case dns.TypeSOA:
+		return
+	case dns.TypeNS:
+		if strings.ToLower(r.Header().Name) == z.origin {
+			z.Apex.NS = removeRR(z.Apex.NS, r)
+			return
+		}
+	case dns.TypeRRSIG:
+		switch r.(*dns.RRSIG).TypeCovered {
+		case dns.TypeSOA:
+			z.Apex.SIGSOA = removeRR(z.Apex.SIGSOA, r)
+			return
+		case dns.TypeNS:
+			if strings.ToLower(r.Header().Name) == z.origin {
+				z.Apex.SIGNS = removeRR(z.Apex.SIGNS, r)
+				return
+			}
+		}
+	}
+
+	elem, _ := z.Tree.Search(strings.ToLower(r.Header().Name))
+	if elem == nil {
+		return
+	}
+	rrs := elem.Type(r.Header().Rrtype)
+	keep := removeRR(rrs, r)
+	if len(keep) == len(rrs) {
+		return
+	}
+	z.Tree.Delete(r)
+	for _, rr := range keep {
+		z.Tree.Insert(rr)
+	}
+}
+
+// removeRR returns rrs without the records that are equal to r.
+func removeRR(rrs []dns.RR, r dns.RR) []dns.RR {
+	keep := make([]dns.RR, 0, len(rrs))
+	for _, rr := range rrs {
+		if !dns.IsDuplicate(rr, r) {
+			keep = append(keep, rr)
+		}
+	}
+	return keep
+}
+
+// apply applies the delta d to z, the caller must hold the write lock on z.
+func (z *Zone) apply(d Delta) error {
+	for _, rr := range d.Deleted {
+		z.remove(rr)
+	}
+	for _, rr := range d.Added {
+		if err := z.Insert(dns.Copy(rr)); err != nil {
+			return err
+		}
+	}
+	return z.Insert(d.To)
+}
+
 // File retrieves the file path in a safe way.
 func (z *Zone) File() string {
 	z.RLock()
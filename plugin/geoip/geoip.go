@@ -0,0 +1,146 @@
+// Package geoip implements a plugin that adds the location and network of the client, looked up in MaxMind DB
+// files, to the metadata of the request.
+package geoip
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIP is a plugin that provides the location of the client as metadata.
+type GeoIP struct {
+	Next plugin.Handler
+
+	readers     []*maxminddb.Reader
+	edns0Subnet bool
+}
+
+// fields maps the labels of the metadata, without the geoip/ prefix, to the path of their data in the databases.
+var fields = map[string][]string{
+	"country":      {"country", "iso_code"},
+	"country_name": {"country", "names", "en"},
+	"continent":    {"continent", "code"},
+	"subdivision":  {"subdivisions", "0", "iso_code"},
+	"city":         {"city", "names", "en"},
+	"postal_code":  {"postal", "code"},
+	"timezone":     {"location", "time_zone"},
+	"latitude":     {"location", "latitude"},
+	"longitude":    {"location", "longitude"},
+	"asn":          {"autonomous_system_number"},
+	"asn_org":      {"autonomous_system_organization"},
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (g GeoIP) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return plugin.NextOrFailure(g.Name(), g.Next, ctx, w, r)
+}
+
+// Name implements the plugin.Handler interface.
+func (g GeoIP) Name() string { return "geoip" }
+
+// Metadata implements the metadata.Provider interface. The databases are only searched when one of the labels is
+// used, and at most once per request.
+func (g GeoIP) Metadata(ctx context.Context, state request.Request) context.Context {
+	ip := g.clientIP(state)
+
+	var (
+		once sync.Once
+		data map[string]interface{}
+	)
+	lookup := func() map[string]interface{} {
+		once.Do(func() { data = g.lookup(ip) })
+		return data
+	}
+	for label, path := range fields {
+		path := path
+		metadata.SetValueFunc(ctx, "geoip/"+label, func() string { return valueOf(lookup(), path) })
+	}
+	return ctx
+}
+
+// clientIP returns the address of the client, or of the client subnet when edns-subnet is enabled and the request
+// has one.
+func (g GeoIP) clientIP(state request.Request) net.IP {
+	if g.edns0Subnet {
+		if o := state.Req.IsEdns0(); o != nil {
+			for _, s := range o.Option {
+				if e, ok := s.(*dns.EDNS0_SUBNET); ok && e.Address != nil {
+					return e.Address
+				}
+			}
+		}
+	}
+	return net.ParseIP(state.IP())
+}
+
+// lookup returns the data of ip in all databases. If databases have the same key, the first one wins.
+func (g GeoIP) lookup(ip net.IP) map[string]interface{} {
+	data := map[string]interface{}{}
+	if ip == nil {
+		return data
+	}
+	for _, r := range g.readers {
+		if r.Metadata.IPVersion == 4 && ip.To4() == nil {
+			continue
+		}
+		var v interface{}
+		if err := r.Lookup(ip, &v); err != nil {
+			log.Errorf("Failed to look up %s in %s database: %s", ip, r.Metadata.DatabaseType, err)
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range m {
+			if _, ok := data[k]; !ok {
+				data[k] = v
+			}
+		}
+	}
+	return data
+}
+
+// valueOf returns the value at path in data as a string, or an empty string if there is no such value. Elements of
+// arrays are selected by their index.
+func valueOf(data interface{}, path []string) string {
+	for _, p := range path {
+		switch x := data.(type) {
+		case map[string]interface{}:
+			data = x[p]
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(x) {
+				return ""
+			}
+			data = x[i]
+		default:
+			return ""
+		}
+	}
+
+	switch x := data.(type) {
+	case string:
+		return x
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case int:
+		return strconv.Itoa(x)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	return ""
+}
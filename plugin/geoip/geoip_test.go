@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+func TestMetadata(t *testing.T) {
+	city, err := maxminddb.FromBytes(writeTestDB(t, "Test-City", 24, testNetworks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	asn, err := maxminddb.FromBytes(writeTestDB(t, "Test-ASN", 24, testASNetworks))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		geoip    GeoIP
+		w        dns.ResponseWriter
+		subnet   string
+		expected map[string]string
+	}{
+		{
+			GeoIP{readers: []*maxminddb.Reader{city, asn}}, &test.ResponseWriter{}, "",
+			map[string]string{"geoip/country": "US", "geoip/country_name": "United States", "geoip/continent": "NA", "geoip/city": "", "geoip/asn": ""},
+		},
+		{
+			GeoIP{readers: []*maxminddb.Reader{city, asn}}, &test.ResponseWriter{}, "81.2.69.0",
+			map[string]string{"geoip/country": "US"},
+		},
+		{
+			GeoIP{readers: []*maxminddb.Reader{city, asn}, edns0Subnet: true}, &test.ResponseWriter{}, "81.2.69.0",
+			map[string]string{
+				"geoip/country": "GB", "geoip/country_name": "United Kingdom", "geoip/continent": "EU",
+				"geoip/subdivision": "ENG", "geoip/city": "London", "geoip/postal_code": "",
+				"geoip/timezone": "Europe/London", "geoip/latitude": "51.5142", "geoip/longitude": "-0.0931",
+				"geoip/asn": "64496", "geoip/asn_org": "Example Networks",
+			},
+		},
+		{
+			GeoIP{readers: []*maxminddb.Reader{city, asn}, edns0Subnet: true}, &test.ResponseWriter{}, "",
+			map[string]string{"geoip/country": "US"},
+		},
+		{
+			GeoIP{readers: []*maxminddb.Reader{city}}, &test.ResponseWriter6{}, "",
+			map[string]string{"geoip/country": "", "geoip/continent": ""},
+		},
+		{
+			GeoIP{readers: []*maxminddb.Reader{asn}}, &test.ResponseWriter{}, "",
+			map[string]string{"geoip/country": "", "geoip/asn": ""},
+		},
+	}
+
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("example.org.", dns.TypeA)
+		if tc.subnet != "" {
+			m.SetEdns0(4096, false)
+			o := m.IsEdns0()
+			o.Option = append(o.Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP(tc.subnet).To4()})
+		}
+		ctx := metadata.ContextWithMetadata(context.Background())
+		ctx = tc.geoip.Metadata(ctx, request.Request{W: tc.w, Req: m})
+
+		for label, expected := range tc.expected {
+			f := metadata.ValueFunc(ctx, label)
+			if f == nil {
+				t.Errorf("Test %d: expected metadata %s to be set", i, label)
+				continue
+			}
+			if got := f(); got != expected {
+				t.Errorf("Test %d: expected %q for %s, got %q", i, expected, label, got)
+			}
+		}
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	data := testNetworks["81.2.69.0/24"]
+	tests := []struct {
+		path     []string
+		expected string
+	}{
+		{[]string{"country", "iso_code"}, "GB"},
+		{[]string{"subdivisions", "0", "iso_code"}, "ENG"},
+		{[]string{"subdivisions", "1", "iso_code"}, ""},
+		{[]string{"subdivisions", "x", "iso_code"}, ""},
+		{[]string{"country", "iso_code", "more"}, ""},
+		{[]string{"country"}, ""},
+		{[]string{"location", "latitude"}, "51.5142"},
+		{[]string{"missing"}, ""},
+	}
+	for i, tc := range tests {
+		if got := valueOf(data, tc.path); got != tc.expected {
+			t.Errorf("Test %d: expected %q for %v, got %q", i, tc.expected, tc.path, got)
+		}
+	}
+	if got := valueOf(testNetworks["2001:db8::/32"], []string{"is_anycast"}); got != "true" {
+		t.Errorf("Expected %q, got %q", "true", got)
+	}
+}
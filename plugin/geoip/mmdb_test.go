@@ -0,0 +1,279 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"math"
+	"net"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// The parts of the MaxMind DB format needed to write the test databases, see https://maxmind.github.io/MaxMind-DB/.
+
+// metadataMarker starts the metadata section at the end of the file.
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparator is the number of zero bytes between the search tree and the data section.
+const dataSectionSeparator = 16
+
+// These are the data types of the data section.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// testNetworks are the networks of the test database, and their data.
+var testNetworks = map[string]map[string]interface{}{
+	"81.2.69.0/24": {
+		"city":         map[string]interface{}{"names": map[string]interface{}{"en": "London"}},
+		"continent":    map[string]interface{}{"code": "EU"},
+		"country":      map[string]interface{}{"iso_code": "GB", "names": map[string]interface{}{"en": "United Kingdom"}},
+		"location":     map[string]interface{}{"latitude": 51.5142, "longitude": -0.0931, "time_zone": "Europe/London"},
+		"subdivisions": []interface{}{map[string]interface{}{"iso_code": "ENG"}},
+	},
+	"10.240.0.0/16": {
+		"continent": map[string]interface{}{"code": "NA"},
+		"country":   map[string]interface{}{"iso_code": "US", "names": map[string]interface{}{"en": "United States"}},
+	},
+	"2001:db8::/32": {
+		"continent":  map[string]interface{}{"code": "OC"},
+		"country":    map[string]interface{}{"iso_code": "AU"},
+		"is_anycast": true,
+	},
+}
+
+// testASNetworks are the networks of the test ASN database, and their data.
+var testASNetworks = map[string]map[string]interface{}{
+	"81.2.69.0/24": {
+		"autonomous_system_number":       uint64(64496),
+		"autonomous_system_organization": "Example Networks",
+	},
+}
+
+func TestTestDB(t *testing.T) {
+	// The test databases must be readable with all record sizes.
+	for _, recordSize := range []int{24, 28, 32} {
+		r, err := maxminddb.FromBytes(writeTestDB(t, "Test-City", recordSize, testNetworks))
+		if err != nil {
+			t.Fatalf("Record size %d: %v", recordSize, err)
+		}
+		if r.Metadata.DatabaseType != "Test-City" {
+			t.Errorf("Record size %d: expected database type Test-City, got %s", recordSize, r.Metadata.DatabaseType)
+		}
+
+		tests := []struct {
+			ip       string
+			expected interface{}
+		}{
+			{"81.2.69.142", testNetworks["81.2.69.0/24"]},
+			{"81.2.69.0", testNetworks["81.2.69.0/24"]},
+			{"10.240.255.255", testNetworks["10.240.0.0/16"]},
+			{"2001:db8::1", testNetworks["2001:db8::/32"]},
+			{"81.2.70.1", nil},
+			{"2001:db9::1", nil},
+		}
+		for _, tc := range tests {
+			var v interface{}
+			if err := r.Lookup(net.ParseIP(tc.ip), &v); err != nil {
+				t.Errorf("Record size %d: expected no error for %s, got %v", recordSize, tc.ip, err)
+				continue
+			}
+			if tc.expected == nil {
+				if v != nil {
+					t.Errorf("Record size %d: expected no data for %s, got %v", recordSize, tc.ip, v)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("Record size %d: expected %v for %s, got %v", recordSize, tc.expected, tc.ip, v)
+			}
+		}
+	}
+}
+
+// writeTestDB returns a MaxMind DB, with an IPv6 search tree, of the networks.
+func writeTestDB(t *testing.T, dbType string, recordSize int, networks map[string]map[string]interface{}) []byte {
+	type node struct {
+		children [2]*node
+		data     int // offset in the data section of a leaf, or -1
+		index    int
+	}
+	root := &node{data: -1}
+
+	var data []byte
+	cidrs := make([]string, 0, len(networks))
+	for cidr := range networks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, bits := n.Mask.Size()
+		ip := n.IP.To16()
+		if bits == 32 {
+			// IPv4 networks are in the ::/96 subtree.
+			ip = append(make(net.IP, 12), n.IP.To4()...)
+			ones += 96
+		}
+		cur := root
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - uint(i)%8) & 1
+			if cur.children[bit] == nil {
+				cur.children[bit] = &node{data: -1}
+			}
+			cur = cur.children[bit]
+		}
+		cur.data = len(data)
+		data = append(data, encode(networks[cidr])...)
+	}
+	// Number the inner nodes, breadth first.
+	var nodes []*node
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		if n.data >= 0 {
+			continue
+		}
+		n.index = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	nodeCount := len(nodes)
+	var tree []byte
+	for _, n := range nodes {
+		var records [2]uint64
+		for i, c := range n.children {
+			switch {
+			case c == nil:
+				records[i] = uint64(nodeCount)
+			case c.data >= 0:
+				records[i] = uint64(nodeCount + dataSectionSeparator + c.data)
+			default:
+				records[i] = uint64(c.index)
+			}
+		}
+		switch recordSize {
+		case 28:
+			l, r := records[0], records[1]
+			tree = append(tree, byte(l>>16), byte(l>>8), byte(l), byte(l>>24&0xF)<<4|byte(r>>24&0xF), byte(r>>16), byte(r>>8), byte(r))
+		default:
+			for _, rec := range records {
+				for i := recordSize/8 - 1; i >= 0; i-- {
+					tree = append(tree, byte(rec>>(8*uint(i))))
+				}
+			}
+		}
+	}
+
+	buf := append(tree, make([]byte, dataSectionSeparator)...)
+	buf = append(buf, data...)
+	buf = append(buf, metadataMarker...)
+	buf = append(buf, encode(map[string]interface{}{
+		"binary_format_major_version": uint64(2),
+		"binary_format_minor_version": uint64(0),
+		"database_type":               dbType,
+		"ip_version":                  uint64(6),
+		"node_count":                  uint64(nodeCount),
+		"record_size":                 uint64(recordSize),
+	})...)
+	return buf
+}
+
+// writeTestDBFile writes a test database to a file in dir and returns its path.
+func writeTestDBFile(t *testing.T, dir, name, dbType string, networks map[string]map[string]interface{}) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, writeTestDB(t, dbType, 28, networks), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// encode encodes v for the data section.
+func encode(v interface{}) []byte {
+	switch x := v.(type) {
+	case string:
+		return append(control(typeString, len(x)), x...)
+	case uint64:
+		var b []byte
+		for ; x > 0; x >>= 8 {
+			b = append([]byte{byte(x)}, b...)
+		}
+		return append(control(typeUint64, len(b)), b...)
+	case float64:
+		b := control(typeDouble, 8)
+		bits := math.Float64bits(x)
+		for i := 7; i >= 0; i-- {
+			b = append(b, byte(bits>>(8*uint(i))))
+		}
+		return b
+	case bool:
+		if x {
+			return control(typeBool, 1)
+		}
+		return control(typeBool, 0)
+	case []interface{}:
+		b := control(typeArray, len(x))
+		for _, e := range x {
+			b = append(b, encode(e)...)
+		}
+		return b
+	case map[string]interface{}:
+		b := control(typeMap, len(x))
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b = append(b, encode(k)...)
+			b = append(b, encode(x[k])...)
+		}
+		return b
+	}
+	panic("can't encode value")
+}
+
+// control returns the control byte(s) for a value of typ and size.
+func control(typ, size int) []byte {
+	var b []byte
+	switch {
+	case size < 29:
+		b = []byte{byte(size)}
+	case size < 285:
+		b = []byte{29, byte(size - 29)}
+	case size < 65821:
+		b = []byte{30, byte((size - 285) >> 8), byte(size - 285)}
+	default:
+		b = []byte{31, byte((size - 65821) >> 16), byte((size - 65821) >> 8), byte(size - 65821)}
+	}
+	if typ > 7 {
+		return append([]byte{b[0], byte(typ - 7)}, b[1:]...)
+	}
+	b[0] |= byte(typ << 5)
+	return b
+}
@@ -0,0 +1,79 @@
+package geoip
+
+import (
+	"path/filepath"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var log = clog.NewWithPlugin("geoip")
+
+func init() { plugin.Register("geoip", setup) }
+
+func setup(c *caddy.Controller) error {
+	g, err := parse(c)
+	if err != nil {
+		return plugin.Error("geoip", err)
+	}
+
+	c.OnShutdown(func() error {
+		for _, r := range g.readers {
+			r.Close()
+		}
+		return nil
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		g.Next = next
+		return g
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (GeoIP, error) {
+	g := GeoIP{}
+	config := dnsserver.GetConfig(c)
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return g, plugin.ErrOnce
+		}
+		i++
+
+		paths := c.RemainingArgs()
+		if len(paths) == 0 {
+			return g, c.ArgErr()
+		}
+		for _, path := range paths {
+			if !filepath.IsAbs(path) && config.Root != "" {
+				path = filepath.Join(config.Root, path)
+			}
+			r, err := maxminddb.Open(path)
+			if err != nil {
+				return g, c.Errf("failed to open database %s: %v", path, err)
+			}
+			log.Debugf("Loaded %s database %s", r.Metadata.DatabaseType, path)
+			g.readers = append(g.readers, r)
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "edns-subnet":
+				if c.NextArg() {
+					return g, c.ArgErr()
+				}
+				g.edns0Subnet = true
+			default:
+				return g, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	return g, nil
+}
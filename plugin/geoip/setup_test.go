@@ -0,0 +1,67 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	city := writeTestDBFile(t, dir, "city.mmdb", "Test-City", testNetworks)
+	asn := writeTestDBFile(t, dir, "asn.mmdb", "Test-ASN", testASNetworks)
+	notADB := filepath.Join(dir, "not.mmdb")
+	if err := ioutil.WriteFile(notADB, []byte("this is not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedErrStr string
+		expectedDBs    int
+		expectedSubnet bool
+	}{
+		{"geoip " + city, false, "", 1, false},
+		{"geoip " + city + " " + asn, false, "", 2, false},
+		{"geoip " + city + " {\n edns-subnet\n}", false, "", 1, true},
+		{"geoip", true, "Wrong argument count", 0, false},
+		{"geoip " + filepath.Join(dir, "missing.mmdb"), true, "failed to open database", 0, false},
+		{"geoip " + notADB, true, "invalid MaxMind DB file", 0, false},
+		{"geoip " + city + " {\n edns-subnet yes\n}", true, "Wrong argument count", 0, false},
+		{"geoip " + city + " {\n language nl\n}", true, "unknown property", 0, false},
+		{"geoip " + city + "\ngeoip " + asn, true, "plugin", 0, false},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		g, err := parse(c)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error but found none for input %s", i, tc.input)
+			} else if !strings.Contains(err.Error(), tc.expectedErrStr) {
+				t.Errorf("Test %d: expected error to contain %q, got %q", i, tc.expectedErrStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if len(g.readers) != tc.expectedDBs {
+			t.Errorf("Test %d: expected %d databases, got %d", i, tc.expectedDBs, len(g.readers))
+		}
+		if g.edns0Subnet != tc.expectedSubnet {
+			t.Errorf("Test %d: expected edns-subnet %t, got %t", i, tc.expectedSubnet, g.edns0Subnet)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package gslb implements a plugin that answers for host names with the healthy addresses of weighted pools, and
+// fails over to backup pools when a pool has no healthy addresses left.
+package gslb
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/shuffle"
+	"github.com/coredns/coredns/plugin/pkg/up"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// GSLB is a plugin that answers A and AAAA queries for its host names from pools of health checked addresses.
+type GSLB struct {
+	Next  plugin.Handler
+	Hosts []*Host
+}
+
+// Host holds the pools that the queries for Names are answered from.
+type Host struct {
+	Names []string
+	// Pools are sorted by priority.
+	Pools []*Pool
+
+	ttl      uint32
+	check    *check
+	interval time.Duration
+	maxFails uint32
+	stop     chan struct{}
+}
+
+// Pool is a named group of addresses. Answers are given from the pools with the lowest priority that have healthy
+// members, the other pools are backups.
+type Pool struct {
+	Name     string
+	Priority int
+	Members  []*Member
+}
+
+// Member is an address in a pool.
+type Member struct {
+	Addr   net.IP
+	Weight int
+
+	fails uint32
+	probe *up.Probe
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (g GSLB) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	h := g.host(state.Name())
+	if h == nil {
+		return plugin.NextOrFailure(g.Name(), g.Next, ctx, w, r)
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	switch state.QType() {
+	case dns.TypeA, dns.TypeAAAA:
+		for _, member := range order(h.members(state.QType())) {
+			m.Answer = append(m.Answer, h.rr(state.QName(), member.Addr))
+		}
+	}
+
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// Name implements the plugin.Handler interface.
+func (g GSLB) Name() string { return "gslb" }
+
+// host returns the host that answers for name, or nil if there is none.
+func (g GSLB) host(name string) *Host {
+	for _, h := range g.Hosts {
+		for _, n := range h.Names {
+			if n == name {
+				return h
+			}
+		}
+	}
+	return nil
+}
+
+// members returns the members of the address family of qtype to answer with: the healthy members of the pools with the
+// lowest priority that have any. When none of the members is healthy, the members of the pools with the lowest
+// priority are returned, as there is nothing better to give.
+func (h *Host) members(qtype uint16) []*Member {
+	var first []*Member
+	for i := 0; i < len(h.Pools); {
+		var all, healthy []*Member
+		for priority := h.Pools[i].Priority; i < len(h.Pools) && h.Pools[i].Priority == priority; i++ {
+			for _, m := range h.Pools[i].Members {
+				if qtypeOf(m.Addr) != qtype {
+					continue
+				}
+				all = append(all, m)
+				if !m.Down(h.maxFails) {
+					healthy = append(healthy, m)
+				}
+			}
+		}
+		if len(healthy) > 0 {
+			return healthy
+		}
+		if first == nil {
+			first = all
+		}
+	}
+	return first
+}
+
+// rr returns the A or AAAA record for name with addr.
+func (h *Host) rr(name string, addr net.IP) dns.RR {
+	hdr := dns.RR_Header{Name: name, Class: dns.ClassINET, Ttl: h.ttl}
+	if ip := addr.To4(); ip != nil {
+		hdr.Rrtype = dns.TypeA
+		return &dns.A{Hdr: hdr, A: ip}
+	}
+	hdr.Rrtype = dns.TypeAAAA
+	return &dns.AAAA{Hdr: hdr, AAAA: addr}
+}
+
+// Down returns true if the member has failed at least maxFails health checks in a row.
+func (m *Member) Down(maxFails uint32) bool {
+	if maxFails == 0 {
+		return false
+	}
+	return atomic.LoadUint32(&m.fails) >= maxFails
+}
+
+// qtypeOf returns the query type that is answered with ip.
+func qtypeOf(ip net.IP) uint16 {
+	if ip.To4() != nil {
+		return dns.TypeA
+	}
+	return dns.TypeAAAA
+}
+
+// order returns the members in a weighted random order: a member is picked for the first position with a probability
+// proportional to its weight, and so on for the next positions.
+func order(members []*Member) []*Member {
+	ms := make([]*Member, len(members))
+	copy(ms, members)
+
+	shuffle.Weighted(len(ms), func(i int) int { return ms[i].Weight }, func(i, j int) { ms[i], ms[j] = ms[j], ms[i] })
+	return ms
+}
@@ -0,0 +1,148 @@
+package gslb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/caddyserver/caddy"
+	"github.com/miekg/dns"
+)
+
+func newTestGSLB(t *testing.T, input string) GSLB {
+	c := caddy.NewTestController("dns", input)
+	g, err := parse(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Next = test.NextHandler(dns.RcodeNameError, nil)
+	return g
+}
+
+func TestServeDNS(t *testing.T) {
+	g := newTestGSLB(t, `gslb www.example.org {
+		pool backup 2 192.0.2.20 2001:db8::20
+		pool primary 1 192.0.2.1 192.0.2.2 2001:db8::1
+		ttl 10
+	}`)
+	h := g.Hosts[0]
+	primary, backup := h.Pools[0], h.Pools[1]
+
+	tests := []struct {
+		qname    string
+		qtype    uint16
+		down     []*Member
+		rcode    int
+		expected []string
+	}{
+		{"www.example.org.", dns.TypeA, nil, dns.RcodeSuccess, []string{"192.0.2.1", "192.0.2.2"}},
+		{"WWW.example.org.", dns.TypeA, nil, dns.RcodeSuccess, []string{"192.0.2.1", "192.0.2.2"}},
+		{"www.example.org.", dns.TypeAAAA, nil, dns.RcodeSuccess, []string{"2001:db8::1"}},
+		{"www.example.org.", dns.TypeA, []*Member{primary.Members[0]}, dns.RcodeSuccess, []string{"192.0.2.2"}},
+		// Fail over to the backup pool.
+		{"www.example.org.", dns.TypeA, primary.Members[:2], dns.RcodeSuccess, []string{"192.0.2.20"}},
+		// The primary pool still has an IPv6 member.
+		{"www.example.org.", dns.TypeAAAA, primary.Members[:2], dns.RcodeSuccess, []string{"2001:db8::1"}},
+		{"www.example.org.", dns.TypeAAAA, primary.Members, dns.RcodeSuccess, []string{"2001:db8::20"}},
+		// Everything is down, answer with the primary pool.
+		{"www.example.org.", dns.TypeA, append(primary.Members[:2:2], backup.Members...), dns.RcodeSuccess, []string{"192.0.2.1", "192.0.2.2"}},
+		{"www.example.org.", dns.TypeMX, nil, dns.RcodeSuccess, nil},
+		{"example.org.", dns.TypeA, nil, dns.RcodeNameError, nil},
+	}
+
+	for i, tc := range tests {
+		for _, p := range h.Pools {
+			for _, m := range p.Members {
+				m.fails = 0
+			}
+		}
+		for _, m := range tc.down {
+			m.fails = h.maxFails
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rcode, err := g.ServeDNS(context.TODO(), rec, m)
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %d, got %d", i, tc.rcode, rcode)
+			continue
+		}
+		if rcode != dns.RcodeSuccess {
+			continue
+		}
+		if !rec.Msg.Authoritative {
+			t.Errorf("Test %d: expected an authoritative answer", i)
+		}
+
+		got := map[string]bool{}
+		for _, rr := range rec.Msg.Answer {
+			if rr.Header().Ttl != 10 || rr.Header().Name != tc.qname {
+				t.Errorf("Test %d: unexpected record %s", i, rr)
+			}
+			switch x := rr.(type) {
+			case *dns.A:
+				got[x.A.String()] = true
+			case *dns.AAAA:
+				got[x.AAAA.String()] = true
+			}
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, rec.Msg.Answer)
+			continue
+		}
+		for _, addr := range tc.expected {
+			if !got[addr] {
+				t.Errorf("Test %d: expected %v, got %v", i, tc.expected, rec.Msg.Answer)
+			}
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	g := newTestGSLB(t, `gslb www.example.org {
+		pool primary 1 192.0.2.1@1 192.0.2.2@9 192.0.2.3@0000010
+	}`)
+	members := g.Hosts[0].Pools[0].Members
+	before := append([]*Member(nil), members...)
+
+	first := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		ms := order(members)
+		if len(ms) != len(members) {
+			t.Fatalf("Expected %d members, got %d", len(members), len(ms))
+		}
+		seen := map[*Member]bool{}
+		for _, m := range ms {
+			seen[m] = true
+		}
+		if len(seen) != len(members) {
+			t.Fatalf("Expected every member once, got %v", ms)
+		}
+		first[ms[0].Addr.String()]++
+	}
+
+	// 192.0.2.1 should come first 100 times, the others about 900 and 1000 times.
+	if n := first["192.0.2.1"]; n < 30 || n > 250 {
+		t.Errorf("Expected 192.0.2.1 to come first about 100 times, got %d", n)
+	}
+	for _, addr := range []string{"192.0.2.2", "192.0.2.3"} {
+		if n := first[addr]; n < 700 || n > 1200 {
+			t.Errorf("Expected %s to come first about 900-1000 times, got %d", addr, n)
+		}
+	}
+	for i := range before {
+		if members[i] != before[i] {
+			t.Errorf("Expected order to leave the order of the pool alone")
+		}
+	}
+	if len(order(nil)) != 0 {
+		t.Errorf("Expected no members")
+	}
+}
@@ -0,0 +1,138 @@
+package gslb
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// check is an active health check of the members of the pools.
+type check struct {
+	// proto is tcp, http or https.
+	proto string
+	port  string
+	// path and host are the path and Host header of HTTP checks, the host is also used as the TLS server name.
+	path string
+	host string
+
+	timeout time.Duration
+	client  *http.Client
+}
+
+func newCheck(proto, port, path, host string, timeout time.Duration) *check {
+	c := &check{proto: proto, port: port, path: path, host: host, timeout: timeout}
+	if proto == "http" || proto == "https" {
+		c.client = &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{ServerName: host},
+				DisableKeepAlives: true,
+			},
+			// A redirect means the member is up.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+	}
+	return c
+}
+
+// do checks the health of ip, it returns nil if it is healthy.
+func (c *check) do(ip net.IP) error {
+	addr := net.JoinHostPort(ip.String(), c.port)
+	if c.proto == "tcp" {
+		conn, err := net.DialTimeout("tcp", addr, c.timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, c.proto+"://"+addr+c.path, nil)
+	if err != nil {
+		return err
+	}
+	if c.host != "" {
+		req.Host = c.host
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// start starts the health checks of the members. Every interval a round of health checks is done, a member that is
+// failing is checked again, with an increasing interval, until it is healthy.
+func (h *Host) start() {
+	if h.check == nil {
+		return
+	}
+	for _, p := range h.Pools {
+		for _, m := range p.Members {
+			m.probe.Start(h.interval)
+		}
+	}
+
+	h.stop = make(chan struct{})
+	go func() {
+		tick := time.NewTicker(h.interval)
+		defer tick.Stop()
+		for {
+			for _, p := range h.Pools {
+				for _, m := range p.Members {
+					h.healthcheck(p, m)
+				}
+			}
+			select {
+			case <-tick.C:
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// shutdown stops the health checks.
+func (h *Host) shutdown() {
+	if h.stop == nil {
+		return
+	}
+	close(h.stop)
+	for _, p := range h.Pools {
+		for _, m := range p.Members {
+			m.probe.Stop()
+		}
+	}
+}
+
+// healthcheck kicks off a health check of the member m of pool p. It is a noop if m is still being checked.
+func (h *Host) healthcheck(p *Pool, m *Member) {
+	addr := m.Addr.String()
+	m.probe.Do(func() error {
+		err := h.check.do(m.Addr)
+		if err != nil {
+			HealthcheckFailureCount.WithLabelValues(h.Names[0], p.Name, addr).Inc()
+			if atomic.AddUint32(&m.fails, 1) == h.maxFails {
+				log.Warningf("%s in pool %s of %s is down: %s", addr, p.Name, h.Names[0], err)
+				MemberHealthy.WithLabelValues(h.Names[0], p.Name, addr).Set(0)
+			}
+			return err
+		}
+
+		if m.Down(h.maxFails) {
+			log.Infof("%s in pool %s of %s is up", addr, p.Name, h.Names[0])
+		}
+		atomic.StoreUint32(&m.fails, 0)
+		MemberHealthy.WithLabelValues(h.Names[0], p.Name, addr).Set(1)
+		return nil
+	})
+}
@@ -0,0 +1,103 @@
+package gslb
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	c := newCheck("tcp", port, "", "", time.Second)
+	if err := c.do(net.ParseIP("127.0.0.1")); err != nil {
+		t.Errorf("Expected healthy, got %v", err)
+	}
+	l.Close()
+	if err := c.do(net.ParseIP("127.0.0.1")); err == nil {
+		t.Errorf("Expected an error after closing the listener")
+	}
+}
+
+func TestCheckHTTP(t *testing.T) {
+	var status int32 = http.StatusOK
+	host := ""
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	ip := net.ParseIP(u.Hostname())
+
+	c := newCheck("http", u.Port(), "/health", "www.example.org", time.Second)
+	if err := c.do(ip); err != nil {
+		t.Errorf("Expected healthy, got %v", err)
+	}
+	if host != "www.example.org" {
+		t.Errorf("Expected Host header www.example.org, got %s", host)
+	}
+
+	atomic.StoreInt32(&status, http.StatusFound)
+	if err := c.do(ip); err != nil {
+		t.Errorf("Expected a redirect to be healthy, got %v", err)
+	}
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	if err := c.do(ip); err == nil {
+		t.Errorf("Expected an error for status %d", status)
+	}
+	c = newCheck("http", u.Port(), "/", "", time.Second)
+	if err := c.do(ip); err == nil {
+		t.Errorf("Expected an error for status 404")
+	}
+}
+
+func TestHealthcheck(t *testing.T) {
+	var healthy int32 = 1
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+
+	g := newTestGSLB(t, `gslb www.example.org {
+		pool primary 1 `+u.Hostname()+`
+		check http `+u.Port()+`
+		interval 10ms
+		max_fails 2
+	}`)
+	h := g.Hosts[0]
+	m := h.Pools[0].Members[0]
+	h.start()
+	defer h.shutdown()
+
+	waitFor := func(down bool) {
+		for i := 0; i < 200; i++ {
+			if m.Down(h.maxFails) == down {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Expected down to be %t", down)
+	}
+
+	waitFor(false)
+	atomic.StoreInt32(&healthy, 0)
+	waitFor(true)
+	atomic.StoreInt32(&healthy, 1)
+	waitFor(false)
+}
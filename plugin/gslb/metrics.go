@@ -0,0 +1,25 @@
+package gslb
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Variables declared for monitoring.
+var (
+	// HealthcheckFailureCount is the number of failed health checks per member.
+	HealthcheckFailureCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "gslb",
+		Name:      "healthcheck_failures_total",
+		Help:      "Counter of the number of failed health checks per member.",
+	}, []string{"name", "pool", "address"})
+	// MemberHealthy is 1 if a member is healthy, and 0 if it is down.
+	MemberHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "gslb",
+		Name:      "member_healthy",
+		Help:      "Gauge that is 1 if a member is healthy, and 0 if it is down.",
+	}, []string{"name", "pool", "address"})
+)
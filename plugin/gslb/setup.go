@@ -0,0 +1,229 @@
+package gslb
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/up"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("gslb")
+
+func init() { plugin.Register("gslb", setup) }
+
+const (
+	defaultTTL      = 30
+	defaultInterval = 5 * time.Second
+	defaultTimeout  = 2 * time.Second
+	defaultMaxFails = 2
+)
+
+func setup(c *caddy.Controller) error {
+	g, err := parse(c)
+	if err != nil {
+		return plugin.Error("gslb", err)
+	}
+
+	c.OnStartup(func() error {
+		metrics.MustRegister(c, HealthcheckFailureCount, MemberHealthy)
+		for _, h := range g.Hosts {
+			h.start()
+		}
+		return nil
+	})
+	c.OnShutdown(func() error {
+		for _, h := range g.Hosts {
+			h.shutdown()
+		}
+		return nil
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		g.Next = next
+		return g
+	})
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (GSLB, error) {
+	g := GSLB{}
+	for c.Next() {
+		h, err := parseHost(c)
+		if err != nil {
+			return g, err
+		}
+		for _, name := range h.Names {
+			if g.host(name) != nil {
+				return g, c.Errf("name '%s' is defined more than once", name)
+			}
+		}
+		g.Hosts = append(g.Hosts, h)
+	}
+	return g, nil
+}
+
+func parseHost(c *caddy.Controller) (*Host, error) {
+	h := &Host{ttl: defaultTTL, interval: defaultInterval, maxFails: defaultMaxFails}
+	timeout := defaultTimeout
+	var checkArgs []string
+
+	names := c.RemainingArgs()
+	if len(names) == 0 {
+		return nil, c.ArgErr()
+	}
+	for _, name := range names {
+		h.Names = append(h.Names, plugin.Name(name).Normalize())
+	}
+
+	for c.NextBlock() {
+		switch c.Val() {
+		case "pool":
+			p, err := parsePool(c)
+			if err != nil {
+				return nil, err
+			}
+			for _, other := range h.Pools {
+				if other.Name == p.Name {
+					return nil, c.Errf("pool '%s' is defined more than once", p.Name)
+				}
+			}
+			h.Pools = append(h.Pools, p)
+		case "check":
+			checkArgs = c.RemainingArgs()
+			if len(checkArgs) == 0 {
+				return nil, c.ArgErr()
+			}
+		case "interval", "timeout":
+			option := c.Val()
+			if !c.NextArg() {
+				return nil, c.ArgErr()
+			}
+			d, err := time.ParseDuration(c.Val())
+			if err != nil {
+				return nil, c.Errf("invalid %s '%s': %v", option, c.Val(), err)
+			}
+			if d <= 0 {
+				return nil, c.Errf("%s must be positive: %s", option, d)
+			}
+			if option == "interval" {
+				h.interval = d
+			} else {
+				timeout = d
+			}
+			if c.NextArg() {
+				return nil, c.ArgErr()
+			}
+		case "max_fails":
+			if !c.NextArg() {
+				return nil, c.ArgErr()
+			}
+			n, err := strconv.ParseUint(c.Val(), 10, 32)
+			if err != nil {
+				return nil, c.Errf("invalid max_fails '%s'", c.Val())
+			}
+			h.maxFails = uint32(n)
+			if c.NextArg() {
+				return nil, c.ArgErr()
+			}
+		case "ttl":
+			if !c.NextArg() {
+				return nil, c.ArgErr()
+			}
+			ttl, err := strconv.ParseUint(c.Val(), 10, 32)
+			if err != nil {
+				return nil, c.Errf("invalid ttl '%s'", c.Val())
+			}
+			h.ttl = uint32(ttl)
+			if c.NextArg() {
+				return nil, c.ArgErr()
+			}
+		default:
+			return nil, c.Errf("unknown property '%s'", c.Val())
+		}
+	}
+
+	if len(h.Pools) == 0 {
+		return nil, c.Err("at least one pool is required")
+	}
+	sort.SliceStable(h.Pools, func(i, j int) bool { return h.Pools[i].Priority < h.Pools[j].Priority })
+
+	if checkArgs != nil {
+		chk, err := parseCheck(c, checkArgs, timeout)
+		if err != nil {
+			return nil, err
+		}
+		h.check = chk
+	}
+	return h, nil
+}
+
+// parsePool parses pool NAME PRIORITY ADDRESS[@WEIGHT]...
+func parsePool(c *caddy.Controller) (*Pool, error) {
+	args := c.RemainingArgs()
+	if len(args) < 3 {
+		return nil, c.ArgErr()
+	}
+	priority, err := strconv.Atoi(args[1])
+	if err != nil || priority < 0 {
+		return nil, c.Errf("invalid priority '%s' of pool '%s'", args[1], args[0])
+	}
+	p := &Pool{Name: args[0], Priority: priority}
+	for _, arg := range args[2:] {
+		addr, weight := arg, 1
+		if i := strings.LastIndex(arg, "@"); i >= 0 {
+			addr = arg[:i]
+			weight, err = strconv.Atoi(arg[i+1:])
+			if err != nil || weight < 1 {
+				return nil, c.Errf("invalid weight of '%s' in pool '%s'", arg, p.Name)
+			}
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, c.Errf("invalid address '%s' in pool '%s'", addr, p.Name)
+		}
+		p.Members = append(p.Members, &Member{Addr: ip, Weight: weight, probe: up.New()})
+	}
+	return p, nil
+}
+
+// parseCheck parses the arguments of check: tcp PORT, or http|https PORT [PATH [HOST]].
+func parseCheck(c *caddy.Controller, args []string, timeout time.Duration) (*check, error) {
+	proto := args[0]
+	switch proto {
+	case "tcp":
+		if len(args) != 2 {
+			return nil, c.ArgErr()
+		}
+	case "http", "https":
+		if len(args) < 2 || len(args) > 4 {
+			return nil, c.ArgErr()
+		}
+	default:
+		return nil, c.Errf("unsupported check '%s'", proto)
+	}
+	if port, err := strconv.ParseUint(args[1], 10, 16); err != nil || port == 0 {
+		return nil, c.Errf("invalid port '%s' of check", args[1])
+	}
+
+	path, host := "/", ""
+	if len(args) > 2 {
+		path = args[2]
+		if !strings.HasPrefix(path, "/") {
+			return nil, c.Errf("path of check must start with /: %s", path)
+		}
+	}
+	if len(args) > 3 {
+		host = args[3]
+	}
+	return newCheck(proto, args[1], path, host, timeout), nil
+}
@@ -0,0 +1,147 @@
+package gslb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input          string
+		shouldErr      bool
+		expectedErrStr string
+	}{
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+		}`, false, ""},
+		{`gslb www.example.org web.example.org {
+			pool primary 1 192.0.2.1@3 192.0.2.2 2001:db8::1@2
+			pool backup 2 198.51.100.1
+			check https 443 /healthz www.example.org
+			interval 10s
+			timeout 1s
+			max_fails 3
+			ttl 60
+		}
+		gslb api.example.org {
+			pool primary 0 192.0.2.3
+			check tcp 8443
+		}`, false, ""},
+		{`gslb`, true, "Wrong argument count"},
+		{`gslb www.example.org`, true, "at least one pool"},
+		{`gslb www.example.org {
+			pool primary 1
+		}`, true, "Wrong argument count"},
+		{`gslb www.example.org {
+			pool primary first 192.0.2.1
+		}`, true, "invalid priority"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.300
+		}`, true, "invalid address"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1@0
+		}`, true, "invalid weight"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			pool primary 2 192.0.2.2
+		}`, true, "more than once"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+		}
+		gslb WWW.example.org {
+			pool primary 1 192.0.2.1
+		}`, true, "more than once"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			check icmp
+		}`, true, "unsupported check"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			check tcp
+		}`, true, "Wrong argument count"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			check http 80000
+		}`, true, "invalid port"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			check http 80 healthz
+		}`, true, "must start with /"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			interval 0s
+		}`, true, "must be positive"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			timeout soon
+		}`, true, "invalid timeout"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			max_fails -1
+		}`, true, "invalid max_fails"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			ttl 10 20
+		}`, true, "Wrong argument count"},
+		{`gslb www.example.org {
+			pool primary 1 192.0.2.1
+			weight 10
+		}`, true, "unknown property"},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		_, err := parse(c)
+		if tc.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: expected error but found none for input %s", i, tc.input)
+			} else if !strings.Contains(err.Error(), tc.expectedErrStr) {
+				t.Errorf("Test %d: expected error to contain %q, got %q", i, tc.expectedErrStr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+		}
+	}
+}
+
+func TestSetupOptions(t *testing.T) {
+	c := caddy.NewTestController("dns", `gslb www.example.org WEB.example.org {
+		pool backup 2 198.51.100.1
+		pool primary 1 192.0.2.1@3 192.0.2.2
+		check https 443 /healthz www.example.org
+		interval 10s
+		timeout 1s
+		max_fails 3
+		ttl 60
+	}`)
+	g, err := parse(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := g.Hosts[0]
+
+	if len(h.Names) != 2 || h.Names[0] != "www.example.org." || h.Names[1] != "web.example.org." {
+		t.Errorf("Expected names www.example.org. and web.example.org., got %v", h.Names)
+	}
+	if h.Pools[0].Name != "primary" || h.Pools[1].Name != "backup" {
+		t.Errorf("Expected the pools to be sorted by priority, got %s, %s", h.Pools[0].Name, h.Pools[1].Name)
+	}
+	if w := h.Pools[0].Members[0].Weight; w != 3 {
+		t.Errorf("Expected weight 3, got %d", w)
+	}
+	if w := h.Pools[0].Members[1].Weight; w != 1 {
+		t.Errorf("Expected weight 1, got %d", w)
+	}
+	if h.interval != 10*time.Second || h.maxFails != 3 || h.ttl != 60 {
+		t.Errorf("Expected interval 10s, max_fails 3 and ttl 60, got %s, %d and %d", h.interval, h.maxFails, h.ttl)
+	}
+	if h.check == nil || h.check.proto != "https" || h.check.port != "443" || h.check.path != "/healthz" ||
+		h.check.host != "www.example.org" || h.check.timeout != time.Second {
+		t.Errorf("Unexpected check %+v", h.check)
+	}
+}
@@ -44,12 +44,8 @@ func (h Hosts) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (
 			return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
 		}
 		answers = h.ptr(qname, h.options.ttl, names)
-	case dns.TypeA:
-		ips := h.LookupStaticHostV4(qname)
-		answers = a(qname, h.options.ttl, ips)
-	case dns.TypeAAAA:
-		ips := h.LookupStaticHostV6(qname)
-		answers = aaaa(qname, h.options.ttl, ips)
+	default:
+		answers = h.records(qname, state.QType())
 	}
 
 	// Only on NXDOMAIN we will fallthrough.
@@ -79,7 +75,38 @@ func (h Hosts) otherRecordsExist(qname string) bool {
 	if len(h.LookupStaticHostV6(qname)) > 0 {
 		return true
 	}
-	return false
+	return h.hasRecords(qname)
+}
+
+// maxChain is the maximum number of CNAMEs followed for an answer.
+const maxChain = 8
+
+// records returns the records of type qtype for qname. If qname is an alias its CNAME record is returned instead,
+// followed by the records of its target, as far as those are in the hosts file as well.
+func (h Hosts) records(qname string, qtype uint16) []dns.RR {
+	var answers []dns.RR
+	for i := 0; i < maxChain; i++ {
+		var rrs []dns.RR
+		switch qtype {
+		case dns.TypeA:
+			rrs = a(qname, h.options.ttl, h.LookupStaticHostV4(qname))
+		case dns.TypeAAAA:
+			rrs = aaaa(qname, h.options.ttl, h.LookupStaticHostV6(qname))
+		default:
+			rrs = h.LookupStaticRecords(qname, qtype, h.options.ttl)
+		}
+		if len(rrs) > 0 || qtype == dns.TypeCNAME {
+			return append(answers, rrs...)
+		}
+
+		cname := h.LookupStaticRecords(qname, dns.TypeCNAME, h.options.ttl)
+		if len(cname) == 0 {
+			return answers
+		}
+		answers = append(answers, cname[0])
+		qname = cname[0].(*dns.CNAME).Target
+	}
+	return answers
 }
 
 // Name implements the plugin.Handle interface.
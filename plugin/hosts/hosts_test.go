@@ -118,3 +118,109 @@ const hostsExample = `
 reload 5s
 timeout 3600
 `
+
+func TestLookupRecords(t *testing.T) {
+	h := Hosts{
+		Next: test.NextHandler(dns.RcodeNameError, nil),
+		Hostsfile: &Hostsfile{
+			Origins: []string{"example.org."},
+			hmap:    newMap(),
+			inline:  newMap(),
+			options: newOptions(),
+		},
+	}
+	h.options.ttl = 300
+	h.hmap = h.parse(strings.NewReader(recordsExample))
+
+	for i, tc := range recordsTestCases {
+		m := tc.Msg()
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rcode, err := h.ServeDNS(context.Background(), rec, m)
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if rcode != tc.Rcode {
+			t.Errorf("Test %d: expected rcode %d, got %d", i, tc.Rcode, rcode)
+			continue
+		}
+		if tc.Rcode != dns.RcodeSuccess {
+			continue
+		}
+		if err := test.SortAndCheck(rec.Msg, tc); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+var recordsTestCases = []test.Case{
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("web.example.org. 300 IN A 10.0.0.1"),
+			test.CNAME("www.example.org. 300 IN CNAME web.example.org."),
+		},
+	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeCNAME,
+		Answer: []dns.RR{
+			test.CNAME("www.example.org. 300 IN CNAME web.example.org."),
+		},
+	},
+	{
+		Qname: "www.example.org.", Qtype: dns.TypeAAAA,
+		Answer: []dns.RR{
+			test.CNAME("www.example.org. 300 IN CNAME web.example.org."),
+		},
+	},
+	{
+		Qname: "external.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.CNAME("external.example.org. 300 IN CNAME example.net."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{
+			test.TXT(`example.org. 300 IN TXT "hello world"`),
+			test.TXT(`example.org. 300 IN TXT "v=spf1 -all"`),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeMX,
+		Answer: []dns.RR{
+			test.MX("example.org. 300 IN MX 10 mail.example.org."),
+		},
+	},
+	{
+		Qname: "_http._tcp.example.org.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.example.org. 300 IN SRV 0 10 8080 web.example.org."),
+		},
+	},
+	{
+		Qname: "example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{},
+	},
+	{
+		Qname: "ignored.example.org.", Qtype: dns.TypeNS,
+		Rcode: dns.RcodeServerFailure,
+	},
+	{
+		Qname: "bad.example.org.", Qtype: dns.TypeMX,
+		Rcode: dns.RcodeServerFailure,
+	},
+}
+
+const recordsExample = `
+10.0.0.1 web.example.org
+www.example.org CNAME web.example.org
+external.example.org cname example.net.
+example.org TXT "v=spf1 -all"
+example.org TXT "hello world"
+example.org MX 10 mail.example.org.
+_http._tcp.example.org SRV 0 10 8080 web.example.org.
+ignored.example.org NS ns.example.org.
+bad.example.org MX mail.example.org.
+www.example.net CNAME web.example.org
+`
@@ -12,11 +12,14 @@ import (
 	"io"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+
+	"github.com/miekg/dns"
 )
 
 // parseIP calls discards any v6 zone info, before calling net.ParseIP.
@@ -59,17 +62,38 @@ type Map struct {
 	// including IPv6 address without zone identifier.
 	// We don't support old-classful IP address notation.
 	addr map[string][]string
+
+	// Key for the list of CNAME, TXT, SRV and MX records must be a FQDN lowercased owner name.
+	records map[string][]dns.RR
 }
 
 func newMap() *Map {
 	return &Map{
-		name4: make(map[string][]net.IP),
-		name6: make(map[string][]net.IP),
-		addr:  make(map[string][]string),
+		name4:   make(map[string][]net.IP),
+		name6:   make(map[string][]net.IP),
+		addr:    make(map[string][]string),
+		records: make(map[string][]dns.RR),
+	}
+}
+
+// merge adds the entries of m to h.
+func (h *Map) merge(m *Map) {
+	for name, ips := range m.name4 {
+		h.name4[name] = append(h.name4[name], ips...)
+	}
+	for name, ips := range m.name6 {
+		h.name6[name] = append(h.name6[name], ips...)
+	}
+	for addr, names := range m.addr {
+		h.addr[addr] = append(h.addr[addr], names...)
+	}
+	for name, rrs := range m.records {
+		h.records[name] = append(h.records[name], rrs...)
 	}
 }
 
-// Len returns the total number of addresses in the hostmap, this includes V4/V6 and any reverse addresses.
+// Len returns the total number of addresses in the hostmap, this includes V4/V6, any reverse addresses and the
+// other records.
 func (h *Map) Len() int {
 	l := 0
 	for _, v4 := range h.name4 {
@@ -81,6 +105,9 @@ func (h *Map) Len() int {
 	for _, a := range h.addr {
 		l += len(a)
 	}
+	for _, rrs := range h.records {
+		l += len(rrs)
+	}
 	return l
 }
 
@@ -100,46 +127,120 @@ type Hostsfile struct {
 	// path to the hosts file
 	path string
 
-	// mtime and size are only read and modified by a single goroutine
-	mtime time.Time
-	size  int64
+	// includes are the paths or globs of more hosts files, a directory includes the *.hosts files in it.
+	includes []string
+
+	// files are the hosts files that have been read, by path. It's only read and modified by a single goroutine.
+	files map[string]*hostsFile
 
 	options *options
 }
 
-// readHosts determines if the cached data needs to be updated based on the size and modification time of the hostsfile.
+// hostsFile holds the entries of a hosts file, and the size and modification time of the file they were read from.
+type hostsFile struct {
+	mtime time.Time
+	size  int64
+	hmap  *Map
+}
+
+// readHosts determines if the cached data needs to be updated based on the size and modification time of the
+// hostsfiles, and merges the entries of all of them.
 func (h *Hostsfile) readHosts() {
-	file, err := os.Open(h.path)
-	if err != nil {
-		// We already log a warning if the file doesn't exist or can't be opened on setup. No need to return the error here.
-		return
+	paths := h.paths()
+	files := make(map[string]*hostsFile, len(paths))
+	changed := len(paths) != len(h.files)
+	for _, path := range paths {
+		old := h.files[path]
+		f, err := h.readFile(path, old)
+		if err != nil {
+			// We already log a warning if the file doesn't exist or can't be opened on setup. Keep serving what we
+			// have until it's back.
+			f = old
+		}
+		if f != old {
+			changed = true
+			if f != nil {
+				log.Debugf("Parsed hosts file %s into %d entries", path, f.hmap.Len())
+			}
+		}
+		if f != nil {
+			files[path] = f
+		}
 	}
-	defer file.Close()
-
-	stat, err := file.Stat()
-	h.RLock()
-	size := h.size
-	h.RUnlock()
-
-	if err == nil && h.mtime.Equal(stat.ModTime()) && size == stat.Size() {
+	if !changed {
 		return
 	}
 
-	newMap := h.parse(file)
-	log.Debugf("Parsed hosts file into %d entries", newMap.Len())
+	newMap := newMap()
+	var mtime time.Time
+	for _, path := range paths {
+		f, ok := files[path]
+		if !ok {
+			continue
+		}
+		newMap.merge(f.hmap)
+		if f.mtime.After(mtime) {
+			mtime = f.mtime
+		}
+		hostsFileEntries.WithLabelValues(path).Set(float64(f.hmap.Len()))
+	}
+	for path := range h.files {
+		if _, ok := files[path]; !ok {
+			hostsFileEntries.DeleteLabelValues(path)
+		}
+	}
+	h.files = files
 
 	h.Lock()
-
 	h.hmap = newMap
-	// Update the data cache.
-	h.mtime = stat.ModTime()
-	h.size = stat.Size()
-
 	hostsEntries.WithLabelValues().Set(float64(h.inline.Len() + h.hmap.Len()))
-	hostsReloadTime.Set(float64(stat.ModTime().UnixNano()) / 1e9)
+	hostsReloadTime.Set(float64(mtime.UnixNano()) / 1e9)
 	h.Unlock()
 }
 
+// readFile reads the hosts file at path, unless it has the same size and modification time as old, in which case old
+// is returned.
+func (h *Hostsfile) readFile(path string, old *hostsFile) (*hostsFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, os.ErrInvalid
+	}
+	if old != nil && old.mtime.Equal(stat.ModTime()) && old.size == stat.Size() {
+		return old, nil
+	}
+	return &hostsFile{mtime: stat.ModTime(), size: stat.Size(), hmap: h.parse(file)}, nil
+}
+
+// paths returns the paths of the hosts files to read: the hosts file, followed by the files matching each include.
+func (h *Hostsfile) paths() []string {
+	paths := []string{h.path}
+	seen := map[string]bool{h.path: true}
+	for _, include := range h.includes {
+		pattern := include
+		if s, err := os.Stat(include); err == nil && s.IsDir() {
+			pattern = filepath.Join(include, "*.hosts")
+		}
+		// The pattern was checked during setup, so there is no error.
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
+	}
+	return paths
+}
+
 func (h *Hostsfile) initInline(inline []string) {
 	if len(inline) == 0 {
 		return
@@ -165,6 +266,10 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 		}
 		addr := parseIP(string(f[0]))
 		if addr == nil {
+			if rr := h.parseRecord(line); rr != nil {
+				name := strings.ToLower(rr.Header().Name)
+				hmap.records[name] = append(hmap.records[name], rr)
+			}
 			continue
 		}
 
@@ -199,6 +304,30 @@ func (h *Hostsfile) parse(r io.Reader) *Map {
 	return hmap
 }
 
+// recordTypes are the types of the records that can be given as NAME TYPE DATA, next to the hosts entries.
+var recordTypes = map[string]bool{"CNAME": true, "TXT": true, "SRV": true, "MX": true}
+
+// parseRecord parses line as a NAME TYPE DATA record, e.g. "www.example.org CNAME web.example.org". Names are
+// fully qualified. It returns nil if the line isn't such a record, or if the name isn't in Origins.
+func (h *Hostsfile) parseRecord(line []byte) dns.RR {
+	f := strings.Fields(string(line))
+	if len(f) < 3 || !recordTypes[strings.ToUpper(f[1])] {
+		return nil
+	}
+	name := plugin.Name(f[0]).Normalize()
+	if plugin.Zones(h.Origins).Matches(name) == "" {
+		return nil
+	}
+	data := strings.TrimSpace(string(line))
+	data = strings.TrimSpace(data[len(f[0]):])
+	rr, err := dns.NewRR(name + " IN " + data)
+	if err != nil || rr == nil {
+		log.Warningf("Failed to parse hosts record %q: %s", strings.TrimSpace(string(line)), err)
+		return nil
+	}
+	return rr
+}
+
 // lookupStaticHost looks up the IP addresses for the given host from the hosts file.
 func (h *Hostsfile) lookupStaticHost(m map[string][]net.IP, host string) []net.IP {
 	h.RLock()
@@ -254,3 +383,32 @@ func (h *Hostsfile) LookupStaticAddr(addr string) []string {
 	copy(hostsCp[len(hosts1):], hosts2)
 	return hostsCp
 }
+
+// LookupStaticRecords looks up the records of type qtype, other than A, AAAA and PTR, for the given name from the hosts
+// file. The records returned are copies, with the TTL set to ttl.
+func (h *Hostsfile) LookupStaticRecords(name string, qtype uint16, ttl uint32) []dns.RR {
+	name = strings.ToLower(name)
+	h.RLock()
+	defer h.RUnlock()
+
+	var rrs []dns.RR
+	for _, m := range []*Map{h.hmap, h.inline} {
+		for _, rr := range m.records[name] {
+			if rr.Header().Rrtype != qtype {
+				continue
+			}
+			rr = dns.Copy(rr)
+			rr.Header().Ttl = ttl
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs
+}
+
+// hasRecords returns true if there are records, other than A, AAAA and PTR, for the given name in the hosts file.
+func (h *Hostsfile) hasRecords(name string) bool {
+	name = strings.ToLower(name)
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.hmap.records[name]) > 0 || len(h.inline.records[name]) > 0
+}
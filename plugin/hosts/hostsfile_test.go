@@ -5,7 +5,10 @@
 package hosts
 
 import (
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -242,3 +245,55 @@ func TestHostCacheModification(t *testing.T) {
 	}
 	testStaticAddr(t, entip, h)
 }
+
+func TestReadHostsIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hosts", "10.0.0.1 main.example.org\n")
+	write("a.hosts", "10.0.0.2 a.example.org\n")
+	write("b.hosts", "10.0.0.3 b.example.org\n10.0.0.4 main.example.org\n")
+	write("ignored", "10.0.0.5 ignored.example.org\n")
+
+	h := &Hostsfile{
+		Origins:  []string{"."},
+		hmap:     newMap(),
+		inline:   newMap(),
+		path:     filepath.Join(dir, "hosts"),
+		includes: []string{dir},
+		options:  newOptions(),
+	}
+	h.readHosts()
+
+	testStaticHost(t, staticHostEntry{"main.example.org.", []string{"10.0.0.1", "10.0.0.4"}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"a.example.org.", []string{"10.0.0.2"}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"b.example.org.", []string{"10.0.0.3"}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"ignored.example.org.", []string{}, []string{}}, h)
+	if len(h.files) != 3 {
+		t.Errorf("Expected 3 hosts files, got %d", len(h.files))
+	}
+
+	// A new fragment is picked up, a removed one is dropped and a changed one is read again.
+	write("c.hosts", "10.0.0.6 c.example.org\n")
+	if err := os.Remove(filepath.Join(dir, "a.hosts")); err != nil {
+		t.Fatal(err)
+	}
+	write("b.hosts", "10.0.0.7 b.example.org\n")
+	h.readHosts()
+
+	testStaticHost(t, staticHostEntry{"main.example.org.", []string{"10.0.0.1"}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"a.example.org.", []string{}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"b.example.org.", []string{"10.0.0.7"}, []string{}}, h)
+	testStaticHost(t, staticHostEntry{"c.example.org.", []string{"10.0.0.6"}, []string{}}, h)
+	if len(h.files) != 3 {
+		t.Errorf("Expected 3 hosts files, got %d", len(h.files))
+	}
+}
@@ -14,11 +14,18 @@ var (
 		Name:      "entries",
 		Help:      "The combined number of entries in hosts and Corefile.",
 	}, []string{})
+	// hostsFileEntries is the number of entries in each hosts file.
+	hostsFileEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "hosts",
+		Name:      "file_entries",
+		Help:      "The number of entries in each hosts file.",
+	}, []string{"file"})
 	// hostsReloadTime is the timestamp of the last reload of hosts file.
 	hostsReloadTime = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: plugin.Namespace,
 		Subsystem: "hosts",
 		Name:      "reload_timestamp_seconds",
-		Help:      "The timestamp of the last reload of hosts file.",
+		Help:      "The timestamp of the last reload of hosts files.",
 	})
 )
@@ -55,6 +55,7 @@ func setup(c *caddy.Controller) error {
 
 	c.OnStartup(func() error {
 		metrics.MustRegister(c, hostsEntries)
+		metrics.MustRegister(c, hostsFileEntries)
 		metrics.MustRegister(c, hostsReloadTime)
 		return nil
 	})
@@ -129,6 +130,20 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 			switch c.Val() {
 			case "fallthrough":
 				h.Fall.SetZonesFromArgs(c.RemainingArgs())
+			case "include":
+				remaining := c.RemainingArgs()
+				if len(remaining) == 0 {
+					return h, c.ArgErr()
+				}
+				for _, include := range remaining {
+					if !filepath.IsAbs(include) && config.Root != "" {
+						include = filepath.Join(config.Root, include)
+					}
+					if _, err := filepath.Match(include, ""); err != nil {
+						return h, c.Errf("invalid include '%s': %v", include, err)
+					}
+					h.includes = append(h.includes, include)
+				}
 			case "no_reverse":
 				h.options.autoReverse = false
 			case "ttl":
@@ -159,7 +174,14 @@ func hostsParse(c *caddy.Controller) (Hosts, error) {
 				h.options.reload = reload
 			default:
 				if len(h.Fall.Zones) == 0 {
-					line := strings.Join(append([]string{c.Val()}, c.RemainingArgs()...), " ")
+					args := append([]string{c.Val()}, c.RemainingArgs()...)
+					for i := range args {
+						// Quote the TXT data that was quoted in the Corefile again.
+						if strings.ContainsAny(args[i], " \t") {
+							args[i] = strconv.Quote(args[i])
+						}
+					}
+					line := strings.Join(args, " ")
 					inline = append(inline, line)
 					continue
 				}
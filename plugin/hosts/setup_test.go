@@ -1,11 +1,13 @@
 package hosts
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/coredns/coredns/plugin/pkg/fall"
 
 	"github.com/caddyserver/caddy"
+	"github.com/miekg/dns"
 )
 
 func TestHostsParse(t *testing.T) {
@@ -169,3 +171,70 @@ func TestHostsInlineParse(t *testing.T) {
 	}
 
 }
+
+func TestHostsIncludeParse(t *testing.T) {
+	tests := []struct {
+		inputFileRules   string
+		shouldErr        bool
+		expectedIncludes []string
+	}{
+		{
+			`hosts /etc/hosts {
+				include /etc/hosts.d
+			}`,
+			false, []string{"/etc/hosts.d"},
+		},
+		{
+			`hosts /etc/hosts {
+				include /etc/hosts.d /srv/hosts/*.hosts
+				include /etc/hosts.extra
+			}`,
+			false, []string{"/etc/hosts.d", "/srv/hosts/*.hosts", "/etc/hosts.extra"},
+		},
+		{
+			`hosts /etc/hosts {
+				include
+			}`,
+			true, nil,
+		},
+		{
+			`hosts /etc/hosts {
+				include /etc/hosts.d/[.hosts
+			}`,
+			true, nil,
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.inputFileRules)
+		h, err := hostsParse(c)
+		if err == nil && test.shouldErr {
+			t.Fatalf("Test %d expected errors, but got no error", i)
+		} else if err != nil && !test.shouldErr {
+			t.Fatalf("Test %d expected no errors, but got '%v'", i, err)
+		} else if !test.shouldErr && !reflect.DeepEqual(h.includes, test.expectedIncludes) {
+			t.Errorf("Test %d expected includes %v, got %v", i, test.expectedIncludes, h.includes)
+		}
+	}
+}
+
+func TestHostsInlineRecordsParse(t *testing.T) {
+	c := caddy.NewTestController("dns", `hosts highly_unlikely_to_exist_hosts_file example.org {
+		10.0.0.1 web.example.org
+		www.example.org CNAME web.example.org
+		example.org TXT "hello world" "v=spf1 -all"
+	}`)
+	h, err := hostsParse(c)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got '%v'", err)
+	}
+
+	rrs := h.LookupStaticRecords("www.example.org.", dns.TypeCNAME, 3600)
+	if len(rrs) != 1 || rrs[0].(*dns.CNAME).Target != "web.example.org." {
+		t.Errorf("Expected CNAME to web.example.org., got %v", rrs)
+	}
+	rrs = h.LookupStaticRecords("example.org.", dns.TypeTXT, 3600)
+	if len(rrs) != 1 || !reflect.DeepEqual(rrs[0].(*dns.TXT).Txt, []string{"hello world", "v=spf1 -all"}) {
+		t.Errorf("Expected TXT with \"hello world\" \"v=spf1 -all\", got %v", rrs)
+	}
+}
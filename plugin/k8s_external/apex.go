@@ -7,11 +7,24 @@ import (
 	"github.com/miekg/dns"
 )
 
-// serveApex serves request that hit the zone' apex. A reply is written back to the client.
+// serveApex serves request that hit the zone' apex. A reply is written back to the client. Address queries are
+// answered when an Ingress or Gateway API resource uses the apex as its hostname.
 func (e *External) serveApex(state request.Request) (int, error) {
 	m := new(dns.Msg)
 	m.SetReply(state.Req)
 	switch state.QType() {
+	case dns.TypeA, dns.TypeAAAA:
+		if e.ingress || e.gateway {
+			svc, _ := e.externalFunc(state)
+			if state.QType() == dns.TypeA {
+				m.Answer = e.a(svc, state)
+			} else {
+				m.Answer = e.aaaa(svc, state)
+			}
+		}
+		if len(m.Answer) == 0 {
+			m.Ns = []dns.RR{e.soa(state)}
+		}
 	case dns.TypeSOA:
 		m.Answer = []dns.RR{e.soa(state)}
 	case dns.TypeNS:
@@ -30,6 +30,14 @@ type Externaler interface {
 	ExternalAddress(state request.Request) []dns.RR
 }
 
+// HostWatcher is implemented by an Externaler that can also return the addresses of the hostnames of Ingresses
+// and Gateway API resources from External.
+type HostWatcher interface {
+	// WatchExternalHosts starts watching Ingresses and/or Gateways and HTTPRoutes. It is called before the
+	// Externaler is started.
+	WatchExternalHosts(ingress, gateway bool) error
+}
+
 // External resolves Ingress and Loadbalance IPs from kubernetes clusters.
 type External struct {
 	Next  plugin.Handler
@@ -39,6 +47,9 @@ type External struct {
 	apex       string
 	ttl        uint32
 
+	ingress bool // serve the hostnames of Ingresses
+	gateway bool // serve the hostnames of Gateways and HTTPRoutes
+
 	externalFunc     func(request.Request) ([]msg.Service, int)
 	externalAddrFunc func(request.Request) []dns.RR
 }
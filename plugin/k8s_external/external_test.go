@@ -206,3 +206,11 @@ func externalAddress(state request.Request) []dns.RR {
 	a := test.A("example.org. IN A 127.0.0.1")
 	return []dns.RR{a}
 }
+
+func (external) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (external) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (external) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (external) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (external) GetNode(string) *object.Node       { return nil }
+func (external) ExternalHostIndex(string) []string { return nil }
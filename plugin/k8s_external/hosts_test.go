@@ -0,0 +1,101 @@
+package external
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestExternalHosts(t *testing.T) {
+	k := kubernetes.New([]string{"cluster.local."})
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.APIConn = &externalHosts{}
+
+	e := New()
+	e.Zones = []string{"example.com."}
+	e.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	e.externalFunc = k.External
+	e.externalAddrFunc = externalAddress // internal test function
+	e.ingress = true
+
+	ctx := context.TODO()
+	for i, tc := range testsHosts {
+		r := tc.Msg()
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+
+		if _, err := e.ServeDNS(ctx, w, r); err != nil {
+			t.Fatalf("Test %d expected no error, got %v", i, err)
+		}
+		if err := test.SortAndCheck(w.Msg, tc); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+var testsHosts = []test.Case{
+	// Hostname of an Ingress
+	{
+		Qname: "app.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("app.example.com.	5	IN	A	1.2.3.4"),
+		},
+	},
+	{
+		Qname: "app.example.com.", Qtype: dns.TypeAAAA, Rcode: dns.RcodeSuccess,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 12345 7200 1800 86400 5"),
+		},
+	},
+	// No ports, so no SRV records
+	{
+		Qname: "app.example.com.", Qtype: dns.TypeSRV, Rcode: dns.RcodeSuccess,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 12345 7200 1800 86400 5"),
+		},
+	},
+	// Hostname at the apex
+	{
+		Qname: "example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("example.com.	5	IN	A	1.2.3.5"),
+		},
+	},
+	{
+		Qname: "example.com.", Qtype: dns.TypeSOA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 12345 7200 1800 86400 5"),
+		},
+	},
+	// Services are still served
+	{
+		Qname: "svc1.testns.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.example.com.	5	IN	A	1.2.3.4"),
+		},
+	},
+	{
+		Qname: "unknown.example.com.", Qtype: dns.TypeA, Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.com.	5	IN	SOA	ns1.dns.example.com. hostmaster.dns.example.com. 12345 7200 1800 86400 5"),
+		},
+	},
+}
+
+type externalHosts struct {
+	external
+}
+
+func (externalHosts) ExternalHostIndex(host string) []string {
+	switch host {
+	case "app.example.com.":
+		return []string{"1.2.3.4"}
+	case "example.com.":
+		return []string{"1.2.3.5"}
+	}
+	return nil
+}
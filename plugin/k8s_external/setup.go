@@ -1,6 +1,7 @@
 package external
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/coredns/coredns/core/dnsserver"
@@ -17,7 +18,8 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("k8s_external", err)
 	}
 
-	// Do this in OnStartup, so all plugins have been initialized.
+	// Do this in OnStartup, so all plugins have been initialized. Because k8s_external comes before kubernetes
+	// in plugin.cfg, this runs before kubernetes starts watching the cluster.
 	c.OnStartup(func() error {
 		m := dnsserver.GetConfig(c).Handler("kubernetes")
 		if m == nil {
@@ -27,6 +29,15 @@ func setup(c *caddy.Controller) error {
 			e.externalFunc = x.External
 			e.externalAddrFunc = x.ExternalAddress
 		}
+		if e.ingress || e.gateway {
+			x, ok := m.(HostWatcher)
+			if !ok {
+				return plugin.Error("k8s_external", fmt.Errorf("%s can not serve ingress or gateway hostnames", m.Name()))
+			}
+			if err := x.WatchExternalHosts(e.ingress, e.gateway); err != nil {
+				return plugin.Error("k8s_external", err)
+			}
+		}
 		return nil
 	})
 
@@ -72,6 +83,16 @@ func parse(c *caddy.Controller) (*External, error) {
 					return nil, c.ArgErr()
 				}
 				e.apex = args[0]
+			case "ingress":
+				if len(c.RemainingArgs()) != 0 {
+					return nil, c.ArgErr()
+				}
+				e.ingress = true
+			case "gateway":
+				if len(c.RemainingArgs()) != 0 {
+					return nil, c.ArgErr()
+				}
+				e.gateway = true
 			default:
 				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
@@ -46,3 +46,42 @@ func TestSetup(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupHosts(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		ingress   bool
+		gateway   bool
+	}{
+		{`k8s_external example.org`, false, false, false},
+		{`k8s_external example.org {
+			ingress
+}`, false, true, false},
+		{`k8s_external example.org {
+			ingress
+			gateway
+}`, false, true, true},
+		{`k8s_external example.org {
+			gateway httproute
+}`, true, false, false},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		e, err := parse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+		if e.ingress != test.ingress || e.gateway != test.gateway {
+			t.Errorf("Test %d, expected ingress %t and gateway %t for input %s, got: %t and %t", i, test.ingress, test.gateway, test.input, e.ingress, e.gateway)
+		}
+	}
+}
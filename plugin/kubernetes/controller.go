@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,9 +12,14 @@ import (
 	"github.com/coredns/coredns/plugin/kubernetes/object"
 
 	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	networking "k8s.io/api/networking/v1beta1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -24,6 +30,9 @@ const (
 	svcIPIndex            = "ServiceIP"
 	epNameNamespaceIndex  = "EndpointNameNamespace"
 	epIPIndex             = "EndpointsIP"
+	svcImportIndex        = "ServiceImportNameNamespace"
+	mcEpIndex             = "MultiClusterEndpointsNameNamespace"
+	hostnameIndex         = "Hostname"
 )
 
 type dnsController interface {
@@ -35,7 +44,16 @@ type dnsController interface {
 	EpIndex(string) []*object.Endpoints
 	EpIndexReverse(string) []*object.Endpoints
 
+	ServiceImportList() []*object.ServiceImport
+	MultiClusterEndpointsList() []*object.MultiClusterEndpoints
+	SvcImportIndex(string) []*object.ServiceImport
+	McEpIndex(string) []*object.MultiClusterEndpoints
+
+	// ExternalHostIndex returns the addresses of the Ingresses, Gateways and HTTPRoutes for a hostname.
+	ExternalHostIndex(string) []string
+
 	GetNodeByName(context.Context, string) (*api.Node, error)
+	GetNode(string) *object.Node
 	GetNamespaceByName(string) (*api.Namespace, error)
 
 	Run()
@@ -52,20 +70,38 @@ type dnsControl struct {
 	// aligned ( we use sync.LoadAtomic with this )
 	modified int64
 
-	client kubernetes.Interface
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
 
 	selector          labels.Selector
 	namespaceSelector labels.Selector
 
-	svcController cache.Controller
-	podController cache.Controller
-	epController  cache.Controller
-	nsController  cache.Controller
+	svcController  cache.Controller
+	podController  cache.Controller
+	epController   cache.Controller
+	nsController   cache.Controller
+	nodeController cache.Controller
+
+	svcImportController cache.Controller
+	mcEpController      cache.Controller
+
+	ingressController cache.Controller
+	gatewayController cache.Controller
+	routeController   cache.Controller
 
-	svcLister cache.Indexer
-	podLister cache.Indexer
-	epLister  cache.Indexer
-	nsLister  cache.Store
+	svcLister   cache.Indexer
+	podLister   cache.Indexer
+	epLister    cache.Indexer
+	sliceLister cache.Indexer // EndpointSlices, only when they are used instead of Endpoints
+	nsLister    cache.Store
+	nodeLister  cache.Indexer
+
+	svcImportLister cache.Indexer
+	mcEpLister      cache.Indexer
+
+	ingressLister cache.Indexer
+	gatewayLister cache.Indexer
+	routeLister   cache.Indexer
 
 	// stopLock is used to enforce only a single call to Stop is active.
 	// Needed because we allow stopping through an http endpoint and
@@ -81,7 +117,9 @@ type dnsControl struct {
 type dnsControlOpts struct {
 	initPodCache       bool
 	initEndpointsCache bool
+	initNodeCache      bool
 	ignoreEmptyService bool
+	useEndpointSlices  bool
 
 	// Label handling.
 	labelSelector          *meta.LabelSelector
@@ -90,14 +128,17 @@ type dnsControlOpts struct {
 	namespaceSelector      labels.Selector
 
 	zones                 []string
+	multiclusterZones     []string
 	endpointNameMode      bool
 	skipAPIObjectsCleanup bool
 }
 
-// newDNSController creates a controller for CoreDNS.
-func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts dnsControlOpts) *dnsControl {
+// newDNSController creates a controller for CoreDNS. The dynamicClient is used to watch the resources that are
+// not part of the core API: ServiceImports and the Gateway API, it may be nil if those are not watched.
+func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, opts dnsControlOpts) *dnsControl {
 	dns := dnsControl{
 		client:            kubeClient,
+		dynamicClient:     dynamicClient,
 		selector:          opts.selector,
 		namespaceSelector: opts.namespaceSelector,
 		stopCh:            make(chan struct{}),
@@ -129,7 +170,21 @@ func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts
 		)
 	}
 
-	if opts.initEndpointsCache {
+	if opts.initEndpointsCache && opts.useEndpointSlices {
+		// The slices of a service are merged in to a single Endpoints that is stored in epLister.
+		dns.epLister = cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc,
+			cache.Indexers{epNameNamespaceIndex: epNameNamespaceIndexFunc, epIPIndex: epIPIndexFunc})
+		dns.sliceLister, dns.epController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  endpointSliceListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+				WatchFunc: endpointSliceWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			},
+			&discovery.EndpointSlice{},
+			cache.ResourceEventHandlerFuncs{},
+			cache.Indexers{epNameNamespaceIndex: epNameNamespaceIndexFunc},
+			dns.endpointSliceProcessor(opts.skipAPIObjectsCleanup),
+		)
+	} else if opts.initEndpointsCache {
 		dns.epLister, dns.epController = object.NewIndexerInformer(
 			&cache.ListWatch{
 				ListFunc:  endpointsListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
@@ -205,6 +260,42 @@ func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts
 
 	}
 
+	if opts.initNodeCache {
+		dns.nodeLister, dns.nodeController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  nodeListFunc(ctx, dns.client),
+				WatchFunc: nodeWatchFunc(ctx, dns.client),
+			},
+			&api.Node{},
+			cache.ResourceEventHandlerFuncs{},
+			cache.Indexers{},
+			object.DefaultProcessor(object.ToNode(opts.skipAPIObjectsCleanup)),
+		)
+	}
+
+	if len(opts.multiclusterZones) > 0 {
+		dns.svcImportLister, dns.svcImportController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  unstructuredListFunc(ctx, dns.dynamicClient, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+				WatchFunc: unstructuredWatchFunc(ctx, dns.dynamicClient, object.ServiceImportResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{svcImportIndex: svcImportIndexFunc},
+			object.DefaultProcessor(object.ToServiceImport(opts.skipAPIObjectsCleanup)),
+		)
+		dns.mcEpLister, dns.mcEpController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  mcEndpointSliceListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+				WatchFunc: mcEndpointSliceWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			},
+			&discovery.EndpointSlice{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{mcEpIndex: mcEpIndexFunc},
+			object.DefaultProcessor(object.ToMultiClusterEndpoints(opts.skipAPIObjectsCleanup)),
+		)
+	}
+
 	dns.nsLister, dns.nsController = cache.NewInformer(
 		&cache.ListWatch{
 			ListFunc:  namespaceListFunc(ctx, dns.client, dns.namespaceSelector),
@@ -217,6 +308,45 @@ func newdnsController(ctx context.Context, kubeClient kubernetes.Interface, opts
 	return &dns
 }
 
+// watchExternalHosts creates the informers for Ingresses, and for Gateways and HTTPRoutes, whose hostnames are
+// served by the k8s_external plugin. It must be called before Run.
+func (dns *dnsControl) watchExternalHosts(ctx context.Context, ingress, gateway bool, skipAPIObjectsCleanup bool) {
+	if ingress && dns.ingressController == nil {
+		dns.ingressLister, dns.ingressController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  ingressListFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+				WatchFunc: ingressWatchFunc(ctx, dns.client, api.NamespaceAll, dns.selector),
+			},
+			&networking.Ingress{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.ToIngress(skipAPIObjectsCleanup)),
+		)
+	}
+	if gateway && dns.gatewayController == nil {
+		dns.gatewayLister, dns.gatewayController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  unstructuredListFunc(ctx, dns.dynamicClient, object.GatewayResource, api.NamespaceAll, dns.selector),
+				WatchFunc: unstructuredWatchFunc(ctx, dns.dynamicClient, object.GatewayResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.ToGateway(skipAPIObjectsCleanup)),
+		)
+		dns.routeLister, dns.routeController = object.NewIndexerInformer(
+			&cache.ListWatch{
+				ListFunc:  unstructuredListFunc(ctx, dns.dynamicClient, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+				WatchFunc: unstructuredWatchFunc(ctx, dns.dynamicClient, object.HTTPRouteResource, api.NamespaceAll, dns.selector),
+			},
+			&unstructured.Unstructured{},
+			cache.ResourceEventHandlerFuncs{AddFunc: dns.Add, UpdateFunc: dns.Update, DeleteFunc: dns.Delete},
+			cache.Indexers{hostnameIndex: hostnameIndexFunc},
+			object.DefaultProcessor(object.ToHTTPRoute(skipAPIObjectsCleanup)),
+		)
+	}
+}
+
 func podIPIndexFunc(obj interface{}) ([]string, error) {
 	p, ok := obj.(*object.Pod)
 	if !ok {
@@ -261,6 +391,34 @@ func epIPIndexFunc(obj interface{}) ([]string, error) {
 	return ep.IndexIP, nil
 }
 
+func svcImportIndexFunc(obj interface{}) ([]string, error) {
+	s, ok := obj.(*object.ServiceImport)
+	if !ok {
+		return nil, errObj
+	}
+	return []string{s.Index}, nil
+}
+
+func mcEpIndexFunc(obj interface{}) ([]string, error) {
+	m, ok := obj.(*object.MultiClusterEndpoints)
+	if !ok {
+		return nil, errObj
+	}
+	return []string{m.Index}, nil
+}
+
+func hostnameIndexFunc(obj interface{}) ([]string, error) {
+	switch o := obj.(type) {
+	case *object.Ingress:
+		return o.Hostnames, nil
+	case *object.Gateway:
+		return o.Hostnames, nil
+	case *object.HTTPRoute:
+		return o.Hostnames, nil
+	}
+	return nil, errObj
+}
+
 func serviceListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
 	return func(opts meta.ListOptions) (runtime.Object, error) {
 		if s != nil {
@@ -295,6 +453,54 @@ func endpointsListFunc(ctx context.Context, c kubernetes.Interface, ns string, s
 	}
 }
 
+func endpointSliceListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		listV1, err := c.DiscoveryV1beta1().EndpointSlices(ns).List(ctx, opts)
+		return listV1, err
+	}
+}
+
+// mcEndpointSliceListFunc lists the EndpointSlices of imported services, these are labeled with the name of
+// the ServiceImport.
+func mcEndpointSliceListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		opts.LabelSelector = mcEndpointSliceSelector(s)
+		listV1, err := c.DiscoveryV1beta1().EndpointSlices(ns).List(ctx, opts)
+		return listV1, err
+	}
+}
+
+func ingressListFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		listV1, err := c.NetworkingV1beta1().Ingresses(ns).List(ctx, opts)
+		return listV1, err
+	}
+}
+
+// unstructuredListFunc lists the resource res, that is not part of the core API, with the dynamic client.
+func unstructuredListFunc(ctx context.Context, c dynamic.Interface, res schema.GroupVersionResource, ns string, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = s.String()
+		}
+		list, err := c.Resource(res).Namespace(ns).List(ctx, opts)
+		return list, err
+	}
+}
+
+func nodeListFunc(ctx context.Context, c kubernetes.Interface) func(meta.ListOptions) (runtime.Object, error) {
+	return func(opts meta.ListOptions) (runtime.Object, error) {
+		listV1, err := c.CoreV1().Nodes().List(ctx, opts)
+		return listV1, err
+	}
+}
+
 func namespaceListFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(meta.ListOptions) (runtime.Object, error) {
 	return func(opts meta.ListOptions) (runtime.Object, error) {
 		if s != nil {
@@ -305,6 +511,114 @@ func namespaceListFunc(ctx context.Context, c kubernetes.Interface, s labels.Sel
 	}
 }
 
+// endpointSliceProcessor returns the process function for the EndpointSlice informer. Each slice is stored
+// in the slice lister, after which all slices of its service are merged in to the endpoints lister.
+func (dns *dnsControl) endpointSliceProcessor(skipAPIObjectsCleanup bool) object.ProcessorBuilder {
+	return func(clientState cache.Indexer, h cache.ResourceEventHandler) cache.ProcessFunc {
+		return func(obj interface{}) error {
+			for _, d := range obj.(cache.Deltas) {
+				apiSlice, ok := d.Object.(*discovery.EndpointSlice)
+				if !ok {
+					if d.Type != cache.Deleted {
+						return errors.New("got non-endpointslice add/update")
+					}
+					// See the comment on tombstones in the Endpoints processor.
+					tombstone, ok := d.Object.(cache.DeletedFinalStateUnknown)
+					if !ok {
+						return errors.New("expected tombstone")
+					}
+					apiSlice, ok = tombstone.Obj.(*discovery.EndpointSlice)
+					if !ok {
+						return errors.New("got non-endpointslice tombstone")
+					}
+				}
+				obj := object.EndpointSliceToEndpoints(apiSlice)
+
+				switch d.Type {
+				case cache.Sync, cache.Added, cache.Updated:
+					if old, exists, err := clientState.Get(obj); err == nil && exists {
+						if err := clientState.Update(obj); err != nil {
+							return err
+						}
+						h.OnUpdate(old, obj)
+					} else {
+						if err := clientState.Add(obj); err != nil {
+							return err
+						}
+						h.OnAdd(obj)
+					}
+				case cache.Deleted:
+					if err := clientState.Delete(obj); err != nil {
+						return err
+					}
+					h.OnDelete(obj)
+				}
+
+				if err := dns.mergeEndpointSlices(clientState, apiSlice); err != nil {
+					return err
+				}
+				if !skipAPIObjectsCleanup {
+					*apiSlice = discovery.EndpointSlice{}
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// mergeEndpointSlices merges all slices of the service apiSlice belongs to, and stores the result in the
+// endpoints lister.
+func (dns *dnsControl) mergeEndpointSlices(slices cache.Indexer, apiSlice *discovery.EndpointSlice) error {
+	name, namespace := apiSlice.GetLabels()[discovery.LabelServiceName], apiSlice.GetNamespace()
+	if name == "" {
+		// Not managed for a service.
+		return nil
+	}
+	idx := object.EndpointsKey(name, namespace)
+	os, err := slices.ByIndex(epNameNamespaceIndex, idx)
+	if err != nil {
+		return err
+	}
+	eps := make([]*object.Endpoints, 0, len(os))
+	for _, o := range os {
+		if e, ok := o.(*object.Endpoints); ok {
+			eps = append(eps, e)
+		}
+	}
+
+	old, exists, err := dns.epLister.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return err
+	}
+	if len(eps) == 0 {
+		if !exists {
+			return nil
+		}
+		if err := dns.epLister.Delete(old); err != nil {
+			return err
+		}
+		dns.updateModifed()
+		recordDNSProgrammingLatency(dns.SvcIndex(idx), apiSlice)
+		return nil
+	}
+
+	ep := object.MergeEndpoints(name, namespace, eps)
+	if exists {
+		if err := dns.epLister.Update(ep); err != nil {
+			return err
+		}
+		// endpoint updates can come frequently, make sure it's a change we care about
+		if endpointsEquivalent(old.(*object.Endpoints), ep) {
+			return nil
+		}
+	} else if err := dns.epLister.Add(ep); err != nil {
+		return err
+	}
+	dns.updateModifed()
+	recordDNSProgrammingLatency(dns.SvcIndex(idx), apiSlice)
+	return nil
+}
+
 // Stop stops the  controller.
 func (dns *dnsControl) Stop() error {
 	dns.stopLock.Lock()
@@ -330,6 +644,18 @@ func (dns *dnsControl) Run() {
 	if dns.podController != nil {
 		go dns.podController.Run(dns.stopCh)
 	}
+	if dns.nodeController != nil {
+		go dns.nodeController.Run(dns.stopCh)
+	}
+	if dns.svcImportController != nil {
+		go dns.svcImportController.Run(dns.stopCh)
+		go dns.mcEpController.Run(dns.stopCh)
+	}
+	for _, c := range []cache.Controller{dns.ingressController, dns.gatewayController, dns.routeController} {
+		if c != nil {
+			go c.Run(dns.stopCh)
+		}
+	}
 	go dns.nsController.Run(dns.stopCh)
 	<-dns.stopCh
 }
@@ -346,7 +672,21 @@ func (dns *dnsControl) HasSynced() bool {
 		c = dns.podController.HasSynced()
 	}
 	d := dns.nsController.HasSynced()
-	return a && b && c && d
+	e := true
+	if dns.svcImportController != nil {
+		e = dns.svcImportController.HasSynced() && dns.mcEpController.HasSynced()
+	}
+	f := true
+	if dns.nodeController != nil {
+		f = dns.nodeController.HasSynced()
+	}
+	g := true
+	for _, c := range []cache.Controller{dns.ingressController, dns.gatewayController, dns.routeController} {
+		if c != nil {
+			g = g && c.HasSynced()
+		}
+	}
+	return a && b && c && d && e && f && g
 }
 
 func (dns *dnsControl) ServiceList() (svcs []*object.Service) {
@@ -449,6 +789,72 @@ func (dns *dnsControl) EpIndexReverse(ip string) (ep []*object.Endpoints) {
 	return ep
 }
 
+func (dns *dnsControl) ServiceImportList() (svcs []*object.ServiceImport) {
+	if dns.svcImportLister == nil {
+		return nil
+	}
+	os := dns.svcImportLister.List()
+	for _, o := range os {
+		s, ok := o.(*object.ServiceImport)
+		if !ok {
+			continue
+		}
+		svcs = append(svcs, s)
+	}
+	return svcs
+}
+
+func (dns *dnsControl) MultiClusterEndpointsList() (eps []*object.MultiClusterEndpoints) {
+	if dns.mcEpLister == nil {
+		return nil
+	}
+	os := dns.mcEpLister.List()
+	for _, o := range os {
+		ep, ok := o.(*object.MultiClusterEndpoints)
+		if !ok {
+			continue
+		}
+		eps = append(eps, ep)
+	}
+	return eps
+}
+
+func (dns *dnsControl) SvcImportIndex(idx string) (svcs []*object.ServiceImport) {
+	if dns.svcImportLister == nil {
+		return nil
+	}
+	os, err := dns.svcImportLister.ByIndex(svcImportIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		s, ok := o.(*object.ServiceImport)
+		if !ok {
+			continue
+		}
+		svcs = append(svcs, s)
+	}
+	return svcs
+}
+
+func (dns *dnsControl) McEpIndex(idx string) (ep []*object.MultiClusterEndpoints) {
+	if dns.mcEpLister == nil {
+		return nil
+	}
+	os, err := dns.mcEpLister.ByIndex(mcEpIndex, idx)
+	if err != nil {
+		return nil
+	}
+	for _, o := range os {
+		e, ok := o.(*object.MultiClusterEndpoints)
+		if !ok {
+			continue
+		}
+		ep = append(ep, e)
+	}
+	return ep
+}
+
 // GetNodeByName return the node by name. If nothing is found an error is
 // returned. This query causes a roundtrip to the k8s API server, so use
 // sparingly. Currently this is only used for Federation.
@@ -457,6 +863,71 @@ func (dns *dnsControl) GetNodeByName(ctx context.Context, name string) (*api.Nod
 	return v1node, err
 }
 
+// ExternalHostIndex returns the addresses of the Ingresses, Gateways and HTTPRoutes with hostname host. An HTTPRoute
+// has the addresses of its parent Gateways. If there are none for host, the addresses for the wildcard name that
+// matches host are returned.
+func (dns *dnsControl) ExternalHostIndex(host string) (addrs []string) {
+	host = object.Hostname(host)
+	keys := []string{host}
+	if i := strings.IndexByte(host, '.'); i > 0 && i < len(host)-1 {
+		keys = append(keys, "*."+host[i+1:])
+	}
+	for _, key := range keys {
+		if dns.ingressLister != nil {
+			os, _ := dns.ingressLister.ByIndex(hostnameIndex, key)
+			for _, o := range os {
+				if i, ok := o.(*object.Ingress); ok {
+					addrs = append(addrs, i.Addresses...)
+				}
+			}
+		}
+		if dns.gatewayLister != nil {
+			os, _ := dns.gatewayLister.ByIndex(hostnameIndex, key)
+			for _, o := range os {
+				if g, ok := o.(*object.Gateway); ok {
+					addrs = append(addrs, g.Addresses...)
+				}
+			}
+			os, _ = dns.routeLister.ByIndex(hostnameIndex, key)
+			for _, o := range os {
+				r, ok := o.(*object.HTTPRoute)
+				if !ok {
+					continue
+				}
+				for _, k := range r.Gateways {
+					o, exists, err := dns.gatewayLister.GetByKey(k)
+					if err != nil || !exists {
+						continue
+					}
+					if g, ok := o.(*object.Gateway); ok {
+						addrs = append(addrs, g.Addresses...)
+					}
+				}
+			}
+		}
+		if len(addrs) > 0 {
+			return addrs
+		}
+	}
+	return nil
+}
+
+// GetNode returns the node with name from the cache, or nil if it can't be found or nodes are not watched.
+func (dns *dnsControl) GetNode(name string) *object.Node {
+	if dns.nodeLister == nil {
+		return nil
+	}
+	o, exists, err := dns.nodeLister.GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+	n, ok := o.(*object.Node)
+	if !ok {
+		return nil
+	}
+	return n
+}
+
 // GetNamespaceByName returns the namespace by name. If nothing is found an error is returned.
 func (dns *dnsControl) GetNamespaceByName(name string) (*api.Namespace, error) {
 	os := dns.nsLister.List()
@@ -491,6 +962,16 @@ func (dns *dnsControl) detectChanges(oldObj, newObj interface{}) {
 		dns.updateModifed()
 	case *object.Pod:
 		dns.updateModifed()
+	case *object.ServiceImport:
+		dns.updateModifed()
+	case *object.Ingress, *object.Gateway, *object.HTTPRoute:
+		dns.updateModifed()
+	case *object.MultiClusterEndpoints:
+		// endpoint updates can come frequently, make sure it's a change we care about
+		if o, ok := oldObj.(*object.MultiClusterEndpoints); ok && endpointsEquivalent(&o.Endpoints, &ob.Endpoints) {
+			return
+		}
+		dns.updateModifed()
 	default:
 		log.Warningf("Updates for %T not supported.", ob)
 	}
@@ -5,12 +5,20 @@ import (
 	"net"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/coredns/coredns/plugin/kubernetes/object"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
 	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
+	networking "k8s.io/api/networking/v1beta1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -30,7 +38,7 @@ func BenchmarkController(b *testing.B) {
 		zones: []string{"cluster.local."},
 	}
 	ctx := context.Background()
-	controller := newdnsController(ctx, client, dco)
+	controller := newdnsController(ctx, client, nil, dco)
 	cidr := "10.0.0.0/19"
 
 	// Add resources
@@ -170,3 +178,255 @@ func createExternalSvc(suffix int, client kubernetes.Interface, ip net.IP) {
 		},
 	}, meta.CreateOptions{})
 }
+
+func TestEndpointSlices(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+	controller := newdnsController(ctx, client, nil, dnsControlOpts{
+		initEndpointsCache: true,
+		useEndpointSlices:  true,
+		// This is needed as otherwise the fake k8s client doesn't work properly.
+		skipAPIObjectsCleanup: true,
+	})
+	go controller.Run()
+	defer controller.Stop()
+
+	ready, notReady := true, false
+	port, portName := int32(80), "http"
+	slice := func(name string, ips ...string) *discovery.EndpointSlice {
+		s := &discovery.EndpointSlice{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace: "testns",
+				Name:      name,
+				Labels:    map[string]string{discovery.LabelServiceName: "svc1"},
+			},
+			AddressType: discovery.AddressTypeIPv4,
+			Ports:       []discovery.EndpointPort{{Port: &port, Name: &portName}},
+		}
+		for _, ip := range ips {
+			s.Endpoints = append(s.Endpoints, discovery.Endpoint{Addresses: []string{ip}, Conditions: discovery.EndpointConditions{Ready: &ready}})
+		}
+		return s
+	}
+
+	s1 := slice("svc1-abc", "10.0.0.1", "10.0.0.2")
+	s1.Endpoints = append(s1.Endpoints, discovery.Endpoint{Addresses: []string{"10.0.0.3"}, Conditions: discovery.EndpointConditions{Ready: &notReady}})
+	if _, err := client.DiscoveryV1beta1().EndpointSlices("testns").Create(ctx, s1, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DiscoveryV1beta1().EndpointSlices("testns").Create(ctx, slice("svc1-def", "10.0.0.4"), meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ips := func() []string {
+		ips := []string{}
+		for _, ep := range controller.EpIndex(object.EndpointsKey("svc1", "testns")) {
+			if ep.Name != "svc1" {
+				t.Errorf("Expected merged endpoints to be named %q, got %q", "svc1", ep.Name)
+			}
+			for _, s := range ep.Subsets {
+				for _, a := range s.Addresses {
+					ips = append(ips, a.IP)
+				}
+			}
+		}
+		return ips
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(ips()) == 3, nil
+	}); err != nil {
+		t.Fatalf("Expected 3 ready addresses, got %v", ips())
+	}
+	if x := controller.EpIndexReverse("10.0.0.4"); len(x) != 1 || x[0].Name != "svc1" {
+		t.Errorf("Expected reverse lookup to return the merged endpoints, got %v", x)
+	}
+
+	if err := client.DiscoveryV1beta1().EndpointSlices("testns").Delete(ctx, "svc1-abc", meta.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(ips()) == 1, nil
+	}); err != nil {
+		t.Fatalf("Expected 1 address after deleting a slice, got %v", ips())
+	}
+
+	if err := client.DiscoveryV1beta1().EndpointSlices("testns").Delete(ctx, "svc1-def", meta.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(controller.EndpointsList()) == 0, nil
+	}); err != nil {
+		t.Fatalf("Expected no endpoints after deleting all slices")
+	}
+}
+
+func TestEndpointSliceSupported(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	if endpointSliceSupported(client) {
+		t.Errorf("Expected EndpointSlices not to be supported")
+	}
+	client.Fake.Resources = []*meta.APIResourceList{{
+		GroupVersion: discovery.SchemeGroupVersion.String(),
+		APIResources: []meta.APIResource{{Name: "endpointslices", Kind: "EndpointSlice"}},
+	}}
+	if !endpointSliceSupported(client) {
+		t.Errorf("Expected EndpointSlices to be supported")
+	}
+}
+
+func TestMultiCluster(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	mcsClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	ctx := context.TODO()
+	controller := newdnsController(ctx, client, mcsClient, dnsControlOpts{
+		initEndpointsCache: true,
+		multiclusterZones:  []string{"clusterset.local."},
+		// This is needed as otherwise the fake k8s client doesn't work properly.
+		skipAPIObjectsCleanup: true,
+	})
+	go controller.Run()
+	defer controller.Stop()
+
+	si := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "multicluster.x-k8s.io/v1alpha1",
+		"kind":       "ServiceImport",
+		"metadata":   map[string]interface{}{"name": "svc1", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"type":  object.ServiceImportClusterSetIP,
+			"ips":   []interface{}{"10.1.0.1"},
+			"ports": []interface{}{map[string]interface{}{"name": "http", "protocol": "TCP", "port": int64(80)}},
+		},
+	}}
+	if _, err := mcsClient.Resource(object.ServiceImportResource).Namespace("testns").Create(ctx, si, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ready := true
+	slice := &discovery.EndpointSlice{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: "testns",
+			Name:      "svc1-cluster1",
+			Labels: map[string]string{
+				object.LabelMultiClusterServiceName: "svc1",
+				object.LabelSourceCluster:           "cluster1",
+			},
+		},
+		AddressType: discovery.AddressTypeIPv4,
+		Endpoints:   []discovery.Endpoint{{Addresses: []string{"10.2.0.1"}, Conditions: discovery.EndpointConditions{Ready: &ready}}},
+	}
+	if _, err := client.DiscoveryV1beta1().EndpointSlices("testns").Create(ctx, slice, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(controller.SvcImportIndex("svc1.testns")) == 1 && len(controller.McEpIndex("svc1.testns")) == 1, nil
+	}); err != nil {
+		t.Fatalf("Expected the service import and its endpoints to be indexed")
+	}
+
+	svc := controller.SvcImportIndex("svc1.testns")[0]
+	if svc.Type != object.ServiceImportClusterSetIP || len(svc.ClusterIPs) != 1 || svc.ClusterIPs[0] != "10.1.0.1" {
+		t.Errorf("Unexpected service import: %+v", svc)
+	}
+	if len(svc.Ports) != 1 || svc.Ports[0].Port != 80 || svc.Ports[0].Name != "http" {
+		t.Errorf("Unexpected service import ports: %+v", svc.Ports)
+	}
+	ep := controller.McEpIndex("svc1.testns")[0]
+	if ep.ClusterID != "cluster1" || len(ep.Subsets) != 1 || len(ep.Subsets[0].Addresses) != 1 || ep.Subsets[0].Addresses[0].IP != "10.2.0.1" {
+		t.Errorf("Unexpected multicluster endpoints: %+v", ep)
+	}
+	if !controller.HasSynced() {
+		t.Errorf("Expected controller to have synced")
+	}
+}
+
+func TestExternalHosts(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	ctx := context.TODO()
+	controller := newdnsController(ctx, client, dynamicClient, dnsControlOpts{
+		// This is needed as otherwise the fake k8s client doesn't work properly.
+		skipAPIObjectsCleanup: true,
+	})
+	controller.watchExternalHosts(ctx, true, true, true)
+	go controller.Run()
+	defer controller.Stop()
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta.ObjectMeta{Namespace: "testns", Name: "ing1"},
+		Spec: networking.IngressSpec{Rules: []networking.IngressRule{
+			{Host: "app.example.com"},
+			{Host: "*.apps.example.com"},
+		}},
+		Status: networking.IngressStatus{LoadBalancer: api.LoadBalancerStatus{
+			Ingress: []api.LoadBalancerIngress{{IP: "1.2.3.4"}},
+		}},
+	}
+	if _, err := client.NetworkingV1beta1().Ingresses("testns").Create(ctx, ing, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	gw := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": "gw1", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"listeners": []interface{}{map[string]interface{}{"name": "http", "hostname": "gw.example.com"}},
+		},
+		"status": map[string]interface{}{
+			"addresses": []interface{}{
+				map[string]interface{}{"type": "IPAddress", "value": "1.2.3.5"},
+				map[string]interface{}{"type": "Hostname", "value": "lb.example.net"},
+			},
+		},
+	}}
+	if _, err := dynamicClient.Resource(object.GatewayResource).Namespace("testns").Create(ctx, gw, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "route1", "namespace": "testns"},
+		"spec": map[string]interface{}{
+			"hostnames":  []interface{}{"route.example.com"},
+			"parentRefs": []interface{}{map[string]interface{}{"name": "gw1"}},
+		},
+	}}
+	if _, err := dynamicClient.Resource(object.HTTPRouteResource).Namespace("testns").Create(ctx, route, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		return len(controller.ExternalHostIndex("route.example.com.")) == 1 && len(controller.ExternalHostIndex("app.example.com.")) == 1, nil
+	}); err != nil {
+		t.Fatalf("Expected the ingress, gateway and route to be indexed")
+	}
+
+	tests := []struct {
+		host     string
+		expected []string
+	}{
+		{"app.example.com.", []string{"1.2.3.4"}},
+		{"APP.example.com.", []string{"1.2.3.4"}},
+		{"web.apps.example.com.", []string{"1.2.3.4"}},
+		{"apps.example.com.", nil},
+		{"gw.example.com.", []string{"1.2.3.5"}},
+		{"route.example.com.", []string{"1.2.3.5"}},
+		{"unknown.example.com.", nil},
+	}
+	for i, tc := range tests {
+		addrs := controller.ExternalHostIndex(tc.host)
+		if len(addrs) != len(tc.expected) {
+			t.Errorf("Test %d, expected %v for %s, got %v", i, tc.expected, tc.host, addrs)
+			continue
+		}
+		for j := range addrs {
+			if addrs[j] != tc.expected[j] {
+				t.Errorf("Test %d, expected %v for %s, got %v", i, tc.expected, tc.host, addrs)
+			}
+		}
+	}
+	if !controller.HasSynced() {
+		t.Errorf("Expected controller to have synced")
+	}
+}
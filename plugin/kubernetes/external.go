@@ -1,6 +1,8 @@
 package kubernetes
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/coredns/coredns/plugin/etcd/msg"
@@ -12,8 +14,18 @@ import (
 )
 
 // External implements the ExternalFunc call from the external plugin.
-// It returns any services matching in the services' ExternalIPs.
+// It returns the addresses of the Ingresses, Gateways and HTTPRoutes with the queried hostname, if
+// those are watched, or any services matching in the services' ExternalIPs.
 func (k *Kubernetes) External(state request.Request) ([]msg.Service, int) {
+	if addrs := k.APIConn.ExternalHostIndex(state.Name()); len(addrs) > 0 {
+		services := make([]msg.Service, len(addrs))
+		for i, a := range addrs {
+			// Port -1 as these have no ports, which leaves them out of SRV answers.
+			services[i] = msg.Service{Host: a, Port: -1, TTL: k.ttl, Key: msg.Path(state.Name(), coredns)}
+		}
+		return services, dns.RcodeSuccess
+	}
+
 	base, _ := dnsutil.TrimZone(state.Name(), state.Zone)
 
 	segs := dns.SplitDomainName(base)
@@ -83,6 +95,18 @@ func (k *Kubernetes) External(state request.Request) ([]msg.Service, int) {
 	return services, rcode
 }
 
+// WatchExternalHosts implements the HostWatcher interface of the external plugin. It makes the plugin watch
+// Ingresses, and Gateways and HTTPRoutes from the Gateway API, so their hostnames can be returned by External.
+// It must be called before the plugin is started.
+func (k *Kubernetes) WatchExternalHosts(ingress, gateway bool) error {
+	dns, ok := k.APIConn.(*dnsControl)
+	if !ok {
+		return fmt.Errorf("can not watch hosts with %T", k.APIConn)
+	}
+	dns.watchExternalHosts(context.Background(), ingress, gateway, k.opts.skipAPIObjectsCleanup)
+	return nil
+}
+
 // ExternalAddress returns the external service address(es) for the CoreDNS service.
 func (k *Kubernetes) ExternalAddress(state request.Request) []dns.RR {
 	// If CoreDNS is running inside the Kubernetes cluster: k.nsAddrs() will return the external IPs of the services
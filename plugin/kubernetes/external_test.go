@@ -134,3 +134,11 @@ func testRequest(name string) request.Request {
 	m := new(dns.Msg).SetQuestion(name, dns.TypeA)
 	return request.Request{W: &test.ResponseWriter{}, Req: m, Zone: "example.org."}
 }
+
+func (external) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (external) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (external) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (external) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (external) GetNode(string) *object.Node       { return nil }
+func (external) ExternalHostIndex(string) []string { return nil }
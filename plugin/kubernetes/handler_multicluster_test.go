@@ -0,0 +1,234 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+)
+
+var dnsMultiClusterTestCases = []test.Case{
+	// A ClusterSetIP service
+	{
+		Qname: "svc1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.clusterset.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+	// SRV of a ClusterSetIP service
+	{
+		Qname: "_http._tcp.svc1.testns.svc.clusterset.local.", Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.svc1.testns.svc.clusterset.local.	5	IN	SRV	0 100 80 svc1.testns.svc.clusterset.local."),
+		},
+		Extra: []dns.RR{
+			test.A("svc1.testns.svc.clusterset.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+	// A headless service, with endpoints in two clusters
+	{
+		Qname: "hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.2"),
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.3"),
+			test.A("hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.2"),
+		},
+	},
+	// SRV of a headless service, the targets are qualified by cluster
+	{
+		Qname: "_http._tcp.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeSRV,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 33 80 172-0-0-2.cluster1.hdls1.testns.svc.clusterset.local."),
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 33 80 dup-name.cluster1.hdls1.testns.svc.clusterset.local."),
+			test.SRV("_http._tcp.hdls1.testns.svc.clusterset.local.	5	IN	SRV	0 33 80 dup-name.cluster2.hdls1.testns.svc.clusterset.local."),
+		},
+		Extra: []dns.RR{
+			test.A("172-0-0-2.cluster1.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.2"),
+			test.A("dup-name.cluster1.hdls1.testns.svc.clusterset.local.	5	IN	A	172.0.0.3"),
+			test.A("dup-name.cluster2.hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.2"),
+		},
+	},
+	// An endpoint with the same hostname in two clusters
+	{
+		Qname: "dup-name.cluster2.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("dup-name.cluster2.hdls1.testns.svc.clusterset.local.	5	IN	A	172.1.0.2"),
+		},
+	},
+	// An endpoint in an unknown cluster
+	{
+		Qname: "dup-name.cluster3.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// An endpoint without a cluster
+	{
+		Qname: "dup-name.hdls1.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// A service that is not imported
+	{
+		Qname: "svc2.testns.svc.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// Pods are not served in the cluster set zone
+	{
+		Qname: "10-240-0-1.podns.pod.clusterset.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("clusterset.local.	5	IN	SOA	ns.dns.clusterset.local. hostmaster.clusterset.local. 1499347823 7200 1800 86400 5"),
+		},
+	},
+	// The cluster's own services are still served in the cluster zone
+	{
+		Qname: "svc1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.cluster.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+}
+
+func TestServeDNSMultiCluster(t *testing.T) {
+	k := New([]string{"cluster.local.", "clusterset.local."})
+	k.opts.multiclusterZones = []string{"clusterset.local."}
+	k.APIConn = &APIConnMultiClusterTest{}
+	k.Next = test.NextHandler(dns.RcodeSuccess, nil)
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	ctx := context.TODO()
+
+	for i, tc := range dnsMultiClusterTestCases {
+		r := tc.Msg()
+
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+
+		_, err := k.ServeDNS(ctx, w, r)
+		if err != tc.Error {
+			t.Errorf("Test %d expected no error, got %v", i, err)
+			return
+		}
+		if tc.Error != nil {
+			continue
+		}
+
+		resp := w.Msg
+		if resp == nil {
+			t.Fatalf("Test %d, got nil message and no error for %q", i, r.Question[0].Name)
+		}
+
+		if err := test.SortAndCheck(resp, tc); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+type APIConnMultiClusterTest struct {
+	APIConnServeTest
+}
+
+var svcImportsIndex = map[string][]*object.ServiceImport{
+	"svc1.testns": {
+		{
+			Name:       "svc1",
+			Namespace:  "testns",
+			Index:      "svc1.testns",
+			Type:       object.ServiceImportClusterSetIP,
+			ClusterIPs: []string{"10.0.0.1"},
+			Ports: []api.ServicePort{
+				{Name: "http", Protocol: "tcp", Port: 80},
+			},
+		},
+	},
+	"hdls1.testns": {
+		{
+			Name:      "hdls1",
+			Namespace: "testns",
+			Index:     "hdls1.testns",
+			Type:      object.ServiceImportHeadless,
+		},
+	},
+}
+
+var mcEpsIndex = map[string][]*object.MultiClusterEndpoints{
+	"hdls1.testns": {
+		{
+			Endpoints: object.Endpoints{
+				Subsets: []object.EndpointSubset{
+					{
+						Addresses: []object.EndpointAddress{
+							{IP: "172.0.0.2"},
+							{IP: "172.0.0.3", Hostname: "dup-name"},
+						},
+						Ports: []object.EndpointPort{
+							{Port: 80, Protocol: "tcp", Name: "http"},
+						},
+					},
+				},
+				Name:      "hdls1-cluster1",
+				Namespace: "testns",
+				Index:     "hdls1.testns",
+			},
+			ClusterID: "cluster1",
+		},
+		{
+			Endpoints: object.Endpoints{
+				Subsets: []object.EndpointSubset{
+					{
+						Addresses: []object.EndpointAddress{
+							{IP: "172.1.0.2", Hostname: "dup-name"},
+						},
+						Ports: []object.EndpointPort{
+							{Port: 80, Protocol: "tcp", Name: "http"},
+						},
+					},
+				},
+				Name:      "hdls1-cluster2",
+				Namespace: "testns",
+				Index:     "hdls1.testns",
+			},
+			ClusterID: "cluster2",
+		},
+	},
+}
+
+func (APIConnMultiClusterTest) SvcImportIndex(s string) []*object.ServiceImport {
+	return svcImportsIndex[s]
+}
+
+func (APIConnMultiClusterTest) McEpIndex(s string) []*object.MultiClusterEndpoints {
+	return mcEpsIndex[s]
+}
+
+func (APIConnMultiClusterTest) ServiceImportList() []*object.ServiceImport {
+	var svcs []*object.ServiceImport
+	for _, svc := range svcImportsIndex {
+		svcs = append(svcs, svc...)
+	}
+	return svcs
+}
+
+func (APIConnMultiClusterTest) MultiClusterEndpointsList() []*object.MultiClusterEndpoints {
+	var eps []*object.MultiClusterEndpoints
+	for _, ep := range mcEpsIndex {
+		eps = append(eps, ep...)
+	}
+	return eps
+}
@@ -725,3 +725,11 @@ func (APIConnServeTest) GetNamespaceByName(name string) (*api.Namespace, error)
 		},
 	}, nil
 }
+
+func (APIConnServeTest) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (APIConnServeTest) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (APIConnServeTest) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (APIConnServeTest) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (APIConnServeTest) GetNode(string) *object.Node       { return nil }
+func (APIConnServeTest) ExternalHostIndex(string) []string { return nil }
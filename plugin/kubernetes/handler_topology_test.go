@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+)
+
+func TestServeDNSTopology(t *testing.T) {
+	tests := []struct {
+		topology []string
+		remote   string // client IP
+		answer   []dns.RR
+	}{
+		// Endpoint on the same node as the client.
+		{
+			[]string{topologyNode, topologyZone}, "10.240.0.1",
+			[]dns.RR{test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.1")},
+		},
+		// Endpoints in the same zone as the client.
+		{
+			[]string{topologyZone}, "10.240.0.1",
+			[]dns.RR{
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.1"),
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.2"),
+			},
+		},
+		// No endpoint on the node of the client, fall back to the zone.
+		{
+			[]string{topologyNode, topologyZone}, "10.240.0.4",
+			[]dns.RR{test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.3")},
+		},
+		// No endpoint on the node of the client, fall back to all endpoints.
+		{
+			[]string{topologyNode}, "10.240.0.4",
+			[]dns.RR{
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.1"),
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.2"),
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.3"),
+			},
+		},
+		// Client is not a known pod.
+		{
+			[]string{topologyNode, topologyZone}, "10.240.0.9",
+			[]dns.RR{
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.1"),
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.2"),
+				test.A("hdls.testns.svc.cluster.local.	0	IN	A	172.0.0.3"),
+			},
+		},
+	}
+
+	ctx := context.TODO()
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTopologyTest{}
+		k.Next = test.NextHandler(dns.RcodeSuccess, nil)
+		k.Namespaces = map[string]struct{}{"testns": {}}
+		k.podMode = podModeVerified
+		k.topology = tc.topology
+
+		m := new(dns.Msg)
+		m.SetQuestion("hdls.testns.svc.cluster.local.", dns.TypeA)
+		w := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: tc.remote})
+		if _, err := k.ServeDNS(ctx, w, m); err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if err := test.SortAndCheck(w.Msg, test.Case{Qname: "hdls.testns.svc.cluster.local.", Qtype: dns.TypeA, Answer: tc.answer}); err != nil {
+			t.Errorf("Test %d: %v", i, err)
+		}
+	}
+}
+
+type APIConnTopologyTest struct {
+	APIConnServeTest
+}
+
+func (APIConnTopologyTest) PodIndex(ip string) []*object.Pod {
+	switch ip {
+	case "10.240.0.1":
+		return []*object.Pod{{Namespace: "podns", Name: "foo", PodIP: ip, NodeName: "node1"}}
+	case "10.240.0.4":
+		return []*object.Pod{{Namespace: "podns", Name: "bar", PodIP: ip, NodeName: "node4"}}
+	}
+	return nil
+}
+
+func (APIConnTopologyTest) GetNode(name string) *object.Node {
+	zones := map[string]string{"node1": "zone-a", "node2": "zone-a", "node3": "zone-b", "node4": "zone-b"}
+	if z, ok := zones[name]; ok {
+		return &object.Node{Name: name, Zone: z}
+	}
+	return nil
+}
+
+func (APIConnTopologyTest) SvcIndex(s string) []*object.Service {
+	if s != "hdls.testns" {
+		return nil
+	}
+	return []*object.Service{{Name: "hdls", Namespace: "testns", Type: api.ServiceTypeClusterIP, ClusterIP: api.ClusterIPNone}}
+}
+
+func (APIConnTopologyTest) EpIndex(s string) []*object.Endpoints {
+	if s != "hdls.testns" {
+		return nil
+	}
+	return []*object.Endpoints{{
+		Subsets: []object.EndpointSubset{
+			{
+				Addresses: []object.EndpointAddress{
+					{IP: "172.0.0.1", NodeName: "node1"},
+					{IP: "172.0.0.2", NodeName: "node2"},
+					{IP: "172.0.0.3", NodeName: "node3"},
+				},
+				Ports: []object.EndpointPort{
+					{Port: 80, Protocol: "tcp", Name: "http"},
+				},
+			},
+		},
+		Name:      "hdls",
+		Namespace: "testns",
+		Index:     "hdls.testns",
+	}}
+}
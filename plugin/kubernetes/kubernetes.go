@@ -18,8 +18,10 @@ import (
 
 	"github.com/miekg/dns"
 	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -47,6 +49,8 @@ type Kubernetes struct {
 	localIPs         []net.IP
 	autoPathSearch   []string // Local search path from /etc/resolv.conf. Needed for autopath.
 	TransferTo       []string
+	topology         []string                // Topology levels, in order of preference, for headless service answers.
+	history          map[string]*zoneHistory // Histories of the transferred zones, nil when transfers are disabled.
 }
 
 // New returns a initialized Kubernetes. It default interfaceAddrFunc to return 127.0.0.1. All other
@@ -76,6 +80,10 @@ const (
 	Pod = "pod"
 	// defaultTTL to apply to all answers.
 	defaultTTL = 5
+	// topologyNode prefers the endpoints on the node of the client.
+	topologyNode = "node"
+	// topologyZone prefers the endpoints in the zone of the client.
+	topologyZone = "zone"
 )
 
 var (
@@ -242,17 +250,47 @@ func (k *Kubernetes) InitKubeCache(ctx context.Context) (err error) {
 	}
 
 	k.opts.initPodCache = k.podMode == podModeVerified
+	for _, t := range k.topology {
+		if t == topologyZone {
+			k.opts.initNodeCache = true
+		}
+	}
 
 	k.opts.zones = k.Zones
 	k.opts.endpointNameMode = k.endpointNameMode
-	k.APIConn = newdnsController(ctx, kubeClient, k.opts)
+	if k.opts.initEndpointsCache {
+		k.opts.useEndpointSlices = endpointSliceSupported(kubeClient)
+		if !k.opts.useEndpointSlices {
+			log.Info("EndpointSlices are not available, watching Endpoints")
+		}
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes notification controller: %q", err)
+	}
+	k.APIConn = newdnsController(ctx, kubeClient, dynamicClient, k.opts)
 
 	return err
 }
 
+// endpointSliceSupported returns true if the API server serves EndpointSlices.
+func endpointSliceSupported(c kubernetes.Interface) bool {
+	res, err := c.Discovery().ServerResourcesForGroupVersion(discovery.SchemeGroupVersion.String())
+	if err != nil {
+		return false
+	}
+	for _, r := range res.APIResources {
+		if r.Kind == "EndpointSlice" {
+			return true
+		}
+	}
+	return false
+}
+
 // Records looks up services in kubernetes.
 func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact bool) ([]msg.Service, error) {
-	r, e := parseRequest(state.Name(), state.Zone)
+	multicluster := k.isMultiClusterZone(state.Zone)
+	r, e := parseRequest(state.Name(), state.Zone, multicluster)
 	if e != nil {
 		return nil, e
 	}
@@ -268,12 +306,24 @@ func (k *Kubernetes) Records(ctx context.Context, state request.Request, exact b
 		return nil, errNsNotExposed
 	}
 
+	if multicluster {
+		if r.podOrSvc == Pod {
+			return nil, errNoItems
+		}
+		services, err := k.findMultiClusterServices(r, state.Zone)
+		return services, err
+	}
+
 	if r.podOrSvc == Pod {
 		pods, err := k.findPods(r, state.Zone)
 		return pods, err
 	}
 
-	services, err := k.findServices(r, state.Zone)
+	var client *object.Pod
+	if len(k.topology) > 0 {
+		client = k.podWithIP(state.IP())
+	}
+	services, err := k.findServices(r, state.Zone, client)
 	return services, err
 }
 
@@ -362,8 +412,9 @@ func (k *Kubernetes) findPods(r recordRequest, zone string) (pods []msg.Service,
 	return pods, err
 }
 
-// findServices returns the services matching r from the cache.
-func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.Service, err error) {
+// findServices returns the services matching r from the cache. If topology is configured, the endpoints of
+// headless services are filtered by their topology relative to the client pod, which may be nil.
+func (k *Kubernetes) findServices(r recordRequest, zone string, client *object.Pod) (services []msg.Service, err error) {
 	if !wildcard(r.namespace) && !k.namespaceExposed(r.namespace) {
 		return nil, errNoItems
 	}
@@ -434,6 +485,8 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 			if endpointsList == nil {
 				endpointsList = endpointsListFunc()
 			}
+			first := len(services)
+			var nodes []string // node of each endpoint in services[first:]
 			for _, ep := range endpointsList {
 				if ep.Name != svc.Name || ep.Namespace != svc.Namespace {
 					continue
@@ -459,10 +512,18 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 							err = nil
 
 							services = append(services, s)
+							nodes = append(nodes, addr.NodeName)
 						}
 					}
 				}
 			}
+			if r.endpoint == "" && len(k.topology) > 0 {
+				services = append(services[:first], k.preferTopology(services[first:], nodes, client)...)
+				// The endpoints depend on the client, so the answer must not be cached.
+				for i := first; i < len(services); i++ {
+					services[i].TTL = 0
+				}
+			}
 			continue
 		}
 
@@ -495,6 +556,171 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 	return services, err
 }
 
+// preferTopology returns the services whose endpoints are closest to the client: for each of the configured
+// topology levels in turn, the services on the same node, or in the same zone, as the client. If none match,
+// or the client isn't known, all services are returned. nodes holds the node name of each service.
+func (k *Kubernetes) preferTopology(services []msg.Service, nodes []string, client *object.Pod) []msg.Service {
+	if client == nil || client.NodeName == "" {
+		return services
+	}
+	for _, t := range k.topology {
+		var preferred []msg.Service
+		switch t {
+		case topologyNode:
+			for i := range services {
+				if nodes[i] == client.NodeName {
+					preferred = append(preferred, services[i])
+				}
+			}
+		case topologyZone:
+			zone := k.nodeZone(client.NodeName)
+			if zone == "" {
+				continue
+			}
+			for i := range services {
+				if k.nodeZone(nodes[i]) == zone {
+					preferred = append(preferred, services[i])
+				}
+			}
+		}
+		if len(preferred) > 0 {
+			return preferred
+		}
+	}
+	return services
+}
+
+// nodeZone returns the zone of the node with name, or the empty string if it is not known.
+func (k *Kubernetes) nodeZone(name string) string {
+	if name == "" {
+		return ""
+	}
+	n := k.APIConn.GetNode(name)
+	if n == nil {
+		return ""
+	}
+	return n.Zone
+}
+
+// isMultiClusterZone returns true if zone is one of the multicluster zones.
+func (k *Kubernetes) isMultiClusterZone(zone string) bool {
+	for _, z := range k.opts.multiclusterZones {
+		if strings.EqualFold(z, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMultiClusterServices returns the imported services matching r from the cache. Headless services and
+// endpoint queries are answered with the endpoints from all clusters the service is exported from, every
+// endpoint name is qualified with the ID of its cluster.
+func (k *Kubernetes) findMultiClusterServices(r recordRequest, zone string) (services []msg.Service, err error) {
+	if !wildcard(r.namespace) && !k.namespaceExposed(r.namespace) {
+		return nil, errNoItems
+	}
+
+	// handle empty service name
+	if r.service == "" {
+		if k.namespaceExposed(r.namespace) || wildcard(r.namespace) {
+			// NODATA
+			return nil, nil
+		}
+		// NXDOMAIN
+		return nil, errNoItems
+	}
+
+	err = errNoItems
+	if wildcard(r.service) && !wildcard(r.namespace) {
+		// If namespace exists, err should be nil, so that we return NODATA instead of NXDOMAIN
+		if k.namespaceExposed(r.namespace) {
+			err = nil
+		}
+	}
+
+	var (
+		endpointsListFunc func() []*object.MultiClusterEndpoints
+		endpointsList     []*object.MultiClusterEndpoints
+		serviceList       []*object.ServiceImport
+	)
+
+	if wildcard(r.service) || wildcard(r.namespace) {
+		serviceList = k.APIConn.ServiceImportList()
+		endpointsListFunc = func() []*object.MultiClusterEndpoints { return k.APIConn.MultiClusterEndpointsList() }
+	} else {
+		idx := object.ServiceKey(r.service, r.namespace)
+		serviceList = k.APIConn.SvcImportIndex(idx)
+		endpointsListFunc = func() []*object.MultiClusterEndpoints { return k.APIConn.McEpIndex(idx) }
+	}
+
+	zonePath := msg.Path(zone, coredns)
+	for _, svc := range serviceList {
+		if !(match(r.namespace, svc.Namespace) && match(r.service, svc.Name)) {
+			continue
+		}
+
+		// If request namespace is a wildcard, filter results against Corefile namespace list.
+		if wildcard(r.namespace) && !k.namespaceExposed(svc.Namespace) {
+			continue
+		}
+
+		// Endpoint query or headless service
+		if svc.Type == object.ServiceImportHeadless || r.endpoint != "" {
+			if endpointsList == nil {
+				endpointsList = endpointsListFunc()
+			}
+			for _, ep := range endpointsList {
+				if ep.Index != svc.Index {
+					continue
+				}
+				if r.cluster != "" && !match(r.cluster, ep.ClusterID) {
+					continue
+				}
+
+				for _, eps := range ep.Subsets {
+					for _, addr := range eps.Addresses {
+						if r.endpoint != "" {
+							if !match(r.endpoint, endpointHostname(addr, k.endpointNameMode)) {
+								continue
+							}
+						}
+
+						for _, p := range eps.Ports {
+							if !(match(r.port, p.Name) && match(r.protocol, string(p.Protocol))) {
+								continue
+							}
+							s := msg.Service{Host: addr.IP, Port: int(p.Port), TTL: k.ttl}
+							s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name, ep.ClusterID, endpointHostname(addr, k.endpointNameMode)}, "/")
+
+							err = nil
+
+							services = append(services, s)
+						}
+					}
+				}
+			}
+			continue
+		}
+
+		// ClusterSetIP service
+		for _, ip := range svc.ClusterIPs {
+			for _, p := range svc.Ports {
+				if !(match(r.port, p.Name) && match(r.protocol, string(p.Protocol))) {
+					continue
+				}
+
+				err = nil
+
+				s := msg.Service{Host: ip, Port: int(p.Port), TTL: k.ttl}
+				s.Key = strings.Join([]string{zonePath, Svc, svc.Namespace, svc.Name}, "/")
+
+				services = append(services, s)
+			}
+		}
+	}
+	return services, err
+}
+
 // match checks if a and b are equal taking wildcards into account.
 func match(a, b string) bool {
 	if wildcard(a) {
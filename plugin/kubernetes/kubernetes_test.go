@@ -365,3 +365,11 @@ func TestServicesAuthority(t *testing.T) {
 		}
 	}
 }
+
+func (APIConnServiceTest) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (APIConnServiceTest) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (APIConnServiceTest) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (APIConnServiceTest) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (APIConnServiceTest) GetNode(string) *object.Node       { return nil }
+func (APIConnServiceTest) ExternalHostIndex(string) []string { return nil }
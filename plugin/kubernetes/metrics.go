@@ -7,6 +7,7 @@ import (
 	"github.com/coredns/coredns/plugin/kubernetes/object"
 	"github.com/prometheus/client_golang/prometheus"
 	api "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var (
@@ -35,11 +36,13 @@ var (
 	durationSinceFunc = time.Since
 )
 
-func recordDNSProgrammingLatency(svcs []*object.Service, endpoints *api.Endpoints) {
+// recordDNSProgrammingLatency records the latency for endpoints, which is either an *api.Endpoints or
+// a *discovery.EndpointSlice.
+func recordDNSProgrammingLatency(svcs []*object.Service, endpoints meta.Object) {
 	// getLastChangeTriggerTime is the time.Time value of the EndpointsLastChangeTriggerTime
 	// annotation stored in the given endpoints object or the "zero" time if the annotation wasn't set
 	var lastChangeTriggerTime time.Time
-	stringVal, ok := endpoints.Annotations[api.EndpointsLastChangeTriggerTime]
+	stringVal, ok := endpoints.GetAnnotations()[api.EndpointsLastChangeTriggerTime]
 	if ok {
 		ts, err := time.Parse(time.RFC3339Nano, stringVal)
 		if err != nil {
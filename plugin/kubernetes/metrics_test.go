@@ -24,7 +24,7 @@ func TestDnsProgrammingLatency(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	now := time.Now()
 	ctx := context.TODO()
-	controller := newdnsController(ctx, client, dnsControlOpts{
+	controller := newdnsController(ctx, client, nil, dnsControlOpts{
 		initEndpointsCache: true,
 		// This is needed as otherwise the fake k8s client doesn't work properly.
 		skipAPIObjectsCleanup: true,
@@ -0,0 +1,193 @@
+package kubernetes
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/transfer"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// notifyInterval is how often the cluster is checked for changes to the transferred zones.
+var notifyInterval = time.Second
+
+// zoneHistory keeps the records of a transferred zone as of its current serial, and a journal of the changes
+// leading up to it, so that IXFR requests can be answered with just the changes.
+type zoneHistory struct {
+	sync.RWMutex
+	synced  bool
+	serial  uint32
+	records []dns.RR // records of the zone at serial, without the SOA
+	journal *file.Journal
+}
+
+// newHistory returns the histories of the zones of k that can be transferred.
+func (k *Kubernetes) newHistory() map[string]*zoneHistory {
+	history := make(map[string]*zoneHistory)
+	for _, z := range k.Zones {
+		if k.isMultiClusterZone(z) {
+			continue
+		}
+		history[z] = &zoneHistory{journal: file.NewJournal("")}
+	}
+	return history
+}
+
+// zoneHistory returns the history of zone, or nil if no history is kept for it.
+func (k *Kubernetes) zoneHistory(zone string) *zoneHistory {
+	return k.history[strings.ToLower(zone)]
+}
+
+// watchZones checks the cluster for changes every notifyInterval until stop is closed. When the records of a zone
+// change, its serial is bumped, the changes are journaled and notifies are sent to the transfer to addresses.
+func (k *Kubernetes) watchZones(stop <-chan struct{}) {
+	ticker := time.NewTicker(notifyInterval)
+	defer ticker.Stop()
+
+	modified := int64(-1)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if !k.APIConn.HasSynced() {
+				continue
+			}
+			m := k.APIConn.Modified()
+			if m == modified {
+				continue
+			}
+			modified = m
+			for zone := range k.history {
+				if k.updateZone(zone, time.Now()) {
+					go transfer.Notify(zone, k.TransferTo)
+				}
+			}
+		}
+	}
+}
+
+// updateZone compares the records of zone in the cluster with the ones in its history. If they differ the serial is
+// bumped and the changes are added to the journal. It returns true if the zone has changed.
+func (k *Kubernetes) updateZone(zone string, now time.Time) bool {
+	h := k.history[zone]
+	if h == nil {
+		return false
+	}
+
+	records := k.zoneRecords(zone)
+	soa, err := plugin.SOA(context.Background(), k, zone, request.Request{Zone: zone}, plugin.Options{})
+	if err != nil {
+		return false
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	serial := uint32(now.Unix())
+	if !h.synced {
+		h.synced, h.serial, h.records = true, serial, records
+		return false
+	}
+
+	d := diffRecords(h.records, records)
+	if len(d.Deleted) == 0 && len(d.Added) == 0 {
+		return false
+	}
+	// Serials must increase, even when the zone changes more than once per second.
+	if int32(serial-h.serial) <= 0 {
+		serial = h.serial + 1
+	}
+	d.From = withSerial(soa[0].(*dns.SOA), h.serial)
+	d.To = withSerial(soa[0].(*dns.SOA), serial)
+	if err := h.journal.Add(d); err != nil {
+		log.Warningf("Failed to journal changes of zone %s: %s", zone, err)
+	}
+	h.serial, h.records = serial, records
+
+	log.Infof("Zone %s changed, serial is now %d", zone, serial)
+	return true
+}
+
+// zoneRecords returns the records of zone as they are transferred, sorted.
+func (k *Kubernetes) zoneRecords(zone string) []dns.RR {
+	c := make(chan dns.RR)
+	go k.transfer(c, zone)
+
+	records := []dns.RR{}
+	for r := range c {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].String() < records[j].String() })
+	return records
+}
+
+// transferRecords returns the records for a transfer of the zone of h, as of its current serial. For an IXFR request
+// from a secondary that is up to date this is just the SOA, and for one whose serial is in the journal these are the
+// changes since that serial. Otherwise all records are returned. The first and last record are the SOA. If h hasn't
+// synced yet nil is returned.
+func (h *zoneHistory) transferRecords(req *dns.Msg, soa *dns.SOA) []dns.RR {
+	h.RLock()
+	defer h.RUnlock()
+	if !h.synced {
+		return nil
+	}
+
+	current := withSerial(soa, h.serial)
+	if len(req.Question) == 1 && req.Question[0].Qtype == dns.TypeIXFR && len(req.Ns) == 1 {
+		if s, ok := req.Ns[0].(*dns.SOA); ok {
+			if int32(h.serial-s.Serial) <= 0 {
+				return []dns.RR{current}
+			}
+			if deltas := h.journal.Since(s.Serial, h.serial); deltas != nil {
+				rrs := []dns.RR{current}
+				for _, d := range deltas {
+					rrs = append(rrs, d.RRs()...)
+				}
+				return append(rrs, current)
+			}
+		}
+	}
+
+	rrs := make([]dns.RR, 0, len(h.records)+2)
+	rrs = append(rrs, current)
+	rrs = append(rrs, h.records...)
+	return append(rrs, current)
+}
+
+// withSerial returns a copy of soa with its serial set to serial.
+func withSerial(soa *dns.SOA, serial uint32) *dns.SOA {
+	s := dns.Copy(soa).(*dns.SOA)
+	s.Serial = serial
+	return s
+}
+
+// diffRecords returns the records from a that are not in b as deleted, and those from b that are not in a as added.
+func diffRecords(a, b []dns.RR) file.Delta {
+	d := file.Delta{}
+	inA := make(map[string]struct{}, len(a))
+	for _, rr := range a {
+		inA[rr.String()] = struct{}{}
+	}
+	inB := make(map[string]struct{}, len(b))
+	for _, rr := range b {
+		s := rr.String()
+		inB[s] = struct{}{}
+		if _, ok := inA[s]; !ok {
+			d.Added = append(d.Added, rr)
+		}
+	}
+	for _, rr := range a {
+		if _, ok := inB[rr.String()]; !ok {
+			d.Deleted = append(d.Deleted, rr)
+		}
+	}
+	return d
+}
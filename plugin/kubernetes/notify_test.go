@@ -0,0 +1,270 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	api "k8s.io/api/core/v1"
+)
+
+func TestUpdateZone(t *testing.T) {
+	conn := &APIConnNotifyTest{}
+	conn.setServices(notifySvc("svc1", "10.0.0.1"))
+	k := New([]string{"cluster.local."})
+	k.APIConn = conn
+	k.TransferTo = []string{"10.240.0.1:53"}
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.history = k.newHistory()
+
+	now := time.Unix(1000, 0)
+	if k.updateZone("cluster.local.", now) {
+		t.Errorf("Expected the first update to only sync the zone")
+	}
+	if k.updateZone("cluster.local.", now) {
+		t.Errorf("Expected zone without changes not to be updated")
+	}
+	if serial := k.Serial(zoneRequest("cluster.local.")); serial != 1000 {
+		t.Errorf("Expected serial %d, got %d", 1000, serial)
+	}
+
+	conn.setServices(notifySvc("svc1", "10.0.0.1"), notifySvc("svc2", "10.0.0.2"))
+	if !k.updateZone("cluster.local.", now) {
+		t.Errorf("Expected zone with a new service to be updated")
+	}
+	// Within the same second, the serial is still increased.
+	if serial := k.Serial(zoneRequest("cluster.local.")); serial != 1001 {
+		t.Errorf("Expected serial %d, got %d", 1001, serial)
+	}
+
+	conn.setServices(notifySvc("svc2", "10.0.0.3"))
+	if !k.updateZone("cluster.local.", time.Unix(2000, 0)) {
+		t.Errorf("Expected zone with changed services to be updated")
+	}
+	if serial := k.Serial(zoneRequest("cluster.local.")); serial != 2000 {
+		t.Errorf("Expected serial %d, got %d", 2000, serial)
+	}
+
+	h := k.zoneHistory("Cluster.Local.")
+	soa := &dns.SOA{Hdr: dns.RR_Header{Name: "cluster.local.", Rrtype: dns.TypeSOA, Class: dns.ClassINET}}
+
+	tests := []struct {
+		qtype    uint16
+		serial   uint32
+		expected []string // expected records, without their TTL and class
+	}{
+		{dns.TypeAXFR, 0, []string{
+			"SOA 2000",
+			"svc2.testns.svc.cluster.local. A 10.0.0.3",
+			"svc2.testns.svc.cluster.local. SRV 0 100 80 svc2.testns.svc.cluster.local.",
+			"SOA 2000",
+		}},
+		{dns.TypeIXFR, 2000, []string{"SOA 2000"}},
+		{dns.TypeIXFR, 1001, []string{
+			"SOA 2000",
+			"SOA 1001",
+			"svc1.testns.svc.cluster.local. A 10.0.0.1",
+			"svc1.testns.svc.cluster.local. SRV 0 100 80 svc1.testns.svc.cluster.local.",
+			"svc2.testns.svc.cluster.local. A 10.0.0.2",
+			"SOA 2000",
+			"svc2.testns.svc.cluster.local. A 10.0.0.3",
+			"SOA 2000",
+		}},
+		{dns.TypeIXFR, 1000, []string{
+			"SOA 2000",
+			"SOA 1000",
+			"SOA 1001",
+			"svc2.testns.svc.cluster.local. A 10.0.0.2",
+			"svc2.testns.svc.cluster.local. SRV 0 100 80 svc2.testns.svc.cluster.local.",
+			"SOA 1001",
+			"svc1.testns.svc.cluster.local. A 10.0.0.1",
+			"svc1.testns.svc.cluster.local. SRV 0 100 80 svc1.testns.svc.cluster.local.",
+			"svc2.testns.svc.cluster.local. A 10.0.0.2",
+			"SOA 2000",
+			"svc2.testns.svc.cluster.local. A 10.0.0.3",
+			"SOA 2000",
+		}},
+		// Not in the journal, fall back to AXFR.
+		{dns.TypeIXFR, 500, []string{
+			"SOA 2000",
+			"svc2.testns.svc.cluster.local. A 10.0.0.3",
+			"svc2.testns.svc.cluster.local. SRV 0 100 80 svc2.testns.svc.cluster.local.",
+			"SOA 2000",
+		}},
+	}
+
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		if tc.qtype == dns.TypeIXFR {
+			req.SetIxfr("cluster.local.", tc.serial, "ns.dns.cluster.local.", "hostmaster.cluster.local.")
+		} else {
+			req.SetAxfr("cluster.local.")
+		}
+		rrs := h.transferRecords(req, soa)
+		if len(rrs) != len(tc.expected) {
+			t.Errorf("Test %d, expected %d records, got %d: %v", i, len(tc.expected), len(rrs), rrs)
+			continue
+		}
+		for j, rr := range rrs {
+			if got := short(rr); got != tc.expected[j] {
+				t.Errorf("Test %d, expected record %d to be %q, got %q", i, j, tc.expected[j], got)
+			}
+		}
+	}
+}
+
+func TestWatchZones(t *testing.T) {
+	defer func(d time.Duration) { notifyInterval = d }(notifyInterval)
+	notifyInterval = 10 * time.Millisecond
+
+	notifies := make(chan string, 10)
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		if r.Opcode == dns.OpcodeNotify {
+			notifies <- r.Question[0].Name
+		}
+		m := new(dns.Msg)
+		m.SetReply(r)
+		w.WriteMsg(m)
+	})
+	defer s.Close()
+
+	conn := &APIConnNotifyTest{}
+	conn.setServices(notifySvc("svc1", "10.0.0.1"))
+	k := New([]string{"cluster.local."})
+	k.APIConn = conn
+	k.TransferTo = []string{s.Addr}
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.history = k.newHistory()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go k.watchZones(stop)
+
+	// Wait for the zone to be synced.
+	h := k.zoneHistory("cluster.local.")
+	for i := 0; ; i++ {
+		h.RLock()
+		synced := h.synced
+		h.RUnlock()
+		if synced {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected zone to be synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	serial := k.Serial(zoneRequest("cluster.local."))
+
+	conn.setServices(notifySvc("svc1", "10.0.0.1"), notifySvc("svc2", "10.0.0.2"))
+	select {
+	case zone := <-notifies:
+		if zone != "cluster.local." {
+			t.Errorf("Expected notify for %s, got %s", "cluster.local.", zone)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a notify after the zone changed")
+	}
+	if s := k.Serial(zoneRequest("cluster.local.")); s == serial {
+		t.Errorf("Expected serial to be bumped from %d", serial)
+	}
+}
+
+func TestKubernetesIXFR(t *testing.T) {
+	conn := &APIConnNotifyTest{}
+	conn.setServices(notifySvc("svc1", "10.0.0.1"))
+	k := New([]string{"cluster.local."})
+	k.APIConn = conn
+	k.TransferTo = []string{"10.240.0.1:53"}
+	k.Namespaces = map[string]struct{}{"testns": {}}
+	k.history = k.newHistory()
+
+	k.updateZone("cluster.local.", time.Unix(1000, 0))
+	conn.setServices(notifySvc("svc1", "10.0.0.2"))
+	k.updateZone("cluster.local.", time.Unix(1010, 0))
+
+	w := dnstest.NewMultiRecorder(&test.ResponseWriter{})
+	m := new(dns.Msg)
+	m.SetIxfr("cluster.local.", 1000, "ns.dns.cluster.local.", "hostmaster.cluster.local.")
+	if _, err := k.ServeDNS(context.TODO(), w, m); err != nil {
+		t.Fatal(err)
+	}
+
+	rrs := []dns.RR{}
+	for _, m := range w.Msgs {
+		rrs = append(rrs, m.Answer...)
+	}
+	expected := []string{
+		"SOA 1010",
+		"SOA 1000",
+		"svc1.testns.svc.cluster.local. A 10.0.0.1",
+		"SOA 1010",
+		"svc1.testns.svc.cluster.local. A 10.0.0.2",
+		"SOA 1010",
+	}
+	if len(rrs) != len(expected) {
+		t.Fatalf("Expected %d records, got %d: %v", len(expected), len(rrs), rrs)
+	}
+	for i, rr := range rrs {
+		if got := short(rr); got != expected[i] {
+			t.Errorf("Expected record %d to be %q, got %q", i, expected[i], got)
+		}
+	}
+}
+
+// short returns rr as "name type rdata", for SOA records it returns "SOA serial".
+func short(rr dns.RR) string {
+	if soa, ok := rr.(*dns.SOA); ok {
+		return fmt.Sprintf("SOA %d", soa.Serial)
+	}
+	hdr := rr.Header()
+	return hdr.Name + " " + dns.TypeToString[hdr.Rrtype] + " " + rr.String()[len(hdr.String()):]
+}
+
+func zoneRequest(zone string) request.Request {
+	return request.Request{Zone: zone}
+}
+
+func notifySvc(name, ip string) *object.Service {
+	return &object.Service{
+		Name:      name,
+		Namespace: "testns",
+		Type:      api.ServiceTypeClusterIP,
+		ClusterIP: ip,
+		Ports:     []api.ServicePort{{Port: 80, Protocol: "TCP"}},
+	}
+}
+
+type APIConnNotifyTest struct {
+	APIConnServeTest
+	sync.Mutex
+	svcs     []*object.Service
+	modified int64
+}
+
+func (a *APIConnNotifyTest) setServices(svcs ...*object.Service) {
+	a.Lock()
+	defer a.Unlock()
+	a.svcs = svcs
+	a.modified++
+}
+
+func (a *APIConnNotifyTest) ServiceList() []*object.Service {
+	a.Lock()
+	defer a.Unlock()
+	return a.svcs
+}
+
+func (a *APIConnNotifyTest) Modified() int64 {
+	a.Lock()
+	defer a.Unlock()
+	return a.modified
+}
@@ -148,3 +148,11 @@ func TestNsAddrs(t *testing.T) {
 		t.Errorf("Expected AAAA Header Name to be %q, got %q", expected, cdr.Header().Name)
 	}
 }
+
+func (APIConnTest) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (APIConnTest) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (APIConnTest) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (APIConnTest) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (APIConnTest) GetNode(string) *object.Node       { return nil }
+func (APIConnTest) ExternalHostIndex(string) []string { return nil }
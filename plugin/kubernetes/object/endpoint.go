@@ -1,7 +1,11 @@
 package object
 
 import (
+	"sort"
+	"strings"
+
 	api "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -91,6 +95,85 @@ func ToEndpoints(end *api.Endpoints) *Endpoints {
 	return e
 }
 
+// EndpointSliceToEndpoints converts a discovery.EndpointSlice to a *Endpoints. The Name is the name of the
+// slice, the Index is that of the service the slice belongs to. Endpoints that are not ready are left out,
+// these include endpoints that are terminating. Slices with FQDN addresses result in an empty Endpoints.
+func EndpointSliceToEndpoints(ends *discovery.EndpointSlice) *Endpoints {
+	e := &Endpoints{
+		Version:   ends.GetResourceVersion(),
+		Name:      ends.GetName(),
+		Namespace: ends.GetNamespace(),
+		Index:     EndpointsKey(ends.GetLabels()[discovery.LabelServiceName], ends.GetNamespace()),
+		Subsets:   make([]EndpointSubset, 1),
+	}
+	if ends.AddressType != discovery.AddressTypeIPv4 && ends.AddressType != discovery.AddressTypeIPv6 {
+		e.Subsets = nil
+		return e
+	}
+
+	sub := EndpointSubset{}
+	if len(ends.Ports) == 0 {
+		// Add sentinel if there are no ports.
+		sub.Ports = []EndpointPort{{Port: -1}}
+	} else {
+		sub.Ports = make([]EndpointPort, len(ends.Ports))
+	}
+	for k, p := range ends.Ports {
+		ep := EndpointPort{Port: -1}
+		if p.Port != nil {
+			ep.Port = *p.Port
+		}
+		if p.Name != nil {
+			ep.Name = *p.Name
+		}
+		if p.Protocol != nil {
+			ep.Protocol = string(*p.Protocol)
+		}
+		sub.Ports[k] = ep
+	}
+
+	for _, end := range ends.Endpoints {
+		// A nil ready condition must be interpreted as ready.
+		if end.Conditions.Ready != nil && !*end.Conditions.Ready {
+			continue
+		}
+		for _, a := range end.Addresses {
+			ea := EndpointAddress{IP: a, NodeName: end.Topology[api.LabelHostname]}
+			if end.Hostname != nil {
+				ea.Hostname = *end.Hostname
+			}
+			if end.TargetRef != nil {
+				ea.TargetRefName = end.TargetRef.Name
+			}
+			sub.Addresses = append(sub.Addresses, ea)
+			e.IndexIP = append(e.IndexIP, a)
+		}
+	}
+	e.Subsets[0] = sub
+
+	return e
+}
+
+// MergeEndpoints merges the endpoints converted from the EndpointSlices of a service in to a single Endpoints
+// for that service, as if it was converted from an api.Endpoints. The subsets are shared with the slices.
+func MergeEndpoints(name, namespace string, slices []*Endpoints) *Endpoints {
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+
+	e := &Endpoints{
+		Name:      name,
+		Namespace: namespace,
+		Index:     EndpointsKey(name, namespace),
+	}
+	versions := make([]string, len(slices))
+	for i, s := range slices {
+		versions[i] = s.Version
+		e.Subsets = append(e.Subsets, s.Subsets...)
+		e.IndexIP = append(e.IndexIP, s.IndexIP...)
+	}
+	e.Version = strings.Join(versions, ",")
+	return e
+}
+
 // CopyWithoutSubsets copies e, without the subsets.
 func (e *Endpoints) CopyWithoutSubsets() *Endpoints {
 	e1 := &Endpoints{
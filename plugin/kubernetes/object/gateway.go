@@ -0,0 +1,220 @@
+package object
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Gateway is a stripped down Gateway from the Kubernetes Gateway API, with only the items we need for CoreDNS.
+// The API is not part of the core API, Gateways are read as unstructured objects.
+type Gateway struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version   string
+	Name      string
+	Namespace string
+	Hostnames []string // hostnames of the listeners
+	Addresses []string
+
+	*Empty
+}
+
+// HTTPRoute is a stripped down HTTPRoute from the Kubernetes Gateway API, with only the items we need for CoreDNS.
+type HTTPRoute struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version   string
+	Name      string
+	Namespace string
+	Hostnames []string
+	Gateways  []string // namespace/name of the parent Gateways
+
+	*Empty
+}
+
+var (
+	// GatewayResource is the resource of the Gateways in the Gateway API.
+	GatewayResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+	// HTTPRouteResource is the resource of the HTTPRoutes in the Gateway API.
+	HTTPRouteResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+)
+
+// ToGateway returns a function that converts an unstructured Gateway to a *Gateway.
+func ToGateway(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		return toGateway(skipCleanup, u)
+	}
+}
+
+func toGateway(skipCleanup bool, u *unstructured.Unstructured) (*Gateway, error) {
+	g := &Gateway{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	listeners, _, err := unstructured.NestedSlice(u.Object, "spec", "listeners")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range listeners {
+		m, ok := l.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected listener %v", l)
+		}
+		if h, _, _ := unstructured.NestedString(m, "hostname"); h != "" {
+			g.Hostnames = append(g.Hostnames, Hostname(h))
+		}
+	}
+
+	addrs, _, err := unstructured.NestedSlice(u.Object, "status", "addresses")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		m, ok := a.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected address %v", a)
+		}
+		// Addresses of type Hostname can't be returned as A or AAAA records.
+		if t, _, _ := unstructured.NestedString(m, "type"); t != "" && t != "IPAddress" {
+			continue
+		}
+		if v, _, _ := unstructured.NestedString(m, "value"); v != "" {
+			g.Addresses = append(g.Addresses, v)
+		}
+	}
+
+	if !skipCleanup {
+		*u = unstructured.Unstructured{}
+	}
+
+	return g, nil
+}
+
+// ToHTTPRoute returns a function that converts an unstructured HTTPRoute to a *HTTPRoute.
+func ToHTTPRoute(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		return toHTTPRoute(skipCleanup, u)
+	}
+}
+
+func toHTTPRoute(skipCleanup bool, u *unstructured.Unstructured) (*HTTPRoute, error) {
+	r := &HTTPRoute{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+	}
+
+	hosts, _, err := unstructured.NestedStringSlice(u.Object, "spec", "hostnames")
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		r.Hostnames = append(r.Hostnames, Hostname(h))
+	}
+
+	parents, _, err := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parents {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected parentRef %v", p)
+		}
+		if kind, _, _ := unstructured.NestedString(m, "kind"); kind != "" && kind != "Gateway" {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(m, "name")
+		ns, _, _ := unstructured.NestedString(m, "namespace")
+		if ns == "" {
+			ns = r.Namespace
+		}
+		r.Gateways = append(r.Gateways, ns+"/"+name)
+	}
+
+	if !skipCleanup {
+		*u = unstructured.Unstructured{}
+	}
+
+	return r, nil
+}
+
+var (
+	_ runtime.Object = &Gateway{}
+	_ runtime.Object = &HTTPRoute{}
+)
+
+// DeepCopyObject implements the ObjectKind interface.
+func (g *Gateway) DeepCopyObject() runtime.Object {
+	g1 := &Gateway{
+		Version:   g.Version,
+		Name:      g.Name,
+		Namespace: g.Namespace,
+		Hostnames: make([]string, len(g.Hostnames)),
+		Addresses: make([]string, len(g.Addresses)),
+	}
+	copy(g1.Hostnames, g.Hostnames)
+	copy(g1.Addresses, g.Addresses)
+	return g1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (g *Gateway) GetNamespace() string { return g.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (g *Gateway) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (g *Gateway) GetName() string { return g.Name }
+
+// SetName implements the metav1.Object interface.
+func (g *Gateway) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (g *Gateway) GetResourceVersion() string { return g.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (g *Gateway) SetResourceVersion(version string) {}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (r *HTTPRoute) DeepCopyObject() runtime.Object {
+	r1 := &HTTPRoute{
+		Version:   r.Version,
+		Name:      r.Name,
+		Namespace: r.Namespace,
+		Hostnames: make([]string, len(r.Hostnames)),
+		Gateways:  make([]string, len(r.Gateways)),
+	}
+	copy(r1.Hostnames, r.Hostnames)
+	copy(r1.Gateways, r.Gateways)
+	return r1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (r *HTTPRoute) GetNamespace() string { return r.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (r *HTTPRoute) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (r *HTTPRoute) GetName() string { return r.Name }
+
+// SetName implements the metav1.Object interface.
+func (r *HTTPRoute) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (r *HTTPRoute) GetResourceVersion() string { return r.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (r *HTTPRoute) SetResourceVersion(version string) {}
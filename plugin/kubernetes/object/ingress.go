@@ -0,0 +1,94 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Ingress is a stripped down networking.Ingress with only the items we need for CoreDNS.
+type Ingress struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version   string
+	Name      string
+	Namespace string
+	Hostnames []string
+	Addresses []string
+
+	*Empty
+}
+
+// ToIngress returns a function that converts a networking.Ingress to an *Ingress.
+func ToIngress(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		ing, ok := obj.(*networking.Ingress)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		return toIngress(skipCleanup, ing), nil
+	}
+}
+
+func toIngress(skipCleanup bool, ing *networking.Ingress) *Ingress {
+	i := &Ingress{
+		Version:   ing.GetResourceVersion(),
+		Name:      ing.GetName(),
+		Namespace: ing.GetNamespace(),
+	}
+	for _, r := range ing.Spec.Rules {
+		if r.Host != "" {
+			i.Hostnames = append(i.Hostnames, Hostname(r.Host))
+		}
+	}
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			i.Addresses = append(i.Addresses, lb.IP)
+		}
+	}
+
+	if !skipCleanup {
+		*ing = networking.Ingress{}
+	}
+
+	return i
+}
+
+// Hostname returns host as it is indexed: lower cased and fully qualified.
+func Hostname(host string) string { return dns.Fqdn(strings.ToLower(host)) }
+
+var _ runtime.Object = &Ingress{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (i *Ingress) DeepCopyObject() runtime.Object {
+	i1 := &Ingress{
+		Version:   i.Version,
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Hostnames: make([]string, len(i.Hostnames)),
+		Addresses: make([]string, len(i.Addresses)),
+	}
+	copy(i1.Hostnames, i.Hostnames)
+	copy(i1.Addresses, i.Addresses)
+	return i1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (i *Ingress) GetNamespace() string { return i.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (i *Ingress) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (i *Ingress) GetName() string { return i.Name }
+
+// SetName implements the metav1.Object interface.
+func (i *Ingress) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (i *Ingress) GetResourceVersion() string { return i.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (i *Ingress) SetResourceVersion(version string) {}
@@ -0,0 +1,49 @@
+package object
+
+import (
+	"fmt"
+
+	discovery "k8s.io/api/discovery/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Labels set on the EndpointSlices of imported services by the Kubernetes Multi-Cluster Services API.
+const (
+	// LabelMultiClusterServiceName is the name of the ServiceImport the slice belongs to.
+	LabelMultiClusterServiceName = "multicluster.kubernetes.io/service-name"
+	// LabelSourceCluster is the ID of the cluster the endpoints are in.
+	LabelSourceCluster = "multicluster.kubernetes.io/source-cluster"
+)
+
+// MultiClusterEndpoints is the Endpoints of a single EndpointSlice of an imported service, together with the
+// ID of the cluster the endpoints are in. The Index is that of the ServiceImport.
+type MultiClusterEndpoints struct {
+	Endpoints
+	ClusterID string
+}
+
+// ToMultiClusterEndpoints returns a function that converts a discovery.EndpointSlice to a *MultiClusterEndpoints.
+func ToMultiClusterEndpoints(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		ends, ok := obj.(*discovery.EndpointSlice)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		e := EndpointSliceToEndpoints(ends)
+		e.Index = EndpointsKey(ends.GetLabels()[LabelMultiClusterServiceName], ends.GetNamespace())
+		m := &MultiClusterEndpoints{Endpoints: *e, ClusterID: ends.GetLabels()[LabelSourceCluster]}
+
+		if !skipCleanup {
+			*ends = discovery.EndpointSlice{}
+		}
+		return m, nil
+	}
+}
+
+var _ runtime.Object = &MultiClusterEndpoints{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (m *MultiClusterEndpoints) DeepCopyObject() runtime.Object {
+	e := m.Endpoints.DeepCopyObject().(*Endpoints)
+	return &MultiClusterEndpoints{Endpoints: *e, ClusterID: m.ClusterID}
+}
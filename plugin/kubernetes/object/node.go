@@ -0,0 +1,76 @@
+package object
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Node is a stripped down api.Node with only the items we need for CoreDNS.
+type Node struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version string
+	Name    string
+	Zone    string
+
+	*Empty
+}
+
+// ToNode returns a function that converts an api.Node to a *Node.
+func ToNode(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		node, ok := obj.(*api.Node)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		return toNode(skipCleanup, node), nil
+	}
+}
+
+func toNode(skipCleanup bool, node *api.Node) *Node {
+	n := &Node{
+		Version: node.GetResourceVersion(),
+		Name:    node.GetName(),
+		Zone:    node.GetLabels()[api.LabelZoneFailureDomainStable],
+	}
+	if n.Zone == "" {
+		n.Zone = node.GetLabels()[api.LabelZoneFailureDomain]
+	}
+
+	if !skipCleanup {
+		*node = api.Node{}
+	}
+
+	return n
+}
+
+var _ runtime.Object = &Node{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (n *Node) DeepCopyObject() runtime.Object {
+	n1 := &Node{
+		Version: n.Version,
+		Name:    n.Name,
+		Zone:    n.Zone,
+	}
+	return n1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (n *Node) GetNamespace() string { return "" }
+
+// SetNamespace implements the metav1.Object interface.
+func (n *Node) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (n *Node) GetName() string { return n.Name }
+
+// SetName implements the metav1.Object interface.
+func (n *Node) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (n *Node) GetResourceVersion() string { return n.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (n *Node) SetResourceVersion(version string) {}
@@ -15,6 +15,7 @@ type Pod struct {
 	PodIP     string
 	Name      string
 	Namespace string
+	NodeName  string
 
 	*Empty
 }
@@ -45,6 +46,7 @@ func toPod(skipCleanup bool, pod *api.Pod) *Pod {
 		PodIP:     pod.Status.PodIP,
 		Namespace: pod.GetNamespace(),
 		Name:      pod.GetName(),
+		NodeName:  pod.Spec.NodeName,
 	}
 
 	if !skipCleanup {
@@ -63,6 +65,7 @@ func (p *Pod) DeepCopyObject() runtime.Object {
 		PodIP:     p.PodIP,
 		Namespace: p.Namespace,
 		Name:      p.Name,
+		NodeName:  p.NodeName,
 	}
 	return p1
 }
@@ -0,0 +1,127 @@
+package object
+
+import (
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ServiceImport is a stripped down ServiceImport from the Kubernetes Multi-Cluster Services API, with only the
+// items we need for CoreDNS. The API is not part of the core API, ServiceImports are read as unstructured
+// objects.
+type ServiceImport struct {
+	// Don't add new fields to this struct without talking to the CoreDNS maintainers.
+	Version    string
+	Name       string
+	Namespace  string
+	Index      string
+	ClusterIPs []string
+	Type       string
+	Ports      []api.ServicePort
+
+	*Empty
+}
+
+// ServiceImportResource is the resource of the ServiceImports in the Multi-Cluster Services API.
+var ServiceImportResource = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+const (
+	// ServiceImportClusterSetIP is the type of a ServiceImport that has one or more cluster set IPs.
+	ServiceImportClusterSetIP = "ClusterSetIP"
+	// ServiceImportHeadless is the type of a ServiceImport that is headless.
+	ServiceImportHeadless = "Headless"
+)
+
+// ToServiceImport returns a function that converts an unstructured ServiceImport to a *ServiceImport.
+func ToServiceImport(skipCleanup bool) ToFunc {
+	return func(obj interface{}) (interface{}, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %v", obj)
+		}
+		return toServiceImport(skipCleanup, u)
+	}
+}
+
+func toServiceImport(skipCleanup bool, u *unstructured.Unstructured) (*ServiceImport, error) {
+	s := &ServiceImport{
+		Version:   u.GetResourceVersion(),
+		Name:      u.GetName(),
+		Namespace: u.GetNamespace(),
+		Index:     ServiceKey(u.GetName(), u.GetNamespace()),
+	}
+
+	var err error
+	if s.Type, _, err = unstructured.NestedString(u.Object, "spec", "type"); err != nil {
+		return nil, err
+	}
+	if s.ClusterIPs, _, err = unstructured.NestedStringSlice(u.Object, "spec", "ips"); err != nil {
+		return nil, err
+	}
+	ports, _, err := unstructured.NestedSlice(u.Object, "spec", "ports")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ports {
+		m, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected port %v", p)
+		}
+		port, _, _ := unstructured.NestedInt64(m, "port")
+		name, _, _ := unstructured.NestedString(m, "name")
+		protocol, _, _ := unstructured.NestedString(m, "protocol")
+		if protocol == "" {
+			protocol = string(api.ProtocolTCP)
+		}
+		s.Ports = append(s.Ports, api.ServicePort{Name: name, Protocol: api.Protocol(protocol), Port: int32(port)})
+	}
+	if len(s.Ports) == 0 {
+		// Add sentinel if there are no ports.
+		s.Ports = []api.ServicePort{{Port: -1}}
+	}
+
+	if !skipCleanup {
+		*u = unstructured.Unstructured{}
+	}
+
+	return s, nil
+}
+
+var _ runtime.Object = &ServiceImport{}
+
+// DeepCopyObject implements the ObjectKind interface.
+func (s *ServiceImport) DeepCopyObject() runtime.Object {
+	s1 := &ServiceImport{
+		Version:    s.Version,
+		Name:       s.Name,
+		Namespace:  s.Namespace,
+		Index:      s.Index,
+		Type:       s.Type,
+		ClusterIPs: make([]string, len(s.ClusterIPs)),
+		Ports:      make([]api.ServicePort, len(s.Ports)),
+	}
+	copy(s1.ClusterIPs, s.ClusterIPs)
+	copy(s1.Ports, s.Ports)
+	return s1
+}
+
+// GetNamespace implements the metav1.Object interface.
+func (s *ServiceImport) GetNamespace() string { return s.Namespace }
+
+// SetNamespace implements the metav1.Object interface.
+func (s *ServiceImport) SetNamespace(namespace string) {}
+
+// GetName implements the metav1.Object interface.
+func (s *ServiceImport) GetName() string { return s.Name }
+
+// SetName implements the metav1.Object interface.
+func (s *ServiceImport) SetName(name string) {}
+
+// GetResourceVersion implements the metav1.Object interface.
+func (s *ServiceImport) GetResourceVersion() string { return s.Version }
+
+// SetResourceVersion implements the metav1.Object interface.
+func (s *ServiceImport) SetResourceVersion(version string) {}
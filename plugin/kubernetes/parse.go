@@ -13,6 +13,8 @@ type recordRequest struct {
 	// SRV record.
 	protocol string
 	endpoint string
+	// The cluster ID of the endpoint, only set for multicluster zones.
+	cluster string
 	// The servicename used in Kubernetes.
 	service string
 	// The namespace used in Kubernetes.
@@ -23,11 +25,13 @@ type recordRequest struct {
 
 // parseRequest parses the qname to find all the elements we need for querying k8s. Anything
 // that is not parsed will have the wildcard "*" value (except r.endpoint).
-// Potential underscores are stripped from _port and _protocol.
-func parseRequest(name, zone string) (r recordRequest, err error) {
+// Potential underscores are stripped from _port and _protocol. For multicluster zones an endpoint
+// is qualified with the cluster it is in.
+func parseRequest(name, zone string, multicluster bool) (r recordRequest, err error) {
 	// 3 Possible cases:
 	// 1. _port._protocol.service.namespace.pod|svc.zone
 	// 2. (endpoint): endpoint.service.namespace.pod|svc.zone
+	//    (endpoint): endpoint.cluster.service.namespace.svc.zone, for multicluster zones
 	// 3. (service): service.namespace.pod|svc.zone
 
 	base, _ := dnsutil.TrimZone(name, zone)
@@ -79,8 +83,16 @@ func parseRequest(name, zone string) (r recordRequest, err error) {
 	switch last {
 
 	case 0: // endpoint only
+		if multicluster {
+			return r, errInvalidRequest
+		}
 		r.endpoint = segs[last]
-	case 1: // service and port
+	case 1: // service and port, or endpoint and cluster
+		if multicluster && segs[last][0] != '_' && segs[last-1][0] != '_' {
+			r.cluster = segs[last]
+			r.endpoint = segs[last-1]
+			break
+		}
 		r.protocol = stripUnderscore(segs[last])
 		r.port = stripUnderscore(segs[last-1])
 
@@ -31,7 +31,7 @@ func TestParseRequest(t *testing.T) {
 		m.SetQuestion(tc.query, dns.TypeA)
 		state := request.Request{Zone: zone, Req: m}
 
-		r, e := parseRequest(state.Name(), state.Zone)
+		r, e := parseRequest(state.Name(), state.Zone, false)
 		if e != nil {
 			t.Errorf("Test %d, expected no error, got '%v'.", i, e)
 		}
@@ -53,10 +53,46 @@ func TestParseInvalidRequest(t *testing.T) {
 		m.SetQuestion(query, dns.TypeA)
 		state := request.Request{Zone: zone, Req: m}
 
-		if _, e := parseRequest(state.Name(), state.Zone); e == nil {
+		if _, e := parseRequest(state.Name(), state.Zone, false); e == nil {
 			t.Errorf("Test %d: expected error from %s, got none", i, query)
 		}
 	}
 }
 
 const zone = "inter.webs.tests."
+
+func TestParseMultiClusterRequest(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string // output from r.String()
+		cluster  string
+	}{
+		// valid SRV request
+		{"_http._tcp.webs.mynamespace.svc.inter.webs.tests.", "http.tcp..webs.mynamespace.svc", ""},
+		// A request of endpoint in a cluster
+		{"1-2-3-4.cluster1.webs.mynamespace.svc.inter.webs.tests.", "*.*.1-2-3-4.webs.mynamespace.svc", "cluster1"},
+		// A request of a service
+		{"webs.mynamespace.svc.inter.webs.tests.", "*.*..webs.mynamespace.svc", ""},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.query, dns.TypeA)
+		state := request.Request{Zone: zone, Req: m}
+
+		r, e := parseRequest(state.Name(), state.Zone, true)
+		if e != nil {
+			t.Errorf("Test %d, expected no error, got '%v'.", i, e)
+		}
+		if rs := r.String(); rs != tc.expected {
+			t.Errorf("Test %d, expected (stringified) recordRequest: %s, got %s", i, tc.expected, rs)
+		}
+		if r.cluster != tc.cluster {
+			t.Errorf("Test %d, expected cluster %q, got %q", i, tc.cluster, r.cluster)
+		}
+	}
+
+	// An endpoint without a cluster is not valid in a multicluster zone.
+	if _, e := parseRequest("1-2-3-4.webs.mynamespace.svc.inter.webs.tests.", zone, true); e == nil {
+		t.Errorf("Expected error for endpoint without cluster, got none")
+	}
+}
@@ -226,3 +226,11 @@ func TestReverse(t *testing.T) {
 		}
 	}
 }
+
+func (APIConnReverseTest) ServiceImportList() []*object.ServiceImport                 { return nil }
+func (APIConnReverseTest) MultiClusterEndpointsList() []*object.MultiClusterEndpoints { return nil }
+func (APIConnReverseTest) SvcImportIndex(string) []*object.ServiceImport              { return nil }
+func (APIConnReverseTest) McEpIndex(string) []*object.MultiClusterEndpoints           { return nil }
+
+func (APIConnReverseTest) GetNode(string) *object.Node       { return nil }
+func (APIConnReverseTest) ExternalHostIndex(string) []string { return nil }
@@ -48,6 +48,19 @@ func setup(c *caddy.Controller) error {
 
 	k.RegisterKubeCache(c)
 
+	if len(k.TransferTo) > 0 {
+		k.history = k.newHistory()
+		stop := make(chan struct{})
+		c.OnStartup(func() error {
+			go k.watchZones(stop)
+			return nil
+		})
+		c.OnShutdown(func() error {
+			close(stop)
+			return nil
+		})
+	}
+
 	c.OnStartup(func() error {
 		metrics.MustRegister(c, DnsProgrammingLatency)
 		return nil
@@ -138,19 +151,6 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 		}
 	}
 
-	k8s.primaryZoneIndex = -1
-	for i, z := range k8s.Zones {
-		if dnsutil.IsReverse(z) > 0 {
-			continue
-		}
-		k8s.primaryZoneIndex = i
-		break
-	}
-
-	if k8s.primaryZoneIndex == -1 {
-		return nil, errors.New("non-reverse zone name must be used")
-	}
-
 	k8s.Upstream = upstream.New()
 
 	for c.NextBlock() {
@@ -266,6 +266,30 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 					return nil, fmt.Errorf("unable to parse ignore value: '%v'", ignore)
 				}
 			}
+		case "topology":
+			args := c.RemainingArgs()
+			if len(args) == 0 {
+				args = []string{topologyNode, topologyZone}
+			}
+			k8s.topology = nil
+			for _, a := range args {
+				if a != topologyNode && a != topologyZone {
+					return nil, c.Errf("wrong value for topology: %s, must be one of: node, zone", a)
+				}
+				k8s.topology = append(k8s.topology, a)
+			}
+		case "multicluster":
+			args := c.RemainingArgs()
+			if len(args) == 0 {
+				return nil, c.ArgErr()
+			}
+			for _, a := range args {
+				z := plugin.Host(a).Normalize()
+				if plugin.Zones(k8s.Zones).Matches(z) != z {
+					return nil, c.Errf("multicluster zone %q is not one of the zones of this plugin", a)
+				}
+				k8s.opts.multiclusterZones = append(k8s.opts.multiclusterZones, z)
+			}
 		case "kubeconfig":
 			args := c.RemainingArgs()
 			if len(args) == 2 {
@@ -286,6 +310,24 @@ func ParseStanza(c *caddy.Controller) (*Kubernetes, error) {
 		return nil, c.Errf("namespaces and namespace_labels cannot both be set")
 	}
 
+	if len(k8s.topology) > 0 && k8s.podMode != podModeVerified {
+		return nil, c.Errf("topology requires pods verified")
+	}
+
+	// The primary zone is the cluster's own zone, it can't be a multicluster zone.
+	k8s.primaryZoneIndex = -1
+	for i, z := range k8s.Zones {
+		if dnsutil.IsReverse(z) > 0 || k8s.isMultiClusterZone(z) {
+			continue
+		}
+		k8s.primaryZoneIndex = i
+		break
+	}
+
+	if k8s.primaryZoneIndex == -1 {
+		return nil, errors.New("non-reverse zone name must be used")
+	}
+
 	return k8s, nil
 }
 
@@ -597,3 +597,145 @@ func TestKubernetesParseIgnoreEmptyService(t *testing.T) {
 		}
 	}
 }
+
+func TestKubernetesParseMulticluster(t *testing.T) {
+	tests := []struct {
+		input                string // Corefile data as string
+		shouldErr            bool   // true if test case is expected to produce an error.
+		expectedErrContent   string // substring from the expected error. Empty for positive cases.
+		expectedMulticluster []string
+		expectedPrimaryZone  string
+	}{
+		// valid
+		{
+			`kubernetes cluster.local clusterset.local {
+	multicluster clusterset.local
+}`,
+			false,
+			"",
+			[]string{"clusterset.local."},
+			"cluster.local.",
+		},
+		// multicluster zone listed first is not the primary zone
+		{
+			`kubernetes clusterset.local cluster.local {
+	multicluster clusterset.local
+}`,
+			false,
+			"",
+			[]string{"clusterset.local."},
+			"cluster.local.",
+		},
+		// not set
+		{
+			`kubernetes cluster.local {
+}`,
+			false,
+			"",
+			nil,
+			"cluster.local.",
+		},
+		// invalid
+		{
+			`kubernetes cluster.local {
+	multicluster
+}`,
+			true,
+			"rong argument count or unexpected",
+			nil,
+			"",
+		},
+		{
+			`kubernetes cluster.local {
+	multicluster clusterset.local
+}`,
+			true,
+			"is not one of the zones",
+			nil,
+			"",
+		},
+		{
+			`kubernetes clusterset.local {
+	multicluster clusterset.local
+}`,
+			true,
+			"non-reverse zone name must be used",
+			nil,
+			"",
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error, but did not find error for input '%s'. Error was: '%v'", i, test.input, err)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		found := k8sController.opts.multiclusterZones
+		if strings.Join(found, ",") != strings.Join(test.expectedMulticluster, ",") {
+			t.Errorf("Test %d: Expected multicluster zones %v, found %v for input '%s'", i, test.expectedMulticluster, found, test.input)
+		}
+		if z := k8sController.primaryZone(); z != test.expectedPrimaryZone {
+			t.Errorf("Test %d: Expected primary zone %q, found %q for input '%s'", i, test.expectedPrimaryZone, z, test.input)
+		}
+	}
+}
+
+func TestKubernetesParseTopology(t *testing.T) {
+	tests := []struct {
+		input            string // Corefile data as string
+		shouldErr        bool   // true if test case is expected to produce an error.
+		expectedTopology string
+	}{
+		{`kubernetes cluster.local {
+	pods verified
+	topology
+}`, false, "node,zone"},
+		{`kubernetes cluster.local {
+	pods verified
+	topology zone
+}`, false, "zone"},
+		{`kubernetes cluster.local {
+	pods verified
+}`, false, ""},
+		{`kubernetes cluster.local {
+	pods verified
+	topology region
+}`, true, ""},
+		{`kubernetes cluster.local {
+	topology zone
+}`, true, ""},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		k8sController, err := kubernetesParse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error, but did not find error for input '%s'", i, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+		if found := strings.Join(k8sController.topology, ","); found != test.expectedTopology {
+			t.Errorf("Test %d: Expected topology %q, found %q for input '%s'", i, test.expectedTopology, found, test.input)
+		}
+	}
+}
@@ -3,9 +3,13 @@ package kubernetes
 import (
 	"context"
 
+	"github.com/coredns/coredns/plugin/kubernetes/object"
+
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -43,6 +47,59 @@ func endpointsWatchFunc(ctx context.Context, c kubernetes.Interface, ns string,
 	}
 }
 
+func endpointSliceWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		w, err := c.DiscoveryV1beta1().EndpointSlices(ns).Watch(ctx, options)
+		return w, err
+	}
+}
+
+func mcEndpointSliceWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		options.LabelSelector = mcEndpointSliceSelector(s)
+		w, err := c.DiscoveryV1beta1().EndpointSlices(ns).Watch(ctx, options)
+		return w, err
+	}
+}
+
+// mcEndpointSliceSelector returns the label selector for the EndpointSlices of imported services, combined with s.
+func mcEndpointSliceSelector(s labels.Selector) string {
+	if s == nil || s.Empty() {
+		return object.LabelMultiClusterServiceName
+	}
+	return object.LabelMultiClusterServiceName + "," + s.String()
+}
+
+func ingressWatchFunc(ctx context.Context, c kubernetes.Interface, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		w, err := c.NetworkingV1beta1().Ingresses(ns).Watch(ctx, options)
+		return w, err
+	}
+}
+
+func unstructuredWatchFunc(ctx context.Context, c dynamic.Interface, res schema.GroupVersionResource, ns string, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = s.String()
+		}
+		w, err := c.Resource(res).Namespace(ns).Watch(ctx, options)
+		return w, err
+	}
+}
+
+func nodeWatchFunc(ctx context.Context, c kubernetes.Interface) func(options meta.ListOptions) (watch.Interface, error) {
+	return func(options meta.ListOptions) (watch.Interface, error) {
+		w, err := c.CoreV1().Nodes().Watch(ctx, options)
+		return w, err
+	}
+}
+
 func namespaceWatchFunc(ctx context.Context, c kubernetes.Interface, s labels.Selector) func(options meta.ListOptions) (watch.Interface, error) {
 	return func(options meta.ListOptions) (watch.Interface, error) {
 		if s != nil {
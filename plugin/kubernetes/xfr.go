@@ -16,8 +16,18 @@ import (
 
 const transferLength = 2000
 
-// Serial implements the Transferer interface.
-func (k *Kubernetes) Serial(state request.Request) uint32 { return uint32(k.APIConn.Modified()) }
+// Serial implements the Transferer interface. When transfers are enabled, the serial of a zone is bumped whenever
+// its records change.
+func (k *Kubernetes) Serial(state request.Request) uint32 {
+	if h := k.zoneHistory(state.Zone); h != nil {
+		h.RLock()
+		defer h.RUnlock()
+		if h.synced {
+			return h.serial
+		}
+	}
+	return uint32(k.APIConn.Modified())
+}
 
 // MinTTL implements the Transferer interface.
 func (k *Kubernetes) MinTTL(state request.Request) uint32 { return k.ttl }
@@ -25,33 +35,42 @@ func (k *Kubernetes) MinTTL(state request.Request) uint32 { return k.ttl }
 // Transfer implements the Transferer interface.
 func (k *Kubernetes) Transfer(ctx context.Context, state request.Request) (int, error) {
 
-	if !k.transferAllowed(state) {
+	// Transfers of multicluster zones are not supported.
+	if !k.transferAllowed(state) || k.isMultiClusterZone(state.Zone) {
 		return dns.RcodeRefused, nil
 	}
 
-	// Get all services.
-	rrs := make(chan dns.RR)
-	go k.transfer(rrs, state.Zone)
+	soa, err := plugin.SOA(ctx, k, state.Zone, state, plugin.Options{})
+	if err != nil {
+		return dns.RcodeServerFailure, nil
+	}
 
-	records := []dns.RR{}
-	for r := range rrs {
-		records = append(records, r)
+	// Transfer the zone as of its serial, or just the changes since the serial of an IXFR request.
+	var records []dns.RR
+	if h := k.zoneHistory(state.Zone); h != nil {
+		records = h.transferRecords(state.Req, soa[0].(*dns.SOA))
 	}
 
-	if len(records) == 0 {
-		return dns.RcodeServerFailure, nil
+	if records == nil {
+		// Get all services.
+		rrs := make(chan dns.RR)
+		go k.transfer(rrs, state.Zone)
+
+		for r := range rrs {
+			records = append(records, r)
+		}
+
+		if len(records) == 0 {
+			return dns.RcodeServerFailure, nil
+		}
+
+		records = append(soa, records...)
+		records = append(records, soa...)
 	}
 
 	ch := make(chan *dns.Envelope)
 	tr := new(dns.Transfer)
 
-	soa, err := plugin.SOA(ctx, k, state.Zone, state, plugin.Options{})
-	if err != nil {
-		return dns.RcodeServerFailure, nil
-	}
-
-	records = append(soa, records...)
-	records = append(records, soa...)
 	go func(ch chan *dns.Envelope) {
 		j, l := 0, 0
 		log.Infof("Outgoing transfer of %d records of zone %s to %s started", len(records), state.Zone, state.IP())
@@ -22,3 +22,18 @@ func (rr RoundRobin) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.
 
 // Name implements the Handler interface.
 func (rr RoundRobin) Name() string { return "loadbalance" }
+
+// Weighted is a plugin to rewrite responses for "load balancing" by the weights of the addresses.
+type Weighted struct {
+	Next    plugin.Handler
+	weights *weights
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (w Weighted) ServeDNS(ctx context.Context, rw dns.ResponseWriter, r *dns.Msg) (int, error) {
+	wrw := &WeightedResponseWriter{rw, w.weights}
+	return plugin.NextOrFailure(w.Name(), w.Next, ctx, wrw, r)
+}
+
+// Name implements the Handler interface.
+func (w Weighted) Name() string { return "loadbalance" }
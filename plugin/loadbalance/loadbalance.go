@@ -1,4 +1,4 @@
-// Package loadbalance shuffles A, AAAA and MX records.
+// Package loadbalance shuffles A, AAAA and MX records, or orders A and AAAA records by weight.
 package loadbalance
 
 import (
@@ -26,6 +26,14 @@ func (r *RoundRobinResponseWriter) WriteMsg(res *dns.Msg) error {
 }
 
 func roundRobin(in []dns.RR) []dns.RR {
+	return balance(in, func(address []dns.RR) []dns.RR {
+		roundRobinShuffle(address)
+		return address
+	})
+}
+
+// balance sorts CNAMEs before the other records, orders the address records with order and shuffles the MX records.
+func balance(in []dns.RR, order func([]dns.RR) []dns.RR) []dns.RR {
 	cname := []dns.RR{}
 	address := []dns.RR{}
 	mx := []dns.RR{}
@@ -43,7 +51,7 @@ func roundRobin(in []dns.RR) []dns.RR {
 		}
 	}
 
-	roundRobinShuffle(address)
+	address = order(address)
 	roundRobinShuffle(mx)
 
 	out := append(cname, rest...)
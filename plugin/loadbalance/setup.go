@@ -2,6 +2,8 @@ package loadbalance
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -14,32 +16,85 @@ var log = clog.NewWithPlugin("loadbalance")
 
 func init() { plugin.Register("loadbalance", setup) }
 
+const defaultReload = 30 * time.Second
+
 func setup(c *caddy.Controller) error {
-	err := parse(c)
+	w, err := parse(c)
 	if err != nil {
 		return plugin.Error("loadbalance", err)
 	}
 
+	if w == nil {
+		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+			return RoundRobin{Next: next}
+		})
+		return nil
+	}
+
+	c.OnStartup(func() error {
+		w.periodicUpdate()
+		return nil
+	})
+	c.OnShutdown(func() error {
+		close(w.stop)
+		return nil
+	})
+
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
-		return RoundRobin{Next: next}
+		return Weighted{Next: next, weights: w}
 	})
 
 	return nil
 }
 
-func parse(c *caddy.Controller) error {
+// parse parses the policy, it returns the weights for the weighted policy and nil for round robin.
+func parse(c *caddy.Controller) (*weights, error) {
+	config := dnsserver.GetConfig(c)
+
 	for c.Next() {
 		args := c.RemainingArgs()
-		switch len(args) {
-		case 0:
-			return nil
-		case 1:
-			if args[0] != "round_robin" {
-				return fmt.Errorf("unknown policy: %s", args[0])
+		switch {
+		case len(args) == 0:
+			return nil, nil
+		case args[0] == "weighted":
+			if len(args) != 2 {
+				return nil, c.ArgErr()
+			}
+		case len(args) > 1:
+			return nil, c.ArgErr()
+		case args[0] != "round_robin":
+			return nil, fmt.Errorf("unknown policy: %s", args[0])
+		default:
+			return nil, nil
+		}
 
+		w := &weights{path: args[1], reload: defaultReload, stop: make(chan struct{})}
+		if !filepath.IsAbs(w.path) && config.Root != "" {
+			w.path = filepath.Join(config.Root, w.path)
+		}
+		for c.NextBlock() {
+			switch c.Val() {
+			case "reload":
+				remaining := c.RemainingArgs()
+				if len(remaining) != 1 {
+					return nil, c.Errf("reload needs a duration (zero seconds to disable)")
+				}
+				reload, err := time.ParseDuration(remaining[0])
+				if err != nil {
+					return nil, c.Errf("invalid duration for reload '%s'", remaining[0])
+				}
+				if reload < 0 {
+					return nil, c.Errf("invalid negative duration for reload '%s'", remaining[0])
+				}
+				w.reload = reload
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
-			return nil
 		}
+		if err := w.readWeights(); err != nil {
+			return nil, c.Errf("failed to read weights file: %v", err)
+		}
+		return w, nil
 	}
-	return c.ArgErr()
+	return nil, c.ArgErr()
 }
@@ -1,8 +1,12 @@
 package loadbalance
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/caddyserver/caddy"
 )
@@ -24,7 +28,7 @@ func TestSetup(t *testing.T) {
 
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.input)
-		err := parse(c)
+		_, err := parse(c)
 
 		if test.shouldErr && err == nil {
 			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
@@ -41,3 +45,75 @@ func TestSetup(t *testing.T) {
 		}
 	}
 }
+
+func TestSetupWeighted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loadbalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "weights")
+	if err := ioutil.WriteFile(path, []byte("www.example.org\n192.0.2.1 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid")
+	if err := ioutil.WriteFile(invalid, []byte("192.0.2.1 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedReload     time.Duration
+		expectedErrContent string
+	}{
+		{`loadbalance weighted ` + path, false, defaultReload, ""},
+		{`loadbalance weighted ` + path + ` {
+			reload 10s
+		}`, false, 10 * time.Second, ""},
+		{`loadbalance weighted ` + path + ` {
+			reload 0s
+		}`, false, 0, ""},
+		{`loadbalance weighted`, true, 0, "Wrong argument count"},
+		{`loadbalance weighted ` + path + ` extra`, true, 0, "Wrong argument count"},
+		{`loadbalance weighted ` + filepath.Join(dir, "missing"), true, 0, "failed to read weights file"},
+		{`loadbalance weighted ` + invalid, true, 0, "address before the first name"},
+		{`loadbalance weighted ` + path + ` {
+			reload -10s
+		}`, true, 0, "invalid negative duration"},
+		{`loadbalance weighted ` + path + ` {
+			reload often
+		}`, true, 0, "invalid duration"},
+		{`loadbalance weighted ` + path + ` {
+			refresh 10s
+		}`, true, 0, "unknown property"},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		w, err := parse(c)
+		if test.shouldErr {
+			if err == nil {
+				t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+			} else if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			continue
+		}
+		if w == nil {
+			t.Errorf("Test %d: Expected weights for input %s", i, test.input)
+			continue
+		}
+		if w.reload != test.expectedReload {
+			t.Errorf("Test %d: Expected reload %s, got %s", i, test.expectedReload, w.reload)
+		}
+		if w.names["www.example.org."]["192.0.2.1"] != 3 {
+			t.Errorf("Test %d: Expected weight 3 for 192.0.2.1, got %v", i, w.names)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package loadbalance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/shuffle"
+
+	"github.com/miekg/dns"
+)
+
+// weights holds the weights of the addresses of names, read from a weights file.
+type weights struct {
+	sync.RWMutex
+	path   string
+	reload time.Duration
+
+	// names maps a name to the weights of its addresses.
+	names map[string]map[string]int
+
+	// mtime and size are only read and modified by a single goroutine
+	mtime time.Time
+	size  int64
+
+	stop chan struct{}
+}
+
+// WeightedResponseWriter is a response writer that orders A and AAAA records by their weights, and shuffles MX
+// records.
+type WeightedResponseWriter struct {
+	dns.ResponseWriter
+	weights *weights
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (r *WeightedResponseWriter) WriteMsg(res *dns.Msg) error {
+	if res.Rcode != dns.RcodeSuccess {
+		return r.ResponseWriter.WriteMsg(res)
+	}
+
+	if res.Question[0].Qtype == dns.TypeAXFR || res.Question[0].Qtype == dns.TypeIXFR {
+		return r.ResponseWriter.WriteMsg(res)
+	}
+
+	// Leaving records out of a signed RRset would break its signature.
+	drain := !signed(res)
+	order := func(address []dns.RR) []dns.RR { return r.weights.order(address, drain) }
+	res.Answer = balance(res.Answer, order)
+	res.Ns = balance(res.Ns, order)
+	res.Extra = balance(res.Extra, order)
+
+	return r.ResponseWriter.WriteMsg(res)
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (r *WeightedResponseWriter) Write(buf []byte) (int, error) {
+	log.Warning("Weighted called with Write: not ordering records")
+	return r.ResponseWriter.Write(buf)
+}
+
+// order orders the address records by the weights of their addresses, per owner name. An address is picked for the
+// first position with a probability proportional to its weight, and so on for the next positions. With drain,
+// addresses with a weight of 0 are left out, unless all addresses of the name have a weight of 0; without it they're
+// put last. Addresses that aren't in the weights file get a weight of 1, names that aren't in it are shuffled round
+// robin.
+func (w *weights) order(address []dns.RR, drain bool) []dns.RR {
+	names := []string{}
+	byName := map[string][]dns.RR{}
+	for _, r := range address {
+		name := strings.ToLower(r.Header().Name)
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], r)
+	}
+
+	w.RLock()
+	defer w.RUnlock()
+
+	out := make([]dns.RR, 0, len(address))
+	for _, name := range names {
+		records := byName[name]
+		ws, ok := w.names[name]
+		if !ok {
+			roundRobinShuffle(records)
+			out = append(out, records...)
+			continue
+		}
+		out = append(out, weightedShuffle(records, ws, drain)...)
+	}
+	return out
+}
+
+// weightedShuffle returns records in a weighted random order. The ones with a weight of 0 are left out with drain, and
+// put last, in random order, without it.
+func weightedShuffle(records []dns.RR, ws map[string]int, drain bool) []dns.RR {
+	weighted := make([]dns.RR, 0, len(records))
+	weight := make([]int, 0, len(records))
+	drained := []dns.RR{}
+	for _, r := range records {
+		wt, ok := ws[addressOf(r)]
+		if !ok {
+			wt = 1
+		}
+		if wt == 0 {
+			drained = append(drained, r)
+			continue
+		}
+		weighted = append(weighted, r)
+		weight = append(weight, wt)
+	}
+	if len(weighted) == 0 {
+		// Everything is drained, there is nothing better to give.
+		return records
+	}
+
+	shuffle.Weighted(len(weighted), func(i int) int { return weight[i] }, func(i, j int) {
+		weighted[i], weighted[j] = weighted[j], weighted[i]
+		weight[i], weight[j] = weight[j], weight[i]
+	})
+	if drain {
+		return weighted
+	}
+	roundRobinShuffle(drained)
+	return append(weighted, drained...)
+}
+
+// signed returns true if res has RRSIGs or is a reply to a query with the DO bit set.
+func signed(res *dns.Msg) bool {
+	if o := res.IsEdns0(); o != nil && o.Do() {
+		return true
+	}
+	for _, section := range [][]dns.RR{res.Answer, res.Ns, res.Extra} {
+		for _, r := range section {
+			if r.Header().Rrtype == dns.TypeRRSIG {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addressOf returns the address of an A or AAAA record.
+func addressOf(r dns.RR) string {
+	switch x := r.(type) {
+	case *dns.A:
+		return x.A.String()
+	case *dns.AAAA:
+		return x.AAAA.String()
+	}
+	return ""
+}
+
+// readWeights reads the weights file if its size or modification time changed.
+func (w *weights) readWeights() error {
+	file, err := os.Open(w.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if w.mtime.Equal(stat.ModTime()) && w.size == stat.Size() {
+		return nil
+	}
+
+	names, err := parseWeights(file)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Read weights of %d names from %s", len(names), w.path)
+
+	w.Lock()
+	w.names = names
+	w.Unlock()
+	w.mtime = stat.ModTime()
+	w.size = stat.Size()
+	return nil
+}
+
+// parseWeights parses a weights file. A line with a single name starts the weights of that name, it's followed by
+// lines with an address and its weight.
+func parseWeights(r io.Reader) (map[string]map[string]int, error) {
+	names := map[string]map[string]int{}
+	var current map[string]int
+
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := scanner.Text()
+		if j := strings.Index(line, "#"); j >= 0 {
+			line = line[:j]
+		}
+		f := strings.Fields(line)
+		switch len(f) {
+		case 0:
+			continue
+		case 1:
+			if _, ok := dns.IsDomainName(f[0]); !ok {
+				return nil, fmt.Errorf("line %d: invalid name: %s", i, f[0])
+			}
+			name := plugin.Name(f[0]).Normalize()
+			if _, ok := names[name]; ok {
+				return nil, fmt.Errorf("line %d: name is defined more than once: %s", i, f[0])
+			}
+			current = map[string]int{}
+			names[name] = current
+		case 2:
+			if current == nil {
+				return nil, fmt.Errorf("line %d: address before the first name: %s", i, f[0])
+			}
+			ip := net.ParseIP(f[0])
+			if ip == nil {
+				return nil, fmt.Errorf("line %d: invalid address: %s", i, f[0])
+			}
+			wt, err := strconv.ParseUint(f[1], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid weight: %s", i, f[1])
+			}
+			current[ip.String()] = int(wt)
+		default:
+			return nil, fmt.Errorf("line %d: expected a name, or an address and a weight: %s", i, strings.TrimSpace(line))
+		}
+	}
+	return names, scanner.Err()
+}
+
+// periodicUpdate reads the weights file every reload interval, until stop is closed.
+func (w *weights) periodicUpdate() {
+	if w.reload == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.reload)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if err := w.readWeights(); err != nil {
+					log.Errorf("Failed to read weights file %s, keeping the current weights: %s", w.path, err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,265 @@
+package loadbalance
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const weightsExample = `
+# Canary rollout of www.
+www.example.org
+192.0.2.1   9
+192.0.2.2   1   # canary
+192.0.2.3   0   # drained
+2001:db8::1 5
+
+Drained.example.org.
+192.0.2.10 0
+192.0.2.11 0
+`
+
+func TestParseWeights(t *testing.T) {
+	names, err := parseWeights(strings.NewReader(weightsExample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]map[string]int{
+		"www.example.org.":     {"192.0.2.1": 9, "192.0.2.2": 1, "192.0.2.3": 0, "2001:db8::1": 5},
+		"drained.example.org.": {"192.0.2.10": 0, "192.0.2.11": 0},
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for name, ws := range expected {
+		for addr, w := range ws {
+			if got, ok := names[name][addr]; !ok || got != w {
+				t.Errorf("Expected weight %d for %s of %s, got %d", w, addr, name, got)
+			}
+		}
+	}
+
+	for _, invalid := range []string{
+		"192.0.2.1 1",
+		"www.example.org\n192.0.2.300 1",
+		"www.example.org\n192.0.2.1 -1",
+		"www.example.org\n192.0.2.1 256",
+		"www.example.org\n192.0.2.1 1 2",
+		"www.example.org\n192.0.2.1 1\nwww.example.org",
+		"www..example.org",
+	} {
+		if _, err := parseWeights(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestWeightedOrder(t *testing.T) {
+	names, err := parseWeights(strings.NewReader(weightsExample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &weights{names: names}
+
+	address := []dns.RR{
+		test.A("www.example.org. 300 IN A 192.0.2.1"),
+		test.A("www.example.org. 300 IN A 192.0.2.2"),
+		test.A("www.example.org. 300 IN A 192.0.2.3"),
+		test.A("www.example.org. 300 IN A 192.0.2.4"),
+	}
+	first := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		out := w.order(append([]dns.RR(nil), address...), true)
+		if len(out) != 3 {
+			t.Fatalf("Expected 3 records without the drained one, got %v", out)
+		}
+		for _, r := range out {
+			if r.(*dns.A).A.String() == "192.0.2.3" {
+				t.Fatalf("Expected the drained address to be left out, got %v", out)
+			}
+		}
+		first[out[0].(*dns.A).A.String()]++
+	}
+	// Weights are 9, 1 and 1 for the unlisted 192.0.2.4, out of 11.
+	if n := first["192.0.2.1"]; n < 1450 || n > 1800 {
+		t.Errorf("Expected 192.0.2.1 first about 1636 times, got %d", n)
+	}
+	for _, addr := range []string{"192.0.2.2", "192.0.2.4"} {
+		if n := first[addr]; n < 90 || n > 290 {
+			t.Errorf("Expected %s first about 182 times, got %d", addr, n)
+		}
+	}
+
+	// Without drain the drained address is kept, last.
+	for i := 0; i < 20; i++ {
+		out := w.order(append([]dns.RR(nil), address...), false)
+		if len(out) != 4 {
+			t.Fatalf("Expected 4 records with the drained one, got %v", out)
+		}
+		if out[3].(*dns.A).A.String() != "192.0.2.3" {
+			t.Fatalf("Expected the drained address last, got %v", out)
+		}
+	}
+
+	// All addresses are drained, the records are left alone.
+	drained := []dns.RR{
+		test.A("drained.example.org. 300 IN A 192.0.2.10"),
+		test.A("drained.example.org. 300 IN A 192.0.2.11"),
+	}
+	if out := w.order(drained, true); len(out) != 2 {
+		t.Errorf("Expected both drained records, got %v", out)
+	}
+
+	// Records of other names are kept, and grouped by name.
+	mixed := []dns.RR{
+		test.A("other.example.org. 300 IN A 192.0.2.20"),
+		test.A("WWW.example.org. 300 IN A 192.0.2.3"),
+		test.AAAA("www.example.org. 300 IN AAAA 2001:db8::1"),
+		test.A("other.example.org. 300 IN A 192.0.2.21"),
+	}
+	out := w.order(mixed, true)
+	if len(out) != 3 {
+		t.Fatalf("Expected 3 records, got %v", out)
+	}
+	if out[0].Header().Name != "other.example.org." || out[1].Header().Name != "other.example.org." {
+		t.Errorf("Expected the records of other.example.org. first, got %v", out)
+	}
+	if x, ok := out[2].(*dns.AAAA); !ok || x.AAAA.String() != "2001:db8::1" {
+		t.Errorf("Expected the AAAA record of www.example.org. last, got %v", out)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	names, err := parseWeights(strings.NewReader(weightsExample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wh := Weighted{Next: handler(), weights: &weights{names: names}}
+
+	m := new(dns.Msg)
+	m.SetQuestion("web.example.org.", dns.TypeA)
+	m.Answer = []dns.RR{
+		test.A("www.example.org. 300 IN A 192.0.2.3"),
+		test.A("www.example.org. 300 IN A 192.0.2.1"),
+		test.CNAME("web.example.org. 300 IN CNAME www.example.org."),
+		test.MX("www.example.org. 300 IN MX 10 mx.example.org."),
+	}
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	if _, err := wh.ServeDNS(context.TODO(), rec, m); err != nil {
+		t.Fatal(err)
+	}
+
+	answer := rec.Msg.Answer
+	if len(answer) != 3 {
+		t.Fatalf("Expected 3 records, got %v", answer)
+	}
+	if _, ok := answer[0].(*dns.CNAME); !ok {
+		t.Errorf("Expected the CNAME first, got %v", answer)
+	}
+	if x, ok := answer[1].(*dns.A); !ok || x.A.String() != "192.0.2.1" {
+		t.Errorf("Expected 192.0.2.1 after the CNAME, got %v", answer)
+	}
+	if _, ok := answer[2].(*dns.MX); !ok {
+		t.Errorf("Expected the MX last, got %v", answer)
+	}
+}
+
+func TestWeightedSigned(t *testing.T) {
+	names, err := parseWeights(strings.NewReader(weightsExample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wh := Weighted{Next: handler(), weights: &weights{names: names}}
+
+	rrsig := test.RRSIG("www.example.org. 300 IN RRSIG A 8 3 300 20200101000000 20190101000000 12345 example.org. c2lnbmF0dXJl")
+	tests := []struct {
+		do    bool
+		rrsig bool
+	}{
+		{true, false},
+		{false, true},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("www.example.org.", dns.TypeA)
+		if tc.do {
+			m.SetEdns0(4096, true)
+		}
+		m.Answer = []dns.RR{
+			test.A("www.example.org. 300 IN A 192.0.2.3"),
+			test.A("www.example.org. 300 IN A 192.0.2.1"),
+		}
+		if tc.rrsig {
+			m.Answer = append(m.Answer, rrsig)
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		if _, err := wh.ServeDNS(context.TODO(), rec, m); err != nil {
+			t.Fatal(err)
+		}
+
+		// The drained address must be kept, or the signature of the RRset is broken.
+		var addrs []string
+		for _, r := range rec.Msg.Answer {
+			if x, ok := r.(*dns.A); ok {
+				addrs = append(addrs, x.A.String())
+			}
+		}
+		if len(addrs) != 2 || addrs[1] != "192.0.2.3" {
+			t.Errorf("Test %d: expected 192.0.2.1 and the drained 192.0.2.3, got %v", i, addrs)
+		}
+	}
+}
+
+func TestReadWeights(t *testing.T) {
+	dir, err := ioutil.TempDir("", "loadbalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := &weights{path: filepath.Join(dir, "weights")}
+	write := func(content string, mtime time.Time) {
+		if err := ioutil.WriteFile(w.path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(w.path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	write("www.example.org\n192.0.2.1 1\n", now.Add(-time.Minute))
+	if err := w.readWeights(); err != nil {
+		t.Fatal(err)
+	}
+	if w.names["www.example.org."]["192.0.2.1"] != 1 {
+		t.Errorf("Expected weight 1, got %v", w.names)
+	}
+
+	// Ramp up.
+	write("www.example.org\n192.0.2.1 10\n", now)
+	if err := w.readWeights(); err != nil {
+		t.Fatal(err)
+	}
+	if w.names["www.example.org."]["192.0.2.1"] != 10 {
+		t.Errorf("Expected weight 10, got %v", w.names)
+	}
+
+	// An invalid file keeps the current weights.
+	write("www.example.org\n192.0.2.1 ten\n", now.Add(time.Minute))
+	if err := w.readWeights(); err == nil {
+		t.Errorf("Expected an error for an invalid weight")
+	}
+	if w.names["www.example.org."]["192.0.2.1"] != 10 {
+		t.Errorf("Expected weight 10, got %v", w.names)
+	}
+}
@@ -28,17 +28,29 @@ func ContextWithMetadata(ctx context.Context) context.Context {
 // ServeDNS implements the plugin.Handler interface.
 func (m *Metadata) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 
+	// When a view was selected with the metadata, it's already collected.
+	if ctx.Value(collectedKey{}) != m {
+		ctx = m.Collect(ctx, request.Request{W: w, Req: r})
+	}
+
+	rcode, err := plugin.NextOrFailure(m.Name(), m.Next, ctx, w, r)
+
+	return rcode, err
+}
+
+// Collect collects the metadata from all Providers and returns the new context. It
+// implements the dnsserver.MetadataCollector interface.
+func (m *Metadata) Collect(ctx context.Context, state request.Request) context.Context {
 	ctx = ContextWithMetadata(ctx)
 
-	state := request.Request{W: w, Req: r}
 	if plugin.Zones(m.Zones).Matches(state.Name()) != "" {
 		// Go through all Providers and collect metadata.
 		for _, p := range m.Providers {
 			ctx = p.Metadata(ctx, state)
 		}
 	}
-
-	rcode, err := plugin.NextOrFailure(m.Name(), m.Next, ctx, w, r)
-
-	return rcode, err
+	return context.WithValue(ctx, collectedKey{}, m)
 }
+
+// collectedKey is the key of the Metadata that collected the metadata in a context.
+type collectedKey struct{}
@@ -63,6 +63,38 @@ func TestMetadataServeDNS(t *testing.T) {
 	}
 }
 
+func TestMetadataCollectOnce(t *testing.T) {
+	calls := 0
+	next := &testHandler{}
+	m := &Metadata{
+		Zones:     []string{"."},
+		Providers: []Provider{countProvider{&calls}},
+		Next:      next,
+	}
+
+	state := request.Request{W: &test.ResponseWriter{}, Req: new(dns.Msg)}
+	ctx := m.Collect(context.TODO(), state)
+	m.ServeDNS(ctx, state.W, state.Req)
+	if calls != 1 {
+		t.Errorf("Expected the metadata to be collected once, got %d", calls)
+	}
+
+	// The metadata collected by another Metadata doesn't count.
+	other := &Metadata{Zones: []string{"."}}
+	m.ServeDNS(other.Collect(context.TODO(), state), state.W, state.Req)
+	if calls != 2 {
+		t.Errorf("Expected the metadata to be collected again, got %d collections", calls)
+	}
+}
+
+// countProvider counts the calls of its Metadata method.
+type countProvider struct{ calls *int }
+
+func (cp countProvider) Metadata(ctx context.Context, state request.Request) context.Context {
+	*cp.calls++
+	return ctx
+}
+
 func TestLabelFormat(t *testing.T) {
 	labels := []struct {
 		label   string
@@ -0,0 +1,278 @@
+// Package cloud keeps the zones hosted by a cloud DNS provider in memory and in sync with that provider. It is
+// shared by the plugins that serve such zones, which only need to implement a Provider.
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/upstream"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Provider is a cloud DNS service that hosts zones.
+type Provider interface {
+	// Records returns all records of the hosted zone with the provider specific id.
+	Records(ctx context.Context, id string) ([]dns.RR, error)
+}
+
+// MaxBackoff is the longest a hosted zone waits for its next sync after failures, unless the refresh interval is
+// longer.
+var MaxBackoff = 10 * time.Minute
+
+// Zones are the zones a plugin serves from a provider. Each origin can be served from several hosted zones, which
+// are tried in the order they were added.
+type Zones struct {
+	// Upstream is used for resolving CNAME targets outside of the zones.
+	Upstream *upstream.Upstream
+
+	plugin   string
+	provider Provider
+	refresh  time.Duration
+	log      clog.P
+
+	names []string
+	zones map[string][]*hostedZone
+
+	mu sync.RWMutex // protects the z, digest and synced fields of the hosted zones
+}
+
+type hostedZone struct {
+	id     string
+	origin string
+	z      *file.Zone
+	digest uint64 // digest of the records in z
+	synced bool
+}
+
+// New returns new Zones, for the plugin named plugin, that are synced from p every refresh.
+func New(plugin string, p Provider, refresh time.Duration) *Zones {
+	return &Zones{
+		Upstream: upstream.New(),
+		plugin:   plugin,
+		provider: p,
+		refresh:  refresh,
+		log:      clog.NewWithPlugin(plugin),
+		zones:    make(map[string][]*hostedZone),
+	}
+}
+
+// Add adds the hosted zone with id for origin. It must be called before Run.
+func (zs *Zones) Add(origin, id string) {
+	origin = dns.Fqdn(strings.ToLower(origin))
+	if _, ok := zs.zones[origin]; !ok {
+		zs.names = append(zs.names, origin)
+	}
+	zs.zones[origin] = append(zs.zones[origin], &hostedZone{id: id, origin: origin, z: file.NewZone(origin, "")})
+}
+
+// Names returns the origins of the zones.
+func (zs *Zones) Names() []string { return zs.names }
+
+// Run syncs all hosted zones and then keeps syncing them until ctx is canceled. It returns an error if the first
+// sync of any hosted zone fails.
+func (zs *Zones) Run(ctx context.Context) error {
+	if err := zs.syncAll(ctx); err != nil {
+		return err
+	}
+	for _, origin := range zs.names {
+		for _, hz := range zs.zones[origin] {
+			go zs.run(ctx, hz)
+		}
+	}
+	return nil
+}
+
+// run syncs hz every refresh until ctx is canceled. After failed syncs it backs off exponentially, up to MaxBackoff.
+func (zs *Zones) run(ctx context.Context, hz *hostedZone) {
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			zs.log.Infof("Breaking out of update loop for %s (%s): %v", hz.origin, hz.id, ctx.Err())
+			return
+		case <-time.After(backoff(zs.refresh, failures)):
+			if err := zs.sync(ctx, hz); err != nil {
+				if ctx.Err() != nil { // Don't log error if ctx expired.
+					continue
+				}
+				failures++
+				zs.log.Errorf("Failed to update zone %s (%s), retrying in %s: %v", hz.origin, hz.id, backoff(zs.refresh, failures), err)
+				continue
+			}
+			failures = 0
+		}
+	}
+}
+
+// backoff returns the time to wait before the next sync after failures consecutive failed syncs.
+func backoff(refresh time.Duration, failures int) time.Duration {
+	max := MaxBackoff
+	if refresh > max {
+		max = refresh
+	}
+	d := refresh
+	for i := 0; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// syncAll syncs all hosted zones concurrently. It returns an error if any of them failed, but waits for all of
+// them to complete first.
+func (zs *Zones) syncAll(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	for _, origin := range zs.names {
+		for _, hz := range zs.zones[origin] {
+			wg.Add(1)
+			go func(hz *hostedZone) {
+				defer wg.Done()
+				if err := zs.sync(ctx, hz); err != nil {
+					mu.Lock()
+					errs = append(errs, err.Error())
+					mu.Unlock()
+				}
+			}(hz)
+		}
+	}
+	wg.Wait()
+	if len(errs) != 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("errors updating zones: %v", errs)
+	}
+	return nil
+}
+
+// sync fetches the records of hz from the provider. Only if they changed since the last sync, the zone is rebuilt
+// and swapped in.
+func (zs *Zones) sync(ctx context.Context, hz *hostedZone) error {
+	start := time.Now()
+	rrs, err := zs.provider.Records(ctx, hz.id)
+	SyncDuration.WithLabelValues(zs.plugin, hz.origin, hz.id).Observe(time.Since(start).Seconds())
+	if err != nil {
+		SyncCount.WithLabelValues(zs.plugin, hz.origin, hz.id, "failure").Inc()
+		return fmt.Errorf("failed to list resource records for %s (%s) from %s: %v", hz.origin, hz.id, zs.plugin, err)
+	}
+	LastSync.WithLabelValues(zs.plugin, hz.origin, hz.id).Set(float64(time.Now().Unix()))
+
+	d := digest(rrs)
+	zs.mu.RLock()
+	unchanged := hz.synced && hz.digest == d
+	zs.mu.RUnlock()
+	if unchanged {
+		SyncCount.WithLabelValues(zs.plugin, hz.origin, hz.id, "unchanged").Inc()
+		return nil
+	}
+
+	z := file.NewZone(hz.origin, "")
+	z.Upstream = zs.Upstream
+	n := 0
+	for _, rr := range rrs {
+		if err := z.Insert(rr); err != nil {
+			zs.log.Debugf("Failed to insert record into zone %s (%s): %v", hz.origin, hz.id, err)
+			continue
+		}
+		n++
+	}
+
+	zs.mu.Lock()
+	hz.z, hz.digest, hz.synced = z, d, true
+	zs.mu.Unlock()
+
+	SyncCount.WithLabelValues(zs.plugin, hz.origin, hz.id, "changed").Inc()
+	RecordCount.WithLabelValues(zs.plugin, hz.origin, hz.id).Set(float64(n))
+	return nil
+}
+
+// digest returns a digest of rrs that doesn't depend on their order.
+func digest(rrs []dns.RR) uint64 {
+	s := make([]string, len(rrs))
+	for i, rr := range rrs {
+		s[i] = strings.ToLower(rr.String())
+	}
+	sort.Strings(s)
+
+	h := fnv.New64a()
+	for _, r := range s {
+		h.Write([]byte(r))
+		h.Write([]byte{'\n'})
+	}
+	return h.Sum64()
+}
+
+// Ready returns true when all hosted zones have been synced at least once.
+func (zs *Zones) Ready() bool {
+	zs.mu.RLock()
+	defer zs.mu.RUnlock()
+	for _, hzs := range zs.zones {
+		for _, hz := range hzs {
+			if !hz.synced {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ServeDNS answers r from the zones. Queries outside of the zones, and those that fall through, are passed to next.
+func (zs *Zones) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, next plugin.Handler, f fall.F) (int, error) {
+	state := request.Request{W: w, Req: r}
+	qname := state.Name()
+
+	zName := plugin.Zones(zs.names).Matches(qname)
+	if zName == "" {
+		return plugin.NextOrFailure(zs.plugin, next, ctx, w, r)
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	var result file.Result
+	for _, hz := range zs.zones[zName] {
+		zs.mu.RLock()
+		m.Answer, m.Ns, m.Extra, result = hz.z.Lookup(ctx, state, qname)
+		zs.mu.RUnlock()
+
+		// Take the answer if it's non-empty OR if there is another
+		// record type exists for this name (NODATA).
+		if len(m.Answer) != 0 || result == file.NoData {
+			break
+		}
+	}
+
+	if len(m.Answer) == 0 && result != file.NoData && f.Through(qname) {
+		return plugin.NextOrFailure(zs.plugin, next, ctx, w, r)
+	}
+
+	switch result {
+	case file.Success:
+	case file.NoData:
+	case file.NameError:
+		m.Rcode = dns.RcodeNameError
+	case file.Delegation:
+		m.Authoritative = false
+	case file.ServerFailure:
+		return dns.RcodeServerFailure, nil
+	}
+
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
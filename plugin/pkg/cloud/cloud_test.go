@@ -0,0 +1,175 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestSync(t *testing.T) {
+	p := NewFake()
+	p.Set("id1", "example.org. 300 IN A 1.2.3.4", "www.example.org. 300 IN A 1.2.3.5")
+	zs := New("test", p, time.Minute)
+	zs.Add("example.org.", "id1")
+	hz := zs.zones["example.org."][0]
+
+	if zs.Ready() {
+		t.Errorf("Expected zones not to be ready before the first sync")
+	}
+	if err := zs.sync(context.TODO(), hz); err != nil {
+		t.Fatal(err)
+	}
+	if !zs.Ready() {
+		t.Errorf("Expected zones to be ready after the first sync")
+	}
+	z := hz.z
+
+	// Same records in a different order don't change the zone.
+	p.Set("id1", "www.example.org. 300 IN A 1.2.3.5", "example.org. 300 IN A 1.2.3.4")
+	if err := zs.sync(context.TODO(), hz); err != nil {
+		t.Fatal(err)
+	}
+	if hz.z != z {
+		t.Errorf("Expected unchanged records not to rebuild the zone")
+	}
+
+	p.Set("id1", "example.org. 300 IN A 1.2.3.4")
+	if err := zs.sync(context.TODO(), hz); err != nil {
+		t.Fatal(err)
+	}
+	if hz.z == z {
+		t.Errorf("Expected changed records to rebuild the zone")
+	}
+
+	// A failed sync keeps serving the last records.
+	z = hz.z
+	p.Fail("id1", errors.New("throttled"))
+	if err := zs.sync(context.TODO(), hz); err == nil {
+		t.Errorf("Expected sync to fail")
+	}
+	if hz.z != z || !zs.Ready() {
+		t.Errorf("Expected a failed sync to keep the zone")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	defer func(d time.Duration) { MaxBackoff = d }(MaxBackoff)
+	MaxBackoff = 10 * time.Minute
+
+	tests := []struct {
+		refresh  time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{time.Minute, 0, time.Minute},
+		{time.Minute, 1, 2 * time.Minute},
+		{time.Minute, 3, 8 * time.Minute},
+		{time.Minute, 4, 10 * time.Minute},
+		{time.Minute, 100, 10 * time.Minute},
+		{time.Hour, 2, time.Hour},
+	}
+	for i, tc := range tests {
+		if got := backoff(tc.refresh, tc.failures); got != tc.expected {
+			t.Errorf("Test %d: expected backoff %s, got %s", i, tc.expected, got)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	p := NewFake()
+	p.Set("id1", soa, "example.org. 300 IN A 1.2.3.4")
+	zs := New("test", p, 10*time.Millisecond)
+	zs.Add("example.org.", "id1")
+	zs.Add("example.org.", "id2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := zs.Run(ctx); err == nil {
+		t.Fatalf("Expected error for a hosted zone that doesn't exist")
+	}
+
+	p.Set("id2", soa, "example.org. 300 IN A 1.2.3.4")
+	if err := zs.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Set("id1", soa, "example.org. 300 IN A 5.6.7.8")
+	for i := 0; ; i++ {
+		if answer(t, zs, "example.org.") == "5.6.7.8" {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected changed record to be synced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeDNS(t *testing.T) {
+	p := NewFake()
+	p.Set("id1", soa, "www.example.org. 300 IN A 1.2.3.4")
+	p.Set("id2", soa, "other.example.org. 300 IN A 5.6.7.8")
+	zs := New("test", p, time.Minute)
+	zs.Add("example.org.", "id1")
+	zs.Add("Example.Org", "id2")
+	if err := zs.Run(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if names := zs.Names(); len(names) != 1 || names[0] != "example.org." {
+		t.Errorf("Expected names [example.org.], got %v", names)
+	}
+
+	tests := []struct {
+		qname    string
+		expected string
+	}{
+		{"www.example.org.", "1.2.3.4"},
+		{"other.example.org.", "5.6.7.8"},
+		{"none.example.org.", "NXDOMAIN"},
+		{"example.net.", "REFUSED"}, // from the next plugin
+	}
+	for i, tc := range tests {
+		if got := answer(t, zs, tc.qname); got != tc.expected {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.expected, got)
+		}
+	}
+}
+
+const soa = "example.org. 300 IN SOA ns.example.org. admin.example.org. 1 7200 900 1209600 86400"
+
+// answer returns the address in the answer to an A query for qname, or the rcode if there is none.
+func answer(t *testing.T, zs *Zones, qname string) string {
+	m := new(dns.Msg)
+	m.SetQuestion(qname, dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	code, err := zs.ServeDNS(context.TODO(), rec, m, test.NextHandler(dns.RcodeRefused, nil), fall.Zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg == nil {
+		return dns.RcodeToString[code]
+	}
+	if len(rec.Msg.Answer) == 0 {
+		return dns.RcodeToString[rec.Msg.Rcode]
+	}
+	return rec.Msg.Answer[0].(*dns.A).A.String()
+}
+
+func TestTXT(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	txt := TXT("example.org.", 300, long)
+	if len(txt.Txt) != 2 || len(txt.Txt[0]) != 255 || txt.Txt[0]+txt.Txt[1] != long {
+		t.Errorf("Expected text to be split in strings of at most 255 bytes, got %v", txt.Txt)
+	}
+	if _, err := dns.NewRR(txt.String()); err != nil {
+		t.Errorf("Expected TXT record to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// Fake is a Provider that hosts zones in memory. It is meant for testing plugins that use Zones.
+type Fake struct {
+	mu      sync.Mutex
+	records map[string][]dns.RR
+	errs    map[string]error
+	calls   map[string]int
+}
+
+// NewFake returns a Fake without any hosted zones.
+func NewFake() *Fake {
+	return &Fake{records: map[string][]dns.RR{}, errs: map[string]error{}, calls: map[string]int{}}
+}
+
+// Set sets the records of the hosted zone with id, they are given in zone file format.
+func (f *Fake) Set(id string, rrs ...string) error {
+	records := make([]dns.RR, 0, len(rrs))
+	for _, s := range rrs {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			return err
+		}
+		records = append(records, rr)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records[id] = records
+	return nil
+}
+
+// Fail makes fetching the records of the hosted zone with id fail with err, until it is called again with a nil err.
+func (f *Fake) Fail(id string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[id] = err
+}
+
+// Calls returns how often the records of the hosted zone with id were fetched.
+func (f *Fake) Calls(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[id]
+}
+
+// Records implements Provider.
+func (f *Fake) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[id]++
+	if err := f.errs[id]; err != nil {
+		return nil, err
+	}
+	records, ok := f.records[id]
+	if !ok {
+		return nil, fmt.Errorf("hosted zone %q not found", id)
+	}
+	rrs := make([]dns.RR, len(records))
+	for i, rr := range records {
+		rrs[i] = dns.Copy(rr)
+	}
+	return rrs, nil
+}
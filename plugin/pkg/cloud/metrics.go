@@ -0,0 +1,40 @@
+package cloud
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics for the syncing of hosted zones. The plugins using Zones must register them.
+var (
+	// SyncCount is the number of syncs, by plugin, zone, hosted zone id and result.
+	SyncCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "cloud",
+		Name:      "syncs_total",
+		Help:      "Counter of syncs of hosted zones per plugin, zone, id and result (changed, unchanged or failure).",
+	}, []string{"plugin", "zone", "id", "result"})
+	// SyncDuration is the time it takes to fetch the records of a hosted zone.
+	SyncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "cloud",
+		Name:      "sync_duration_seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12), // from 10ms to ~20s
+		Help:      "Histogram of the time it takes to fetch the records of a hosted zone from the provider.",
+	}, []string{"plugin", "zone", "id"})
+	// LastSync is the time of the last successful sync of a hosted zone.
+	LastSync = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "cloud",
+		Name:      "last_sync_timestamp_seconds",
+		Help:      "The time of the last successful sync per hosted zone.",
+	}, []string{"plugin", "zone", "id"})
+	// RecordCount is the number of records in a hosted zone.
+	RecordCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "cloud",
+		Name:      "records",
+		Help:      "The number of records per hosted zone, as of the last sync that changed it.",
+	}, []string{"plugin", "zone", "id"})
+)
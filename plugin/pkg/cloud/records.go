@@ -0,0 +1,35 @@
+package cloud
+
+import (
+	"github.com/miekg/dns"
+)
+
+// SOA returns a SOA record for origin, for providers that don't expose the SOA record of a hosted zone. The SOA
+// names ns as the primary name server, and has minttl as its negative caching TTL.
+func SOA(origin, ns string, minttl uint32) *dns.SOA {
+	if ns == "" {
+		ns = "ns." + origin
+	}
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: origin, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: minttl},
+		Ns:      dns.Fqdn(ns),
+		Mbox:    "hostmaster." + origin,
+		Serial:  1,
+		Refresh: 7200,
+		Retry:   1800,
+		Expire:  1209600,
+		Minttl:  minttl,
+	}
+}
+
+// TXT returns a TXT record for name with text, split into strings of at most 255 bytes. Providers usually return
+// the text of TXT records unquoted, which can't be parsed with dns.NewRR.
+func TXT(name string, ttl uint32, text string) *dns.TXT {
+	txt := &dns.TXT{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: ttl}}
+	for len(text) > 255 {
+		txt.Txt = append(txt.Txt, text[:255])
+		text = text[255:]
+	}
+	txt.Txt = append(txt.Txt, text)
+	return txt
+}
@@ -0,0 +1,75 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/caddyserver/caddy"
+)
+
+// Options are the Corefile options shared by the plugins that use Zones.
+type Options struct {
+	Refresh time.Duration
+	Fall    fall.F
+}
+
+// Parse parses the current option of c if it's one of the shared options, refresh or fallthrough. It returns false
+// if it isn't, so the plugin can parse it itself.
+func (o *Options) Parse(c *caddy.Controller) (bool, error) {
+	switch c.Val() {
+	case "fallthrough":
+		o.Fall.SetZonesFromArgs(c.RemainingArgs())
+	case "refresh":
+		if !c.NextArg() {
+			return true, c.ArgErr()
+		}
+		d, err := ParseRefresh(c.Val())
+		if err != nil {
+			return true, c.Err(err.Error())
+		}
+		o.Refresh = d
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// ParseRefresh parses the refresh interval s, if it has no unit it is in seconds.
+func ParseRefresh(s string) (time.Duration, error) {
+	if _, err := strconv.Atoi(s); err == nil {
+		s += "s"
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse duration: '%v'", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("refresh interval must be greater than 0: %s", s)
+	}
+	return d, nil
+}
+
+// Run calls run to do the first sync of the zones of a plugin, and registers the metrics of the syncs when the
+// server starts. The context given to run is canceled when the server shuts down, which stops the syncing.
+func Run(c *caddy.Controller, run func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := run(ctx); err != nil {
+		cancel()
+		return err
+	}
+
+	c.OnStartup(func() error {
+		metrics.MustRegister(c, SyncCount, SyncDuration, LastSync, RecordCount)
+		return nil
+	})
+	c.OnShutdown(func() error {
+		cancel()
+		return nil
+	})
+	return nil
+}
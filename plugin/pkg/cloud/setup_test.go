@@ -0,0 +1,76 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		in          string
+		expected    time.Duration
+		expectedErr bool
+	}{
+		{"90", 90 * time.Second, false},
+		{"5m", 5 * time.Minute, false},
+		{"0", 0, true},
+		{"-1m", 0, true},
+		{"soon", 0, true},
+	}
+	for i, tc := range tests {
+		d, err := ParseRefresh(tc.in)
+		if (err != nil) != tc.expectedErr {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedErr, err)
+		}
+		if d != tc.expected {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.expected, d)
+		}
+	}
+}
+
+func TestOptionsParse(t *testing.T) {
+	tests := []struct {
+		body        string
+		parsed      bool
+		expectedErr bool
+	}{
+		{"refresh 5m", true, false},
+		{"refresh", true, true},
+		{"refresh never", true, true},
+		{"fallthrough example.org", true, false},
+		{"token secret", false, false},
+	}
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.body)
+		c.Next()
+		o := Options{Refresh: time.Minute}
+		parsed, err := o.Parse(c)
+		if parsed != tc.parsed {
+			t.Errorf("Test %d: expected parsed %t, got %t", i, tc.parsed, parsed)
+		}
+		if (err != nil) != tc.expectedErr {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedErr, err)
+		}
+	}
+
+	c := caddy.NewTestController("dns", "refresh 90")
+	c.Next()
+	o := Options{Refresh: time.Minute}
+	if _, err := o.Parse(c); err != nil || o.Refresh != 90*time.Second {
+		t.Errorf("Expected refresh of 90s, got %s, %v", o.Refresh, err)
+	}
+}
+
+func TestSetupRun(t *testing.T) {
+	c := caddy.NewTestController("dns", "")
+	if err := Run(c, func(context.Context) error { return errors.New("failed") }); err == nil {
+		t.Errorf("Expected the error of the first sync")
+	}
+	if err := Run(c, func(context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
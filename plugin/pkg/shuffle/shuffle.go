@@ -0,0 +1,25 @@
+// Package shuffle implements a weighted random shuffle.
+package shuffle
+
+import "math/rand"
+
+// Weighted shuffles n elements: an element is picked for the first position with a probability proportional to its
+// weight, and so on for the next positions. weight returns the weight of element i, which must be positive, and swap
+// swaps the elements with indexes i and j, like the swap of rand.Shuffle.
+func Weighted(n int, weight func(i int) int, swap func(i, j int)) {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += weight(i)
+	}
+	for i := 0; i < n; i++ {
+		r := rand.Intn(total)
+		for j := i; j < n; j++ {
+			if r < weight(j) {
+				swap(i, j)
+				break
+			}
+			r -= weight(j)
+		}
+		total -= weight(i)
+	}
+}
@@ -0,0 +1,33 @@
+package shuffle
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	weights := []int{1, 3}
+	first := 0
+	for i := 0; i < 1000; i++ {
+		ws := []int{weights[0], weights[1]}
+		Weighted(len(ws), func(i int) int { return ws[i] }, func(i, j int) { ws[i], ws[j] = ws[j], ws[i] })
+		if ws[0] == 3 {
+			first++
+		}
+	}
+	// The element with weight 3 should be first about 750 times.
+	if first < 650 || first > 850 {
+		t.Errorf("Expected the heaviest element first about 750 times out of 1000, got %d", first)
+	}
+
+	ws := []int{5, 1, 2, 4, 3}
+	Weighted(len(ws), func(i int) int { return ws[i] }, func(i, j int) { ws[i], ws[j] = ws[j], ws[i] })
+	sort.Ints(ws)
+	for i, w := range ws {
+		if w != i+1 {
+			t.Fatalf("Expected a permutation of the elements, got %v", ws)
+		}
+	}
+
+	Weighted(0, func(i int) int { return 1 }, func(i, j int) {})
+}
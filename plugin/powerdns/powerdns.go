@@ -0,0 +1,140 @@
+// Package powerdns implements a plugin that returns resource records
+// from the HTTP API of a PowerDNS authoritative server.
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
+)
+
+// PowerDNS is a plugin that returns RR from a PowerDNS server.
+type PowerDNS struct {
+	Next plugin.Handler
+	Fall fall.F
+
+	endpoint string // URL of the API, without the /api/v1 path
+	apiKey   string
+	serverID string
+	client   *http.Client
+	zones    *cloud.Zones
+}
+
+// New validates that each of the zones exists on the PowerDNS server serverID, whose API is at endpoint, and returns
+// a new *PowerDNS that serves them. The zones are synced every refresh.
+func New(ctx context.Context, endpoint, apiKey, serverID string, zones []string, refresh time.Duration) (*PowerDNS, error) {
+	h := &PowerDNS{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		apiKey:   apiKey,
+		serverID: serverID,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	h.zones = cloud.New("powerdns", h, refresh)
+	for _, name := range zones {
+		name = dns.Fqdn(strings.ToLower(name))
+		// Get the zone without its records, just to see it exists.
+		if err := h.get(ctx, name, "?rrsets=false", &zone{}); err != nil {
+			return nil, fmt.Errorf("failed to get zone %s: %v", name, err)
+		}
+		h.zones.Add(name, name)
+	}
+	return h, nil
+}
+
+// Run executes first update, spins up an update forever-loop.
+// Returns error if first update fails.
+func (h *PowerDNS) Run(ctx context.Context) error { return h.zones.Run(ctx) }
+
+// ServeDNS implements the plugin.Handler interface.
+func (h *PowerDNS) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return h.zones.ServeDNS(ctx, w, r, h.Next, h.Fall)
+}
+
+// zone is a zone as it is returned by the PowerDNS API.
+type zone struct {
+	Name   string  `json:"name"`
+	Serial uint32  `json:"serial"`
+	RRsets []rrset `json:"rrsets"`
+}
+
+type rrset struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TTL     uint32 `json:"ttl"`
+	Records []struct {
+		Content  string `json:"content"`
+		Disabled bool   `json:"disabled"`
+	} `json:"records"`
+}
+
+// Records implements cloud.Provider, it lists the records of the zone with id, which is its name. Disabled records
+// are skipped.
+func (h *PowerDNS) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	z := &zone{}
+	if err := h.get(ctx, id, "", z); err != nil {
+		return nil, err
+	}
+
+	var rrs []dns.RR
+	for _, set := range z.RRsets {
+		for _, r := range set.Records {
+			if r.Disabled {
+				continue
+			}
+			// The content of records is in presentation format.
+			rfc1035 := fmt.Sprintf("%s %d IN %s %s", set.Name, set.TTL, set.Type, r.Content)
+			rr, err := dns.NewRR(rfc1035)
+			if err != nil || rr == nil {
+				// Maybe unsupported record type, such as ALIAS or LUA. Log and carry on.
+				log.Warningf("Failed to parse resource record %q: %v", rfc1035, err)
+				continue
+			}
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs, nil
+}
+
+// get gets the zone with id from the API, with the query string query, and decodes it into z.
+func (h *PowerDNS) get(ctx context.Context, id, query string, z *zone) error {
+	u := h.endpoint + "/api/v1/servers/" + url.PathEscape(h.serverID) + "/zones/" + url.PathEscape(id) + query
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-API-Key", h.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := struct {
+			Error string `json:"error"`
+		}{}
+		json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&e)
+		return fmt.Errorf("%s: %s", resp.Status, e.Error)
+	}
+	err = json.NewDecoder(resp.Body).Decode(z)
+	io.Copy(ioutil.Discard, resp.Body)
+	return err
+}
+
+// Name implements the Handler interface.
+func (h *PowerDNS) Name() string { return "powerdns" }
@@ -0,0 +1,153 @@
+package powerdns
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// fakePowerDNS is a stand-in for the HTTP API of a PowerDNS server, it serves the zones it is given.
+type fakePowerDNS struct {
+	apiKey   string
+	serverID string
+	zones    map[string]string // zone file by zone name
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Header.Get("X-API-Key") != f.apiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+		return
+	}
+	prefix := "/api/v1/servers/" + f.serverID + "/zones/"
+	zf, ok := f.zones[strings.TrimPrefix(r.URL.Path, prefix)]
+	if !strings.HasPrefix(r.URL.Path, prefix) || !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Could not find domain"})
+		return
+	}
+
+	z := zone{Name: strings.TrimPrefix(r.URL.Path, prefix), Serial: 1}
+	if r.URL.Query().Get("rrsets") != "false" {
+		for _, line := range strings.Split(strings.TrimSpace(zf), "\n") {
+			// NAME TTL TYPE CONTENT, where a leading '-' marks a disabled record.
+			fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+			disabled := strings.HasPrefix(fields[0], "-")
+			set := rrset{Name: strings.TrimPrefix(fields[0], "-"), Type: fields[2]}
+			json.Unmarshal([]byte(fields[1]), &set.TTL)
+			set.Records = append(set.Records, struct {
+				Content  string `json:"content"`
+				Disabled bool   `json:"disabled"`
+			}{fields[3], disabled})
+			z.RRsets = append(z.RRsets, set)
+		}
+	}
+	json.NewEncoder(w).Encode(z)
+}
+
+func newFakePowerDNS() *httptest.Server {
+	return httptest.NewServer(&fakePowerDNS{
+		apiKey:   "secret",
+		serverID: "localhost",
+		zones: map[string]string{
+			"example.org.": `
+example.org. 3600 SOA ns1.example.org. hostmaster.example.org. 2020010101 10800 3600 604800 3600
+example.org. 3600 NS ns1.example.org.
+example.org. 300 A 1.2.3.4
+www.example.org. 300 A 1.2.3.5
+-disabled.example.org. 300 A 1.2.3.6
+alias.example.org. 300 CNAME example.org.
+example.org. 300 MX 10 mail.example.org.
+txt.example.org. 300 TXT "v=spf1 -all"
+_sip._tcp.example.org. 300 SRV 10 20 5060 sip.example.org.
+lua.example.org. 300 LUA A "ifportup(443, {'192.0.2.1'})"`,
+		},
+	})
+}
+
+func TestPowerDNS(t *testing.T) {
+	s := newFakePowerDNS()
+	defer s.Close()
+	ctx := context.Background()
+
+	if _, err := New(ctx, s.URL, "secret", "localhost", []string{"example.com"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a zone that doesn't exist")
+	}
+	if _, err := New(ctx, s.URL, "wrong", "localhost", []string{"example.org"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a wrong API key")
+	}
+	if _, err := New(ctx, s.URL, "secret", "other", []string{"example.org"}, time.Minute); err == nil {
+		t.Errorf("Expected error for a server that doesn't exist")
+	}
+
+	h, err := New(ctx, s.URL+"/", "secret", "localhost", []string{"example.org"}, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create PowerDNS: %v", err)
+	}
+	h.Fall = fall.Zero
+	h.Fall.SetZonesFromArgs([]string{"example.org."})
+	h.Next = test.NextHandler(dns.RcodeRefused, nil)
+	if err := h.Run(ctx); err != nil {
+		t.Fatalf("Failed to initialize PowerDNS: %v", err)
+	}
+	if !h.Ready() {
+		t.Errorf("Expected plugin to be ready")
+	}
+
+	tests := []struct {
+		qname      string
+		qtype      uint16
+		wantRcode  int
+		wantAnswer []string
+	}{
+		{"example.org.", dns.TypeA, dns.RcodeSuccess, []string{"example.org.	300	IN	A	1.2.3.4"}},
+		{"www.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"www.example.org.	300	IN	A	1.2.3.5"}},
+		{"alias.example.org.", dns.TypeA, dns.RcodeSuccess, []string{"alias.example.org.	300	IN	CNAME	example.org.", "example.org.	300	IN	A	1.2.3.4"}},
+		{"example.org.", dns.TypeMX, dns.RcodeSuccess, []string{"example.org.	300	IN	MX	10 mail.example.org."}},
+		{"txt.example.org.", dns.TypeTXT, dns.RcodeSuccess, []string{"txt.example.org.	300	IN	TXT	\"v=spf1 -all\""}},
+		{"_sip._tcp.example.org.", dns.TypeSRV, dns.RcodeSuccess, []string{"_sip._tcp.example.org.	300	IN	SRV	10 20 5060 sip.example.org."}},
+		{"example.org.", dns.TypeSOA, dns.RcodeSuccess, []string{"example.org.	3600	IN	SOA	ns1.example.org. hostmaster.example.org. 2020010101 10800 3600 604800 3600"}},
+		{"disabled.example.org.", dns.TypeA, dns.RcodeRefused, nil}, // fallthrough
+		{"lua.example.org.", dns.TypeA, dns.RcodeRefused, nil},
+		{"example.net.", dns.TypeA, dns.RcodeRefused, nil},
+	}
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		code, err := h.ServeDNS(ctx, rec, req)
+		if err != nil {
+			t.Fatalf("Test %d: expected no error, got %v", i, err)
+		}
+		if rec.Msg != nil {
+			code = rec.Msg.Rcode
+		}
+		if code != tc.wantRcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.wantRcode], dns.RcodeToString[code])
+			continue
+		}
+		if rec.Msg == nil {
+			continue
+		}
+		if len(rec.Msg.Answer) != len(tc.wantAnswer) {
+			t.Errorf("Test %d: expected %d answers, got %v", i, len(tc.wantAnswer), rec.Msg.Answer)
+			continue
+		}
+		for j, rr := range rec.Msg.Answer {
+			if rr.String() != tc.wantAnswer[j] {
+				t.Errorf("Test %d: expected answer %q, got %q", i, tc.wantAnswer[j], rr.String())
+			}
+		}
+	}
+}
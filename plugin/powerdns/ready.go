@@ -0,0 +1,4 @@
+package powerdns
+
+// Ready implements the ready.Readiness interface.
+func (h *PowerDNS) Ready() bool { return h.zones.Ready() }
@@ -0,0 +1,77 @@
+package powerdns
+
+import (
+	"context"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("powerdns")
+
+func init() { plugin.Register("powerdns", setup) }
+
+func setup(c *caddy.Controller) error {
+	for c.Next() {
+		zones := c.RemainingArgs()
+		if len(zones) == 0 {
+			return plugin.Error("powerdns", c.ArgErr())
+		}
+		var (
+			endpoint, apiKey string
+			serverID         = "localhost"
+			opts             = cloud.Options{Refresh: time.Minute}
+		)
+
+		for c.NextBlock() {
+			if ok, err := opts.Parse(c); ok {
+				if err != nil {
+					return plugin.Error("powerdns", err)
+				}
+				continue
+			}
+			switch c.Val() {
+			case "endpoint":
+				if !c.NextArg() {
+					return plugin.Error("powerdns", c.ArgErr())
+				}
+				endpoint = c.Val()
+			case "api_key":
+				if !c.NextArg() {
+					return plugin.Error("powerdns", c.ArgErr())
+				}
+				apiKey = c.Val()
+			case "server_id":
+				if !c.NextArg() {
+					return plugin.Error("powerdns", c.ArgErr())
+				}
+				serverID = c.Val()
+			default:
+				return plugin.Error("powerdns", c.Errf("unknown property '%s'", c.Val()))
+			}
+		}
+		if endpoint == "" {
+			return plugin.Error("powerdns", c.Err("no endpoint given"))
+		}
+
+		h, err := New(context.Background(), endpoint, apiKey, serverID, zones, opts.Refresh)
+		if err != nil {
+			return plugin.Error("powerdns", c.Errf("failed to create PowerDNS plugin: %v", err))
+		}
+		h.Fall = opts.Fall
+		if err := cloud.Run(c, h.Run); err != nil {
+			return plugin.Error("powerdns", c.Errf("failed to initialize PowerDNS plugin: %v", err))
+		}
+
+		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+			h.Next = next
+			return h
+		})
+	}
+	return nil
+}
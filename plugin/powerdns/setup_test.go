@@ -0,0 +1,67 @@
+package powerdns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetupPowerDNS(t *testing.T) {
+	s := newFakePowerDNS()
+	defer s.Close()
+
+	tests := []struct {
+		body          string
+		expectedError bool
+	}{
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key secret
+}`, false},
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key secret
+    server_id localhost
+    fallthrough
+    refresh 90
+}`, false},
+		{`powerdns {
+    endpoint ENDPOINT
+    api_key secret
+}`, true},
+		{`powerdns example.org {
+    api_key secret
+}`, true},
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key wrong
+}`, true},
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key secret
+    server_id other
+}`, true},
+		{`powerdns example.com {
+    endpoint ENDPOINT
+    api_key secret
+}`, true},
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key secret
+    refresh 0
+}`, true},
+		{`powerdns example.org {
+    endpoint ENDPOINT
+    api_key secret
+    wat
+}`, true},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", strings.Replace(tc.body, "ENDPOINT", s.URL, 1))
+		if err := setup(c); (err == nil) == tc.expectedError {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedError, err)
+		}
+	}
+}
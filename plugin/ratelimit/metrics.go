@@ -0,0 +1,24 @@
+package ratelimit
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// DroppedCount is the number of queries and responses dropped because they exceeded the rate limit.
+	DroppedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "ratelimit",
+		Name:      "dropped_total",
+		Help:      "Counter of queries and responses dropped because of rate limiting.",
+	}, []string{"server", "type"})
+	// SlippedCount is the number of rate limited queries and responses answered with a truncated response.
+	SlippedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: "ratelimit",
+		Name:      "slipped_total",
+		Help:      "Counter of rate limited queries and responses answered with a truncated response.",
+	}, []string{"server", "type"})
+)
@@ -0,0 +1,214 @@
+// Package ratelimit implements per client query rate limiting and response rate limiting (RRL).
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	"github.com/coredns/coredns/request"
+
+	"github.com/infobloxopen/go-trees/iptree"
+	"github.com/miekg/dns"
+)
+
+// RateLimit limits the rate of queries from, and responses to, client networks.
+type RateLimit struct {
+	Next  plugin.Handler
+	Zones []string
+
+	// Rates in queries or responses per second for each kind of response, 0 disables the limit.
+	Rates map[string]float64
+	// Window is the time in seconds over which rates are tracked.
+	Window time.Duration
+	// Slip is the leak rate: every Slip'th rate limited response is answered with an empty truncated
+	// response instead of being dropped, so legitimate clients can retry over TCP. 0 drops all of them.
+	Slip int
+	// IPv4Mask and IPv6Mask group clients into networks that share a bucket.
+	IPv4Mask net.IPMask
+	IPv6Mask net.IPMask
+	// Exempt holds the networks that are never rate limited.
+	Exempt *iptree.Tree
+
+	table *table
+	now   func() time.Time
+}
+
+// The kinds of responses, each has its own rate.
+const (
+	kindQuery    = "query"
+	kindResponse = "response"
+	kindNodata   = "nodata"
+	kindNXDomain = "nxdomain"
+	kindReferral = "referral"
+	kindError    = "error"
+)
+
+// New returns a RateLimit with the defaults: a window of 15 seconds, a slip of 2, and clients
+// grouped by /24 for IPv4 and /56 for IPv6. No rates are set.
+func New() *RateLimit {
+	return &RateLimit{
+		Rates:    map[string]float64{},
+		Window:   defaultWindow,
+		Slip:     defaultSlip,
+		IPv4Mask: net.CIDRMask(defaultIPv4Prefix, 32),
+		IPv6Mask: net.CIDRMask(defaultIPv6Prefix, 128),
+		Exempt:   iptree.NewTree(),
+		table:    newTable(defaultMaxTableSize),
+		now:      time.Now,
+	}
+}
+
+const (
+	defaultWindow       = 15 * time.Second
+	defaultSlip         = 2
+	defaultIPv4Prefix   = 24
+	defaultIPv6Prefix   = 56
+	defaultMaxTableSize = 100000
+)
+
+// ServeDNS implements the plugin.Handler interface.
+func (rl *RateLimit) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(rl.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	ip := net.ParseIP(state.IP())
+	if ip == nil {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+	if _, exempt := rl.Exempt.GetByIP(ip); exempt {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	udp := state.Proto() == "udp"
+	server := metrics.WithServer(ctx)
+	prefix := rl.prefix(ip)
+
+	if rate := rl.Rates[kindQuery]; rate > 0 {
+		if n := rl.table.debit(prefix+"|"+kindQuery, rate, rl.Window, rl.now()); n > 0 {
+			if udp && rl.slip(n) {
+				SlippedCount.WithLabelValues(server, kindQuery).Inc()
+				w.WriteMsg(truncated(r))
+				return dns.RcodeSuccess, nil
+			}
+			DroppedCount.WithLabelValues(server, kindQuery).Inc()
+			// Pretend we've written the response, so the server doesn't.
+			return dns.RcodeSuccess, nil
+		}
+	}
+
+	// Response rate limiting is only useful over UDP, as TCP source addresses can't be spoofed.
+	if !udp {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	rw := &ResponseWriter{ResponseWriter: w, rl: rl, prefix: prefix, server: server, request: r}
+	return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, rw, r)
+}
+
+// Name implements the plugin.Handler interface.
+func (rl *RateLimit) Name() string { return "ratelimit" }
+
+// prefix returns the network ip is in, as determined by the configured prefix lengths.
+func (rl *RateLimit) prefix(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(rl.IPv4Mask).String()
+	}
+	return ip.Mask(rl.IPv6Mask).String()
+}
+
+// slip returns true if the n'th rate limited response should be answered with a truncated response.
+func (rl *RateLimit) slip(n int) bool { return rl.Slip > 0 && n%rl.Slip == 0 }
+
+// ResponseWriter is a response writer that drops, or truncates, responses that exceed the configured rates.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	rl      *RateLimit
+	prefix  string
+	server  string
+	request *dns.Msg
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) WriteMsg(res *dns.Msg) error {
+	kind, name := classify(res)
+	rate := w.rl.Rates[kind]
+	if rate <= 0 {
+		return w.ResponseWriter.WriteMsg(res)
+	}
+
+	key := w.prefix + "|" + kind + "|" + name
+	n := w.rl.table.debit(key, rate, w.rl.Window, w.rl.now())
+	if n == 0 {
+		return w.ResponseWriter.WriteMsg(res)
+	}
+	if w.rl.slip(n) {
+		SlippedCount.WithLabelValues(w.server, kind).Inc()
+		return w.ResponseWriter.WriteMsg(truncated(w.request))
+	}
+	DroppedCount.WithLabelValues(w.server, kind).Inc()
+	return nil
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) Write(buf []byte) (int, error) {
+	log.Warning("RateLimit called with Write: not rate limiting reply")
+	return w.ResponseWriter.Write(buf)
+}
+
+// classify returns the kind of response res is and the name that identifies its bucket. Like BIND, positive
+// responses are tracked per query name and type, negative responses per zone (the owner name of the SOA record),
+// referrals per delegation and errors are tracked per client network only.
+func classify(res *dns.Msg) (kind, name string) {
+	qname, qtype := ".", ""
+	if len(res.Question) > 0 {
+		qname = strings.ToLower(res.Question[0].Name)
+		qtype = strconv.Itoa(int(res.Question[0].Qtype))
+	}
+
+	switch res.Rcode {
+	case dns.RcodeSuccess:
+		if len(res.Answer) > 0 {
+			return kindResponse, qname + "/" + qtype
+		}
+		if soa := owner(res.Ns, dns.TypeSOA); soa != "" {
+			return kindNodata, soa
+		}
+		if ns := owner(res.Ns, dns.TypeNS); ns != "" {
+			return kindReferral, ns
+		}
+		return kindNodata, qname
+	case dns.RcodeNameError:
+		if soa := owner(res.Ns, dns.TypeSOA); soa != "" {
+			return kindNXDomain, soa
+		}
+		return kindNXDomain, qname
+	}
+	return kindError, ""
+}
+
+// owner returns the lowercased owner name of the first record of type rrtype in rrs.
+func owner(rrs []dns.RR, rrtype uint16) string {
+	for _, rr := range rrs {
+		if rr.Header().Rrtype == rrtype {
+			return strings.ToLower(rr.Header().Name)
+		}
+	}
+	return ""
+}
+
+// truncated returns an empty response to r with the TC bit set.
+func truncated(r *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Truncated = true
+	return m
+}
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+// answer answers every query with an A record, except for nx.example.org which is NXDOMAIN.
+func answer() plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		if r.Question[0].Name == "nx.example.org." {
+			m.Rcode = dns.RcodeNameError
+			m.Ns = []dns.RR{test.SOA("example.org. 300 IN SOA ns.example.org. admin.example.org. 1 3600 600 86400 300")}
+		} else {
+			m.Answer = []dns.RR{test.A(r.Question[0].Name + " 300 IN A 127.0.0.1")}
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+}
+
+func newTestRateLimit(now *time.Time) *RateLimit {
+	rl := New()
+	rl.Zones = []string{"example.org."}
+	rl.Next = answer()
+	rl.now = func() time.Time { return *now }
+	return rl
+}
+
+// query sends a query for qname from ip and returns the outcome: "ok", "slip" or "drop".
+func query(rl *RateLimit, qname, ip string, tcp bool) string {
+	m := new(dns.Msg)
+	m.SetQuestion(qname, dns.TypeA)
+	rec := dnstest.NewRecorder(&test.ResponseWriter{RemoteIP: ip, TCP: tcp})
+	rl.ServeDNS(context.TODO(), rec, m)
+	switch {
+	case rec.Msg == nil:
+		return "drop"
+	case rec.Msg.Truncated:
+		return "slip"
+	}
+	return "ok"
+}
+
+func TestResponseRateLimit(t *testing.T) {
+	now := time.Now()
+	rl := newTestRateLimit(&now)
+	rl.Rates[kindResponse] = 2
+	rl.Rates[kindNXDomain] = 1
+
+	expect := func(qname, ip string, want ...string) {
+		t.Helper()
+		for i, w := range want {
+			if got := query(rl, qname, ip, false); got != w {
+				t.Errorf("Query %d for %s from %s: expected %s, got %s", i, qname, ip, w, got)
+			}
+		}
+	}
+
+	expect("a.example.org.", "10.0.0.1", "ok", "ok", "drop", "slip", "drop", "slip")
+	// Same network, same bucket.
+	expect("a.example.org.", "10.0.0.2", "drop", "slip")
+	// Different name, or different network, is a different bucket.
+	expect("b.example.org.", "10.0.0.1", "ok", "ok", "drop")
+	expect("a.example.org.", "10.0.1.1", "ok", "ok", "drop")
+	// NXDOMAINs are counted per zone.
+	expect("nx.example.org.", "10.0.0.1", "ok", "drop", "slip")
+	// Not in our zones.
+	expect("a.example.net.", "10.0.0.1", "ok", "ok", "ok", "ok")
+
+	// TCP is never response rate limited.
+	if got := query(rl, "a.example.org.", "10.0.0.1", true); got != "ok" {
+		t.Errorf("Expected TCP query to be answered, got %s", got)
+	}
+
+	// The bucket is at -6 and is credited with 2 tokens per second.
+	now = now.Add(2 * time.Second)
+	expect("a.example.org.", "10.0.0.1", "drop")
+	now = now.Add(4 * time.Second)
+	expect("a.example.org.", "10.0.0.1", "ok")
+
+	_, exempt, _ := net.ParseCIDR("10.0.0.0/24")
+	rl.Exempt.InplaceInsertNet(exempt, struct{}{})
+	expect("b.example.org.", "10.0.0.1", "ok", "ok", "ok", "ok")
+}
+
+func TestQueryRateLimit(t *testing.T) {
+	now := time.Now()
+	rl := newTestRateLimit(&now)
+	rl.Rates[kindQuery] = 3
+	rl.Slip = 0
+
+	for i, want := range []string{"ok", "ok", "ok", "drop", "drop"} {
+		if got := query(rl, "a.example.org.", "2001:db8::1", false); got != want {
+			t.Errorf("Query %d: expected %s, got %s", i, want, got)
+		}
+	}
+	// Queries are limited regardless of name or transport.
+	if got := query(rl, "b.example.org.", "2001:db8::2", true); got != "drop" {
+		t.Errorf("Expected query to be dropped, got %s", got)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	soa := test.SOA("example.org. 300 IN SOA ns.example.org. admin.example.org. 1 3600 600 86400 300")
+	ns := test.NS("sub.example.org. 300 IN NS ns.sub.example.org.")
+	tests := []struct {
+		rcode  int
+		answer []dns.RR
+		ns     []dns.RR
+		kind   string
+		name   string
+	}{
+		{dns.RcodeSuccess, []dns.RR{test.A("a.example.org. 300 IN A 127.0.0.1")}, nil, kindResponse, "a.example.org./1"},
+		{dns.RcodeSuccess, nil, []dns.RR{soa}, kindNodata, "example.org."},
+		{dns.RcodeSuccess, nil, []dns.RR{ns}, kindReferral, "sub.example.org."},
+		{dns.RcodeNameError, nil, []dns.RR{soa}, kindNXDomain, "example.org."},
+		{dns.RcodeNameError, nil, nil, kindNXDomain, "a.example.org."},
+		{dns.RcodeServerFailure, nil, nil, kindError, ""},
+		{dns.RcodeRefused, nil, nil, kindError, ""},
+	}
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("A.example.org.", dns.TypeA)
+		m.Rcode, m.Answer, m.Ns = tc.rcode, tc.answer, tc.ns
+		kind, name := classify(m)
+		if kind != tc.kind || name != tc.name {
+			t.Errorf("Test %d: expected %s %q, got %s %q", i, tc.kind, tc.name, kind, name)
+		}
+	}
+}
+
+func TestTableMakeRoom(t *testing.T) {
+	tb := newTable(2)
+	now := time.Now()
+	tb.debit("a", 1, time.Second, now)
+	tb.debit("b", 1, time.Second, now)
+	tb.debit("c", 1, time.Second, now)
+	if x := tb.Len(); x != 2 {
+		t.Errorf("Expected %d buckets, got %d", 2, x)
+	}
+}
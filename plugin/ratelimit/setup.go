@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/metrics"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("ratelimit")
+
+func init() { plugin.Register("ratelimit", setup) }
+
+func setup(c *caddy.Controller) error {
+	rl, err := parse(c)
+	if err != nil {
+		return plugin.Error("ratelimit", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		rl.Next = next
+		return rl
+	})
+
+	c.OnStartup(func() error {
+		metrics.MustRegister(c, DroppedCount, SlippedCount)
+		return nil
+	})
+	return nil
+}
+
+// rateOptions maps the rate options to the kind of response they apply to.
+var rateOptions = map[string]string{
+	"queries-per-second":   kindQuery,
+	"responses-per-second": kindResponse,
+	"nodata-per-second":    kindNodata,
+	"nxdomains-per-second": kindNXDomain,
+	"referrals-per-second": kindReferral,
+	"errors-per-second":    kindError,
+}
+
+func parse(c *caddy.Controller) (*RateLimit, error) {
+	rl := New()
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		rl.Zones = c.RemainingArgs()
+		if len(rl.Zones) == 0 {
+			rl.Zones = make([]string, len(c.ServerBlockKeys))
+			copy(rl.Zones, c.ServerBlockKeys)
+		}
+		for j := range rl.Zones {
+			rl.Zones[j] = plugin.Host(rl.Zones[j]).Normalize()
+		}
+
+		for c.NextBlock() {
+			opt := c.Val()
+			switch opt {
+			case "queries-per-second", "responses-per-second", "nodata-per-second", "nxdomains-per-second",
+				"referrals-per-second", "errors-per-second":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				rate, err := strconv.ParseFloat(args[0], 64)
+				if err != nil || rate < 0 {
+					return nil, c.Errf("invalid rate %q for %s", args[0], opt)
+				}
+				rl.Rates[rateOptions[opt]] = rate
+
+			case "window":
+				n, err := positiveInt(c, opt)
+				if err != nil {
+					return nil, err
+				}
+				rl.Window = time.Duration(n) * time.Second
+
+			case "slip":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 0 {
+					return nil, c.Errf("invalid slip %q", args[0])
+				}
+				rl.Slip = n
+
+			case "ipv4-prefix-length", "ipv6-prefix-length":
+				bits := 32
+				if opt == "ipv6-prefix-length" {
+					bits = 128
+				}
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 0 || n > bits {
+					return nil, c.Errf("invalid prefix length %q for %s", args[0], opt)
+				}
+				if bits == 32 {
+					rl.IPv4Mask = net.CIDRMask(n, bits)
+				} else {
+					rl.IPv6Mask = net.CIDRMask(n, bits)
+				}
+
+			case "exempt":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					_, source, err := net.ParseCIDR(normalize(a))
+					if err != nil {
+						return nil, c.Errf("illegal CIDR notation %q", a)
+					}
+					rl.Exempt.InplaceInsertNet(source, struct{}{})
+				}
+
+			case "max-table-size":
+				n, err := positiveInt(c, opt)
+				if err != nil {
+					return nil, err
+				}
+				rl.table = newTable(n)
+
+			default:
+				return nil, c.Errf("unknown property '%s'", opt)
+			}
+		}
+	}
+
+	// Like BIND, the rates for negative responses, referrals and errors default to the rate for responses.
+	if rate, ok := rl.Rates[kindResponse]; ok {
+		for _, kind := range []string{kindNodata, kindNXDomain, kindReferral, kindError} {
+			if _, ok := rl.Rates[kind]; !ok {
+				rl.Rates[kind] = rate
+			}
+		}
+	}
+	if len(rl.Rates) == 0 {
+		return nil, c.Err("no rates configured")
+	}
+	return rl, nil
+}
+
+func positiveInt(c *caddy.Controller, opt string) (int, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return 0, c.ArgErr()
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, c.Errf("invalid value %q for %s, must be a positive integer", args[0], opt)
+	}
+	return n, nil
+}
+
+// normalize appends '/32' for any single IPv4 address and '/128' for IPv6.
+func normalize(rawNet string) string {
+	if strings.Contains(rawNet, "/") {
+		return rawNet
+	}
+	if strings.Contains(rawNet, ":") {
+		return rawNet + "/128"
+	}
+	return rawNet + "/32"
+}
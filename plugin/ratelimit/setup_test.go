@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+	}{
+		{`ratelimit {
+			queries-per-second 100
+		}`, false},
+		{`ratelimit example.org {
+			responses-per-second 5
+			nxdomains-per-second 2.5
+			window 5
+			slip 0
+			ipv4-prefix-length 32
+			ipv6-prefix-length 64
+			exempt 10.0.0.0/8 ::1
+			max-table-size 1000
+		}`, false},
+		// fails
+		{`ratelimit`, true},
+		{`ratelimit {
+			responses-per-second
+		}`, true},
+		{`ratelimit {
+			responses-per-second -1
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+			window 0
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+			ipv4-prefix-length 33
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+			exempt 10.0.0.0/33
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+			slip foo
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+			unknown
+		}`, true},
+		{`ratelimit {
+			responses-per-second 5
+		}
+		ratelimit {
+			responses-per-second 5
+		}`, true},
+	}
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		err := setup(c)
+		if tc.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error but found none for input %s", i, tc.input)
+		}
+		if !tc.shouldErr && err != nil {
+			t.Errorf("Test %d: expected no error but found one for input %s, got: %v", i, tc.input, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	c := caddy.NewTestController("dns", `ratelimit example.org {
+		responses-per-second 5
+		nxdomains-per-second 2
+		window 5
+		slip 3
+		ipv4-prefix-length 16
+		exempt 10.0.0.0/8
+	}`)
+	rl, err := parse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	if rl.Zones[0] != "example.org." {
+		t.Errorf("Expected zone %q, got %q", "example.org.", rl.Zones[0])
+	}
+	rates := map[string]float64{kindResponse: 5, kindNXDomain: 2, kindNodata: 5, kindReferral: 5, kindError: 5}
+	for kind, rate := range rates {
+		if rl.Rates[kind] != rate {
+			t.Errorf("Expected rate %f for %s, got %f", rate, kind, rl.Rates[kind])
+		}
+	}
+	if _, ok := rl.Rates[kindQuery]; ok {
+		t.Errorf("Expected no query rate")
+	}
+	if rl.Window != 5*time.Second {
+		t.Errorf("Expected window %s, got %s", 5*time.Second, rl.Window)
+	}
+	if rl.Slip != 3 {
+		t.Errorf("Expected slip %d, got %d", 3, rl.Slip)
+	}
+	if x := rl.prefix(net.ParseIP("192.168.1.1")); x != "192.168.0.0" {
+		t.Errorf("Expected prefix %q, got %q", "192.168.0.0", x)
+	}
+	if x := rl.prefix(net.ParseIP("2001:db8:1:2:3::1")); x != "2001:db8:1::" {
+		t.Errorf("Expected prefix %q, got %q", "2001:db8:1::", x)
+	}
+	if _, ok := rl.Exempt.GetByIP(net.ParseIP("10.1.1.1")); !ok {
+		t.Errorf("Expected 10.1.1.1 to be exempt")
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket. It is credited with rate tokens per second, up to rate, and every
+// response (or query) debits one token. A bucket with a negative balance is rate limited.
+type bucket struct {
+	balance float64
+	last    time.Time
+	limited int // number of rate limited responses since the bucket went negative
+}
+
+// table holds the buckets, keyed by client prefix and, for responses, the kind of response and name.
+type table struct {
+	max int
+
+	sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func newTable(max int) *table { return &table{max: max, buckets: make(map[string]*bucket)} }
+
+// debit takes a token from the bucket for key, which is credited with rate tokens per second. The
+// balance can go no lower than -window*rate, so an abusive client needs to be quiet for window
+// seconds before it is no longer limited. It returns 0 if the bucket is not limited, otherwise it
+// returns the number of limited responses (including this one) since the bucket became limited.
+func (t *table) debit(key string, rate float64, window time.Duration, now time.Time) int {
+	t.Lock()
+	defer t.Unlock()
+
+	b, ok := t.buckets[key]
+	if !ok {
+		t.makeRoom(window, now)
+		b = &bucket{balance: rate, last: now}
+		t.buckets[key] = b
+	}
+
+	b.balance += now.Sub(b.last).Seconds() * rate
+	if b.balance > rate {
+		b.balance = rate
+	}
+	b.last = now
+
+	b.balance--
+	if min := -window.Seconds() * rate; b.balance < min {
+		b.balance = min
+	}
+
+	if b.balance >= 0 {
+		b.limited = 0
+		return 0
+	}
+	b.limited++
+	return b.limited
+}
+
+// makeRoom removes buckets that haven't been used for window when the table is full. If that doesn't
+// free up any space arbitrary buckets are removed. The caller must hold the lock.
+func (t *table) makeRoom(window time.Duration, now time.Time) {
+	if t.max <= 0 || len(t.buckets) < t.max {
+		return
+	}
+	// Sweeping is expensive, do it at most once per second.
+	if now.Sub(t.swept) > time.Second {
+		t.swept = now
+		for k, b := range t.buckets {
+			if now.Sub(b.last) > window {
+				delete(t.buckets, k)
+			}
+		}
+	}
+	for k := range t.buckets {
+		if len(t.buckets) < t.max {
+			break
+		}
+		delete(t.buckets, k)
+	}
+}
+
+// Len returns the number of buckets in the table.
+func (t *table) Len() int {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.buckets)
+}
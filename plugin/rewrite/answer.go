@@ -0,0 +1,183 @@
+package rewrite
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// answerRule is a rule that only rewrites the data of the records in the response, it applies to every request.
+type answerRule struct {
+	NextAction string
+	ResponseRule
+}
+
+// newAnswerRule creates a rule that rewrites the data of records in the response. It is either a value rule, that
+// rewrites the target names of CNAME, DNAME, MX, NS, PTR and SRV records matching a regular expression, or an address
+// rule, that translates the addresses of A and AAAA records from one network to another.
+func newAnswerRule(nextAction string, args ...string) (Rule, error) {
+	if len(args) > 0 && strings.ToLower(args[0]) == "name" {
+		return nil, fmt.Errorf("response rewrites must begin with a name rule")
+	}
+	if len(args) != 3 {
+		return nil, fmt.Errorf("answer rules must have a field and exactly two arguments")
+	}
+	switch strings.ToLower(args[0]) {
+	case "value":
+		pattern, err := isValidRegexPattern(args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		return &answerRule{
+			nextAction,
+			ResponseRule{
+				Active:      true,
+				Type:        "value",
+				Pattern:     pattern,
+				Replacement: plugin.Name(args[2]).Normalize(),
+			},
+		}, nil
+	case "address":
+		_, from, err := net.ParseCIDR(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid network in an answer address rule: %s", args[1])
+		}
+		_, to, err := net.ParseCIDR(args[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid network in an answer address rule: %s", args[2])
+		}
+		fromOnes, fromBits := from.Mask.Size()
+		toOnes, toBits := to.Mask.Size()
+		if fromOnes != toOnes || fromBits != toBits {
+			return nil, fmt.Errorf("networks in an answer address rule must be of the same family and size: %s, %s", args[1], args[2])
+		}
+		return &answerRule{
+			nextAction,
+			ResponseRule{
+				Active: true,
+				Type:   "address",
+				From:   from,
+				To:     to,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("answer rule supports only value and address fields, received: %s", args[0])
+	}
+}
+
+// Rewrite leaves the request alone, the rule applies to every response.
+func (rule *answerRule) Rewrite(ctx context.Context, state request.Request) Result {
+	return RewriteDone
+}
+
+// Mode returns the processing nextAction
+func (rule *answerRule) Mode() string { return rule.NextAction }
+
+// GetResponseRule return a rule to rewrite the response with.
+func (rule *answerRule) GetResponseRule() ResponseRule { return rule.ResponseRule }
+
+// rewriteData returns rrs with the data of the records rewritten by the value and address rules. The records are
+// copied before they are changed, as they may be shared with other responses, e.g. from the zone of the file plugin.
+func rewriteData(rules []ResponseRule, rrs []dns.RR) []dns.RR {
+	if len(rrs) == 0 {
+		return rrs
+	}
+	out := make([]dns.RR, len(rrs))
+	for i, rr := range rrs {
+		for _, rule := range rules {
+			switch rule.Type {
+			case "value":
+				rr = rewriteValue(rule, rr)
+			case "address":
+				rr = rewriteAddress(rule, rr)
+			}
+		}
+		out[i] = rr
+	}
+	return out
+}
+
+// rewriteValue returns a copy of rr with the target name in its data rewritten, if it matches the pattern of rule.
+// Otherwise rr is returned.
+func rewriteValue(rule ResponseRule, rr dns.RR) dns.RR {
+	target := valueOf(rr)
+	if target == nil {
+		return rr
+	}
+	regexGroups := rule.Pattern.FindStringSubmatch(*target)
+	if len(regexGroups) == 0 {
+		return rr
+	}
+	s := rule.Replacement
+	for groupIndex, groupValue := range regexGroups {
+		groupIndexStr := "{" + strconv.Itoa(groupIndex) + "}"
+		s = strings.Replace(s, groupIndexStr, groupValue, -1)
+	}
+	if _, ok := dns.IsDomainName(s); !ok {
+		return rr
+	}
+	rr = dns.Copy(rr)
+	*valueOf(rr) = dns.Fqdn(s)
+	return rr
+}
+
+// valueOf returns the target name in the data of rr, or nil if rr has none.
+func valueOf(rr dns.RR) *string {
+	switch x := rr.(type) {
+	case *dns.CNAME:
+		return &x.Target
+	case *dns.DNAME:
+		return &x.Target
+	case *dns.MX:
+		return &x.Mx
+	case *dns.NS:
+		return &x.Ns
+	case *dns.PTR:
+		return &x.Ptr
+	case *dns.SRV:
+		return &x.Target
+	}
+	return nil
+}
+
+// rewriteAddress returns a copy of rr with the address in its data translated to the network rule.To, if it is in the
+// network rule.From. The host part of the address is kept. Otherwise rr is returned.
+func rewriteAddress(rule ResponseRule, rr dns.RR) dns.RR {
+	var ip net.IP
+	switch x := rr.(type) {
+	case *dns.A:
+		if len(rule.From.IP) != net.IPv4len {
+			return rr
+		}
+		ip = x.A.To4()
+	case *dns.AAAA:
+		if len(rule.From.IP) != net.IPv6len {
+			return rr
+		}
+		ip = x.AAAA
+	default:
+		return rr
+	}
+	if ip == nil || !rule.From.Contains(ip) {
+		return rr
+	}
+	translated := make(net.IP, len(ip))
+	for i := range ip {
+		translated[i] = rule.To.IP[i] | ip[i]&^rule.From.Mask[i]
+	}
+	rr = dns.Copy(rr)
+	switch x := rr.(type) {
+	case *dns.A:
+		x.A = translated
+	case *dns.AAAA:
+		x.AAAA = translated
+	}
+	return rr
+}
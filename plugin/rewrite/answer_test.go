@@ -0,0 +1,186 @@
+package rewrite
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestNewAnswerRule(t *testing.T) {
+	tests := []struct {
+		args         []string
+		expectedFail bool
+	}{
+		{[]string{"value", `(.*)\.internal\.`, "{1}.overlay.example.org."}, false},
+		{[]string{"VALUE", `(.*)\.internal\.`, "{1}.overlay.example.org"}, false},
+		{[]string{"address", "10.0.0.0/8", "100.64.0.0/8"}, false},
+		{[]string{"address", "fd00::/64", "2001:db8::/64"}, false},
+		{[]string{"value", `(.*)\.internal\.`}, true},
+		{[]string{"value", "\xecore", "overlay.example.org."}, true},
+		{[]string{"address", "10.0.0.0/8", "100.64.0.0/10"}, true},
+		{[]string{"address", "10.0.0.0/8", "fd00::/8"}, true},
+		{[]string{"address", "10.0.0.1", "100.64.0.1"}, true},
+		{[]string{"address", "10.0.0.0/8", "100.64.0.0/8", "extra"}, true},
+		{[]string{"ttl", "10.0.0.0/8", "100.64.0.0/8"}, true},
+		{[]string{"name", "foo", "bar"}, true},
+	}
+	for i, tc := range tests {
+		_, err := newAnswerRule("stop", tc.args...)
+		if (err != nil) != tc.expectedFail {
+			t.Errorf("Test %d: expected fail=%t, got %v", i, tc.expectedFail, err)
+		}
+		_, err = newRule(append([]string{"continue", "answer"}, tc.args...)...)
+		if (err != nil) != tc.expectedFail {
+			t.Errorf("Test %d: expected fail=%t for newRule, got %v", i, tc.expectedFail, err)
+		}
+	}
+}
+
+func TestAnswerRewrite(t *testing.T) {
+	rules := []Rule{}
+	for _, args := range [][]string{
+		{"continue", "answer", "value", `(.*)\.internal\.`, "{1}.overlay.example.org."},
+		{"continue", "answer", "address", "10.0.0.0/8", "100.64.0.0/8"},
+		{"continue", "answer", "address", "fd00::/64", "2001:db8::/64"},
+	} {
+		r, err := newRule(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, r)
+	}
+	rw := Rewrite{
+		Next:  dataHandler(),
+		Rules: rules,
+	}
+
+	tests := []struct {
+		qname    string
+		qtype    uint16
+		expected []string
+	}{
+		{"a.example.org.", dns.TypeA, []string{"a.example.org.	5	IN	A	100.1.2.3"}},
+		{"public.example.org.", dns.TypeA, []string{"public.example.org.	5	IN	A	192.0.2.1"}},
+		{"a.example.org.", dns.TypeAAAA, []string{"a.example.org.	5	IN	AAAA	2001:db8::1"}},
+		{"other.example.org.", dns.TypeAAAA, []string{"other.example.org.	5	IN	AAAA	fd00:0:0:1::1"}},
+		{"alias.example.org.", dns.TypeCNAME, []string{"alias.example.org.	5	IN	CNAME	web.overlay.example.org."}},
+		{"example.org.", dns.TypeMX, []string{"example.org.	5	IN	MX	10 mail.overlay.example.org.", "mail.internal.	5	IN	A	100.0.0.25"}},
+		{"_http._tcp.example.org.", dns.TypeSRV, []string{"_http._tcp.example.org.	5	IN	SRV	0 0 80 web.overlay.example.org."}},
+		{"example.org.", dns.TypeNS, []string{"example.org.	5	IN	NS	ns.example.net."}},
+	}
+	ctx := context.TODO()
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rw.ServeDNS(ctx, rec, m)
+		resp := rec.Msg
+		got := []string{}
+		for _, rr := range append(resp.Answer, resp.Extra...) {
+			got = append(got, rr.String())
+		}
+		if len(got) != len(tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+			continue
+		}
+		for j := range got {
+			if got[j] != tc.expected[j] {
+				t.Errorf("Test %d: expected %q, got %q", i, tc.expected[j], got[j])
+			}
+		}
+	}
+}
+
+func TestAnswerRewriteKeepsZone(t *testing.T) {
+	zone, err := file.Parse(strings.NewReader(dbInternal), "example.org.", "stdin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := file.File{Next: test.ErrorHandler(), Zones: file.Zones{Z: map[string]*file.Zone{"example.org.": zone}, Names: []string{"example.org."}}}
+
+	rules := []Rule{}
+	for _, args := range [][]string{
+		{"continue", "answer", "value", `(.*)\.internal\.`, "{1}.overlay.example.org."},
+		{"continue", "answer", "address", "10.0.0.0/8", "100.64.0.0/8"},
+	} {
+		r, err := newRule(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, r)
+	}
+	rw := Rewrite{Next: fm, Rules: rules}
+
+	tests := []struct {
+		qname    string
+		qtype    uint16
+		expected string
+	}{
+		{"a.example.org.", dns.TypeA, "a.example.org.	300	IN	A	100.1.2.3"},
+		{"alias.example.org.", dns.TypeCNAME, "alias.example.org.	300	IN	CNAME	web.overlay.example.org."},
+	}
+	ctx := context.TODO()
+	for i, tc := range tests {
+		// The second query must see the data of the zone, and not the rewritten data of the first response.
+		for j := 0; j < 2; j++ {
+			m := new(dns.Msg)
+			m.SetQuestion(tc.qname, tc.qtype)
+			rec := dnstest.NewRecorder(&test.ResponseWriter{})
+			rw.ServeDNS(ctx, rec, m)
+			if len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].String() != tc.expected {
+				t.Errorf("Test %d, query %d: expected %q, got %v", i, j, tc.expected, rec.Msg.Answer)
+			}
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		fm.ServeDNS(ctx, rec, m)
+		if len(rec.Msg.Answer) != 1 || rec.Msg.Answer[0].String() == tc.expected {
+			t.Errorf("Test %d: expected the zone to be unchanged, got %v", i, rec.Msg.Answer)
+		}
+	}
+}
+
+const dbInternal = `
+$TTL    300
+example.org.       IN SOA ns.example.org. admin.example.org. 1 3600 600 86400 300
+example.org.       IN NS  ns.example.net.
+a.example.org.     IN A   10.1.2.3
+alias.example.org. IN CNAME web.internal.
+`
+
+// dataHandler returns a handler that answers with records whose data has internal names and addresses.
+func dataHandler() test.HandlerFunc {
+	return func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		switch q := r.Question[0]; {
+		case q.Name == "a.example.org." && q.Qtype == dns.TypeA:
+			m.Answer = []dns.RR{test.A("a.example.org. 5 IN A 10.1.2.3")}
+		case q.Name == "public.example.org.":
+			m.Answer = []dns.RR{test.A("public.example.org. 5 IN A 192.0.2.1")}
+		case q.Name == "a.example.org." && q.Qtype == dns.TypeAAAA:
+			m.Answer = []dns.RR{test.AAAA("a.example.org. 5 IN AAAA fd00::1")}
+		case q.Name == "other.example.org.":
+			m.Answer = []dns.RR{test.AAAA("other.example.org. 5 IN AAAA fd00:0:0:1::1")}
+		case q.Qtype == dns.TypeCNAME:
+			m.Answer = []dns.RR{test.CNAME("alias.example.org. 5 IN CNAME web.internal.")}
+		case q.Qtype == dns.TypeMX:
+			m.Answer = []dns.RR{test.MX("example.org. 5 IN MX 10 mail.internal.")}
+			m.Extra = []dns.RR{test.A("mail.internal. 5 IN A 10.0.0.25")}
+		case q.Qtype == dns.TypeSRV:
+			m.Answer = []dns.RR{test.SRV("_http._tcp.example.org. 5 IN SRV 0 0 80 web.internal.")}
+		case q.Qtype == dns.TypeNS:
+			m.Answer = []dns.RR{test.NS("example.org. 5 IN NS ns.example.net.")}
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	}
+}
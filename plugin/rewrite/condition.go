@@ -0,0 +1,233 @@
+package rewrite
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// These are the operators of conditions.
+const (
+	// Is is true when the variable equals the value.
+	Is = "is"
+	// Not is true when the variable doesn't equal the value.
+	Not = "not"
+	// In is true when the variable is an address in one of the comma separated networks of the value.
+	In = "in"
+	// NotIn is true when the variable isn't an address in any of the comma separated networks of the value.
+	NotIn = "not_in"
+	// Regex is true when the variable matches the regular expression of the value.
+	Regex = "regex"
+)
+
+// Variables of conditions, in addition to the ones of the EDNS0 rules.
+const (
+	edns0Subnet = "{edns0/subnet}"
+	edns0Prefix = "{edns0/"
+	httpPrefix  = "{http/"
+)
+
+// conditionalRule is a rule that only applies when all of its conditions are true.
+type conditionalRule struct {
+	Rule
+	conditions []condition
+}
+
+// condition compares the value of a variable of the request with a value.
+type condition struct {
+	variable string
+	operator string
+	value    string
+	networks []*net.IPNet
+	pattern  *regexp.Regexp
+}
+
+// newConditionalRule creates the rule of args, that only applies when the conditions of conds are true. conds is a
+// list of VARIABLE OPERATOR VALUE triples.
+func newConditionalRule(args []string, conds []string) (Rule, error) {
+	if len(conds) == 0 || len(conds)%3 != 0 {
+		return nil, fmt.Errorf("conditions must consist of a variable, an operator and a value, received: %s", conds)
+	}
+	rule, err := newRule(args...)
+	if err != nil {
+		return nil, err
+	}
+	r := &conditionalRule{Rule: rule}
+	for i := 0; i < len(conds); i += 3 {
+		c, err := newCondition(conds[i], strings.ToLower(conds[i+1]), conds[i+2])
+		if err != nil {
+			return nil, err
+		}
+		r.conditions = append(r.conditions, c)
+	}
+	return r, nil
+}
+
+func newCondition(variable, operator, value string) (condition, error) {
+	c := condition{variable: variable, operator: operator, value: value}
+	code, isLocal := edns0Code(variable)
+	if !isValidVariable(variable) && !isLocal && variable != edns0Subnet && !isHTTPVariable(variable) {
+		return c, fmt.Errorf("unsupported variable in a condition: %s", variable)
+	}
+	if isLocal && code < 0 {
+		return c, fmt.Errorf("invalid EDNS0 option code in a condition: %s", variable)
+	}
+
+	switch operator {
+	case Is, Not:
+		if isLocal && strings.HasPrefix(value, "0x") {
+			data, err := hex.DecodeString(value[2:])
+			if err != nil {
+				return c, fmt.Errorf("invalid hex value in a condition: %s", value)
+			}
+			c.value = string(data)
+		}
+	case In, NotIn:
+		for _, s := range strings.Split(value, ",") {
+			if !strings.Contains(s, "/") {
+				if strings.Contains(s, ":") {
+					s += "/128"
+				} else {
+					s += "/32"
+				}
+			}
+			_, n, err := net.ParseCIDR(s)
+			if err != nil {
+				return c, fmt.Errorf("invalid network in a condition: %s", s)
+			}
+			c.networks = append(c.networks, n)
+		}
+	case Regex:
+		p, err := regexp.Compile(value)
+		if err != nil {
+			return c, fmt.Errorf("invalid regex pattern in a condition: %s", value)
+		}
+		c.pattern = p
+	default:
+		return c, fmt.Errorf("unsupported operator in a condition: %s", operator)
+	}
+	return c, nil
+}
+
+// Rewrite rewrites the current request with the rule, if all conditions are true.
+func (rule *conditionalRule) Rewrite(ctx context.Context, state request.Request) Result {
+	for _, c := range rule.conditions {
+		if !c.match(ctx, state) {
+			return RewriteIgnored
+		}
+	}
+	return rule.Rule.Rewrite(ctx, state)
+}
+
+// match returns true if the condition is true for the request.
+func (c condition) match(ctx context.Context, state request.Request) bool {
+	v := variableValue(ctx, state, c.variable)
+	switch c.operator {
+	case Is:
+		return v == c.value
+	case Not:
+		return v != c.value
+	case In, NotIn:
+		if i := strings.Index(v, "/"); i >= 0 {
+			v = v[:i]
+		}
+		ip := net.ParseIP(v)
+		in := false
+		for _, n := range c.networks {
+			if ip != nil && n.Contains(ip) {
+				in = true
+				break
+			}
+		}
+		return in == (c.operator == In)
+	case Regex:
+		return c.pattern.MatchString(v)
+	}
+	return false
+}
+
+// variableValue returns the value of variable for the request, or an empty string if it has none.
+func variableValue(ctx context.Context, state request.Request, variable string) string {
+	switch variable {
+	case queryName:
+		return state.Name()
+	case queryType:
+		return state.Type()
+	case clientIP:
+		return state.IP()
+	case clientPort:
+		return state.Port()
+	case protocol:
+		return state.Proto()
+	case serverIP:
+		return state.LocalIP()
+	case serverPort:
+		return state.LocalPort()
+	case edns0Subnet:
+		o := state.Req.IsEdns0()
+		if o == nil {
+			return ""
+		}
+		for _, s := range o.Option {
+			if e, ok := s.(*dns.EDNS0_SUBNET); ok {
+				return e.Address.String() + "/" + strconv.Itoa(int(e.SourceNetmask))
+			}
+		}
+		return ""
+	}
+
+	if isHTTPVariable(variable) {
+		r, ok := ctx.Value(dnsserver.HTTPRequestKey{}).(*http.Request)
+		if !ok {
+			return ""
+		}
+		return strings.Join(r.Header[http.CanonicalHeaderKey(variable[len(httpPrefix):len(variable)-1])], ", ")
+	}
+
+	if code, ok := edns0Code(variable); ok {
+		o := state.Req.IsEdns0()
+		if o == nil {
+			return ""
+		}
+		for _, s := range o.Option {
+			if e, ok := s.(*dns.EDNS0_LOCAL); ok && int(e.Code) == code {
+				return string(e.Data)
+			}
+		}
+		return ""
+	}
+
+	if fetcher := metadata.ValueFunc(ctx, variable[1:len(variable)-1]); fetcher != nil {
+		return fetcher()
+	}
+	return ""
+}
+
+// edns0Code returns the code of the local EDNS0 option of variable, if it is such a variable, e.g. {edns0/0xffee}.
+// The code is -1 if it isn't valid.
+func edns0Code(variable string) (int, bool) {
+	if !strings.HasPrefix(variable, edns0Prefix) || !strings.HasSuffix(variable, "}") || variable == edns0Subnet {
+		return 0, false
+	}
+	c, err := strconv.ParseUint(variable[len(edns0Prefix):len(variable)-1], 0, 16)
+	if err != nil {
+		return -1, true
+	}
+	return int(c), true
+}
+
+// isHTTPVariable returns true if variable is a header of the HTTP request of DNS over HTTPS, e.g. {http/X-Forwarded-For}.
+func isHTTPVariable(variable string) bool {
+	return strings.HasPrefix(variable, httpPrefix) && strings.HasSuffix(variable, "}") && len(variable) > len(httpPrefix)+1
+}
@@ -0,0 +1,118 @@
+package rewrite
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestNewConditionalRule(t *testing.T) {
+	tests := []struct {
+		args         string
+		expectedFail bool
+		expectedType reflect.Type
+	}{
+		{"name a.com b.com if {client_ip} in 10.0.0.0/8", false, reflect.TypeOf(&conditionalRule{})},
+		{"continue name a.com b.com if {client_ip} in 10.0.0.0/8,fd00::/8 {protocol} is tcp", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com IF {client_ip} not_in 10.0.0.1", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com if {kubernetes/client-namespace} is tenant-a", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com if {edns0/0xffee} is 0x61626364", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com if {edns0/subnet} in 192.0.2.0/24", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com if {qname} regex ^a\\.", false, reflect.TypeOf(&conditionalRule{})},
+		{"ttl a.com 10 if {qtype} not AAAA", false, reflect.TypeOf(&conditionalRule{})},
+		{"name a.com b.com if", true, nil},
+		{"name a.com b.com if {client_ip} in", true, nil},
+		{"name a.com b.com if {client_ip} in 10.0.0.0/33", true, nil},
+		{"name a.com b.com if {client_ip} near 10.0.0.0/8", true, nil},
+		{"name a.com b.com if {client} is 10.0.0.1", true, nil},
+		{"name a.com b.com if {edns0/foo} is bar", true, nil},
+		{"name a.com b.com if {edns0/0xffee} is 0xzz", true, nil},
+		{"name a.com b.com if {qname} regex \xedns", true, nil},
+		{"name a.com if {protocol} is tcp", true, nil},
+		{"continue if {protocol} is tcp", true, nil},
+		// A literal if as FROM or TO value.
+		{"name exact if b.com", false, reflect.TypeOf(&exactNameRule{})},
+		{"name a.com if", false, reflect.TypeOf(&exactNameRule{})},
+		{"name exact if b.com if {protocol} is tcp", false, reflect.TypeOf(&conditionalRule{})},
+		{"name regex (.*)\\.if\\.com {1}.if.org if {protocol} is tcp", false, reflect.TypeOf(&conditionalRule{})},
+	}
+	for i, tc := range tests {
+		r, err := newRule(strings.Fields(tc.args)...)
+		if (err != nil) != tc.expectedFail {
+			t.Errorf("Test %d: expected fail=%t, got %v", i, tc.expectedFail, err)
+			continue
+		}
+		if err == nil && reflect.TypeOf(r) != tc.expectedType {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.expectedType, reflect.TypeOf(r))
+		}
+	}
+}
+
+func TestConditionalRewrite(t *testing.T) {
+	tests := []struct {
+		condition string
+		w         dns.ResponseWriter
+		opts      []dns.EDNS0
+		md        map[string]string
+		expected  string
+	}{
+		{"{client_ip} in 10.0.0.0/8", &test.ResponseWriter{}, nil, nil, "b.com."},
+		{"{client_ip} in 192.0.2.0/24,10.240.0.1", &test.ResponseWriter{}, nil, nil, "b.com."},
+		{"{client_ip} in 192.0.2.0/24", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{client_ip} not_in 192.0.2.0/24", &test.ResponseWriter{}, nil, nil, "b.com."},
+		{"{client_ip} in fe80::/10", &test.ResponseWriter6{}, nil, nil, "b.com."},
+		{"{protocol} is tcp", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{protocol} is tcp", &test.ResponseWriter{TCP: true}, nil, nil, "b.com."},
+		{"{qtype} not A", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{qname} regex ^a\\.", &test.ResponseWriter{}, nil, nil, "b.com."},
+		{"{edns0/0xffee} is abcd", &test.ResponseWriter{}, []dns.EDNS0{&dns.EDNS0_LOCAL{Code: 0xffee, Data: []byte("abcd")}}, nil, "b.com."},
+		{"{edns0/0xffee} is 0x61626364", &test.ResponseWriter{}, []dns.EDNS0{&dns.EDNS0_LOCAL{Code: 0xffee, Data: []byte("abcd")}}, nil, "b.com."},
+		{"{edns0/0xffee} is abcd", &test.ResponseWriter{}, []dns.EDNS0{&dns.EDNS0_LOCAL{Code: 0xffed, Data: []byte("abcd")}}, nil, "a.com."},
+		{"{edns0/0xffee} is abcd", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{edns0/subnet} in 192.0.2.0/24", &test.ResponseWriter{}, []dns.EDNS0{&dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP("192.0.2.0").To4()}}, nil, "b.com."},
+		{"{edns0/subnet} in 192.0.2.0/24", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{kubernetes/client-namespace} is tenant-a", &test.ResponseWriter{}, nil, map[string]string{"kubernetes/client-namespace": "tenant-a"}, "b.com."},
+		{"{kubernetes/client-namespace} is tenant-a", &test.ResponseWriter{}, nil, map[string]string{"kubernetes/client-namespace": "tenant-b"}, "a.com."},
+		{"{kubernetes/client-namespace} is tenant-a", &test.ResponseWriter{}, nil, nil, "a.com."},
+		{"{client_ip} in 10.0.0.0/8 {protocol} is udp", &test.ResponseWriter{}, nil, nil, "b.com."},
+		{"{client_ip} in 10.0.0.0/8 {protocol} is tcp", &test.ResponseWriter{}, nil, nil, "a.com."},
+	}
+
+	for i, tc := range tests {
+		r, err := newRule(append([]string{"name", "a.com", "b.com", "if"}, strings.Fields(tc.condition)...)...)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i, err)
+		}
+		rw := Rewrite{
+			Next:     test.HandlerFunc(msgPrinter),
+			Rules:    []Rule{r},
+			noRevert: true,
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion("a.com.", dns.TypeA)
+		if tc.opts != nil {
+			o := setupEdns0Opt(m)
+			o.Option = tc.opts
+		}
+		ctx := metadata.ContextWithMetadata(context.TODO())
+		for label, value := range tc.md {
+			value := value
+			metadata.SetValueFunc(ctx, label, func() string { return value })
+		}
+
+		rec := dnstest.NewRecorder(tc.w)
+		rw.ServeDNS(ctx, rec, m)
+		if got := rec.Msg.Question[0].Name; got != tc.expected {
+			t.Errorf("Test %d: expected name %s for %q, got %s", i, tc.expected, tc.condition, got)
+		}
+	}
+}
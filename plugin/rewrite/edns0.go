@@ -38,6 +38,12 @@ type edns0NsidRule struct {
 	action string
 }
 
+// edns0UnsetRule is a rewrite rule that removes the EDNS0 options with a code.
+type edns0UnsetRule struct {
+	mode string
+	code uint16
+}
+
 // setupEdns0Opt will retrieve the EDNS0 OPT or create it if it does not exist.
 func setupEdns0Opt(r *dns.Msg) *dns.OPT {
 	o := r.IsEdns0()
@@ -48,6 +54,32 @@ func setupEdns0Opt(r *dns.Msg) *dns.OPT {
 	return o
 }
 
+// Rewrite will remove the EDNS0 options with the code of the rule from the request.
+func (rule *edns0UnsetRule) Rewrite(ctx context.Context, state request.Request) Result {
+	o := state.Req.IsEdns0()
+	if o == nil {
+		return RewriteIgnored
+	}
+
+	result := RewriteIgnored
+	opts := o.Option[:0]
+	for _, s := range o.Option {
+		if s.Option() == rule.code {
+			result = RewriteDone
+			continue
+		}
+		opts = append(opts, s)
+	}
+	o.Option = opts
+	return result
+}
+
+// Mode returns the processing mode.
+func (rule *edns0UnsetRule) Mode() string { return rule.mode }
+
+// GetResponseRule return a rule to rewrite the response with. Currently not implemented.
+func (rule *edns0UnsetRule) GetResponseRule() ResponseRule { return ResponseRule{} }
+
 // Rewrite will alter the request EDNS0 NSID option
 func (rule *edns0NsidRule) Rewrite(ctx context.Context, state request.Request) Result {
 	o := setupEdns0Opt(state.Req)
@@ -118,6 +150,8 @@ func newEdns0Rule(mode string, args ...string) (Rule, error) {
 	case Append:
 	case Replace:
 	case Set:
+	case Unset:
+		return newEdns0UnsetRule(mode, ruleType, args[2:]...)
 	default:
 		return nil, fmt.Errorf("invalid action: %q", action)
 	}
@@ -138,10 +172,42 @@ func newEdns0Rule(mode string, args ...string) (Rule, error) {
 		}
 		return &edns0NsidRule{mode: mode, action: action}, nil
 	case "subnet":
-		if len(args) != 4 {
-			return nil, fmt.Errorf("EDNS0 subnet rules require exactly three args")
+		if len(args) != 4 && len(args) != 5 {
+			return nil, fmt.Errorf("EDNS0 subnet rules require three or four args")
+		}
+		source := clientIP
+		if len(args) == 5 {
+			source = args[4]
+		}
+		return newEdns0SubnetRule(mode, action, args[2], args[3], source)
+	default:
+		return nil, fmt.Errorf("invalid rule type %q", ruleType)
+	}
+}
+
+// newEdns0UnsetRule creates an EDNS0 rule that removes options from the request. Local rules remove the options with
+// the code in args, which can be any option, the other rules remove the option of their type.
+func newEdns0UnsetRule(mode, ruleType string, args ...string) (*edns0UnsetRule, error) {
+	switch ruleType {
+	case "local":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("EDNS0 local unset rules require exactly one arg")
 		}
-		return newEdns0SubnetRule(mode, action, args[2], args[3])
+		c, err := strconv.ParseUint(args[0], 0, 16)
+		if err != nil {
+			return nil, err
+		}
+		return &edns0UnsetRule{mode: mode, code: uint16(c)}, nil
+	case "nsid":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("EDNS0 NSID rules do not accept args")
+		}
+		return &edns0UnsetRule{mode: mode, code: dns.EDNS0NSID}, nil
+	case "subnet":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("EDNS0 subnet unset rules do not accept args")
+		}
+		return &edns0UnsetRule{mode: mode, code: dns.EDNS0SUBNET}, nil
 	default:
 		return nil, fmt.Errorf("invalid rule type %q", ruleType)
 	}
@@ -281,9 +347,10 @@ type edns0SubnetRule struct {
 	v4BitMaskLen uint8
 	v6BitMaskLen uint8
 	action       string
+	source       string // variable with the address the subnet is derived from
 }
 
-func newEdns0SubnetRule(mode, action, v4BitMaskLen, v6BitMaskLen string) (*edns0SubnetRule, error) {
+func newEdns0SubnetRule(mode, action, v4BitMaskLen, v6BitMaskLen, source string) (*edns0SubnetRule, error) {
 	v4Len, err := strconv.ParseUint(v4BitMaskLen, 0, 16)
 	if err != nil {
 		return nil, err
@@ -302,13 +369,42 @@ func newEdns0SubnetRule(mode, action, v4BitMaskLen, v6BitMaskLen string) (*edns0
 		return nil, fmt.Errorf("invalid IPv6 bit mask length %d", v6Len)
 	}
 
+	if !isValidVariable(source) && !isHTTPVariable(source) {
+		return nil, fmt.Errorf("unsupported variable for an EDNS0 subnet rule: %s", source)
+	}
+
 	return &edns0SubnetRule{mode: mode, action: action,
-		v4BitMaskLen: uint8(v4Len), v6BitMaskLen: uint8(v6Len)}, nil
+		v4BitMaskLen: uint8(v4Len), v6BitMaskLen: uint8(v6Len), source: source}, nil
+}
+
+// sourceIP returns the address the subnet is derived from, and its family.
+func (rule *edns0SubnetRule) sourceIP(ctx context.Context, state request.Request) (string, int) {
+	if rule.source == clientIP {
+		return state.IP(), state.Family()
+	}
+	// Proxies append the address of their client to X-Forwarded-For, so the last address is the one that was
+	// added by the proxy we trust.
+	v := variableValue(ctx, state, rule.source)
+	if i := strings.LastIndex(v, ","); i >= 0 {
+		v = v[i+1:]
+	}
+	v = strings.TrimSpace(v)
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		v = host
+	}
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return "", 0
+	}
+	if ip.To4() != nil {
+		return v, 1
+	}
+	return v, 2
 }
 
 // fillEcsData sets the subnet data into the ecs option
-func (rule *edns0SubnetRule) fillEcsData(state request.Request, ecs *dns.EDNS0_SUBNET) error {
-	family := state.Family()
+func (rule *edns0SubnetRule) fillEcsData(ctx context.Context, state request.Request, ecs *dns.EDNS0_SUBNET) error {
+	ipAddr, family := rule.sourceIP(ctx, state)
 	if (family != 1) && (family != 2) {
 		return fmt.Errorf("unable to fill data for EDNS0 subnet due to invalid IP family")
 	}
@@ -316,7 +412,6 @@ func (rule *edns0SubnetRule) fillEcsData(state request.Request, ecs *dns.EDNS0_S
 	ecs.Family = uint16(family)
 	ecs.SourceScope = 0
 
-	ipAddr := state.IP()
 	switch family {
 	case 1:
 		ipv4Mask := net.CIDRMask(int(rule.v4BitMaskLen), 32)
@@ -339,7 +434,7 @@ func (rule *edns0SubnetRule) Rewrite(ctx context.Context, state request.Request)
 	for _, s := range o.Option {
 		if e, ok := s.(*dns.EDNS0_SUBNET); ok {
 			if rule.action == Replace || rule.action == Set {
-				if rule.fillEcsData(state, e) == nil {
+				if rule.fillEcsData(ctx, state, e) == nil {
 					return RewriteDone
 				}
 			}
@@ -350,7 +445,7 @@ func (rule *edns0SubnetRule) Rewrite(ctx context.Context, state request.Request)
 	// add option if not found
 	if rule.action == Append || rule.action == Set {
 		opt := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET}
-		if rule.fillEcsData(state, opt) == nil {
+		if rule.fillEcsData(ctx, state, opt) == nil {
 			o.Option = append(o.Option, opt)
 			return RewriteDone
 		}
@@ -370,6 +465,7 @@ const (
 	Replace = "replace"
 	Set     = "set"
 	Append  = "append"
+	Unset   = "unset"
 )
 
 // Supported local EDNS0 variables
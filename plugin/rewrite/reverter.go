@@ -1,6 +1,7 @@
 package rewrite
 
 import (
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
@@ -15,6 +16,8 @@ type ResponseRule struct {
 	Pattern     *regexp.Regexp
 	Replacement string
 	TTL         uint32
+	From        *net.IPNet
+	To          *net.IPNet
 }
 
 // ResponseReverter reverses the operations done on the question section of a packet.
@@ -75,6 +78,8 @@ func (r *ResponseReverter) WriteMsg(res *dns.Msg) error {
 				rr.Header().Ttl = ttl
 			}
 		}
+		res.Answer = rewriteData(r.ResponseRules, res.Answer)
+		res.Extra = rewriteData(r.ResponseRules, res.Extra)
 	}
 	return r.ResponseWriter.WriteMsg(res)
 }
@@ -87,7 +87,32 @@ func newRule(args ...string) (Rule, error) {
 		return nil, fmt.Errorf("no rule type specified for rewrite")
 	}
 
+	// The conditions start at an if keyword that follows the complete arguments of the rule. An if before that is
+	// an argument of the rule itself, e.g. a FROM or TO value.
+	var condErr error
+	for i, arg := range args {
+		if strings.ToLower(arg) != "if" {
+			continue
+		}
+		rule, err := newConditionalRule(args[:i], args[i+1:])
+		if err == nil {
+			return rule, nil
+		}
+		condErr = err
+	}
+	rule, err := newPlainRule(args...)
+	if err != nil && condErr != nil {
+		return nil, condErr
+	}
+	return rule, err
+}
+
+// newPlainRule creates the rule of args, without conditions.
+func newPlainRule(args ...string) (Rule, error) {
 	arg0 := strings.ToLower(args[0])
+	if (arg0 == Continue || arg0 == Stop) && len(args) < 2 {
+		return nil, fmt.Errorf("no rule type specified for rewrite")
+	}
 	var ruleType string
 	var expectNumArgs, startArg int
 	mode := Stop
@@ -110,7 +135,7 @@ func newRule(args ...string) (Rule, error) {
 
 	switch ruleType {
 	case "answer":
-		return nil, fmt.Errorf("response rewrites must begin with a name rule")
+		return newAnswerRule(mode, args[startArg:]...)
 	case "name":
 		return newNameRule(mode, args[startArg:]...)
 	case "class":
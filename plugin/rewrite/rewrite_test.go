@@ -3,9 +3,12 @@ package rewrite
 import (
 	"bytes"
 	"context"
+	"net"
+	"net/http"
 	"reflect"
 	"testing"
 
+	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metadata"
 	"github.com/coredns/coredns/plugin/pkg/dnstest"
@@ -104,6 +107,20 @@ func TestNewRule(t *testing.T) {
 		{[]string{"edns0", "subnet", "set", "24", "56"}, false, reflect.TypeOf(&edns0SubnetRule{})},
 		{[]string{"edns0", "subnet", "append", "24", "56"}, false, reflect.TypeOf(&edns0SubnetRule{})},
 		{[]string{"edns0", "subnet", "replace", "24", "56"}, false, reflect.TypeOf(&edns0SubnetRule{})},
+		{[]string{"edns0", "subnet", "set", "24", "56", "{http/X-Forwarded-For}"}, false, reflect.TypeOf(&edns0SubnetRule{})},
+		{[]string{"edns0", "subnet", "set", "24", "56", "{proxy/client-ip}"}, false, reflect.TypeOf(&edns0SubnetRule{})},
+		{[]string{"edns0", "subnet", "set", "24", "56", "{dummy}"}, true, nil},
+		{[]string{"edns0", "subnet", "set", "24", "56", "{http/}"}, true, nil},
+		{[]string{"edns0", "subnet", "unset"}, false, reflect.TypeOf(&edns0UnsetRule{})},
+		{[]string{"edns0", "subnet", "unset", "24"}, true, nil},
+		{[]string{"edns0", "nsid", "unset"}, false, reflect.TypeOf(&edns0UnsetRule{})},
+		{[]string{"edns0", "nsid", "unset", "junk"}, true, nil},
+		{[]string{"edns0", "local", "unset", "0xffee"}, false, reflect.TypeOf(&edns0UnsetRule{})},
+		{[]string{"edns0", "local", "unset", "10"}, false, reflect.TypeOf(&edns0UnsetRule{})},
+		{[]string{"edns0", "local", "unset"}, true, nil},
+		{[]string{"edns0", "local", "unset", "0xfffff"}, true, nil},
+		{[]string{"edns0", "local", "unset", "0xffee", "abcd"}, true, nil},
+		{[]string{"edns0", "foo", "unset"}, true, nil},
 		{[]string{"unknown-action", "name", "a.com", "b.com"}, true, nil},
 		{[]string{"stop", "name", "a.com", "b.com"}, false, reflect.TypeOf(&exactNameRule{})},
 		{[]string{"continue", "name", "a.com", "b.com"}, false, reflect.TypeOf(&exactNameRule{})},
@@ -686,3 +703,145 @@ func TestRewriteEDNS0Subnet(t *testing.T) {
 		}
 	}
 }
+
+func TestRewriteEDNS0Unset(t *testing.T) {
+	rw := Rewrite{
+		Next:     plugin.HandlerFunc(msgPrinter),
+		noRevert: true,
+	}
+
+	ecs := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP("192.0.2.0").To4()}
+	nsid := &dns.EDNS0_NSID{Code: dns.EDNS0NSID}
+	local := &dns.EDNS0_LOCAL{Code: 0xffee, Data: []byte("abcd")}
+	cookie := &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0123456789abcdef"}
+
+	tests := []struct {
+		fromOpts []dns.EDNS0
+		args     []string
+		toOpts   []dns.EDNS0
+	}{
+		{[]dns.EDNS0{ecs, nsid, local}, []string{"subnet", "unset"}, []dns.EDNS0{nsid, local}},
+		{[]dns.EDNS0{ecs, nsid, local}, []string{"nsid", "unset"}, []dns.EDNS0{ecs, local}},
+		{[]dns.EDNS0{ecs, nsid, local}, []string{"local", "unset", "0xffee"}, []dns.EDNS0{ecs, nsid}},
+		{[]dns.EDNS0{ecs, nsid, local}, []string{"local", "unset", "0xffed"}, []dns.EDNS0{ecs, nsid, local}},
+		{[]dns.EDNS0{ecs, cookie}, []string{"local", "unset", "10"}, []dns.EDNS0{ecs}},
+		{[]dns.EDNS0{}, []string{"subnet", "unset"}, []dns.EDNS0{}},
+	}
+
+	ctx := context.TODO()
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("example.com.", dns.TypeA)
+		o := setupEdns0Opt(m)
+		o.Option = append([]dns.EDNS0{}, tc.fromOpts...)
+
+		r, err := newEdns0Rule("stop", tc.args...)
+		if err != nil {
+			t.Errorf("Error creating test rule: %s", err)
+			continue
+		}
+		rw.Rules = []Rule{r}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rw.ServeDNS(ctx, rec, m)
+
+		resp := rec.Msg
+		o = resp.IsEdns0()
+		if o == nil {
+			t.Errorf("Test %d: EDNS0 options not set", i)
+			continue
+		}
+		if !optsEqual(o.Option, tc.toOpts) {
+			t.Errorf("Test %d: Expected %v but got %v", i, tc.toOpts, o)
+		}
+	}
+
+	// Without EDNS0 in the request, none is added.
+	m := new(dns.Msg)
+	m.SetQuestion("example.com.", dns.TypeA)
+	r, _ := newEdns0Rule("stop", "subnet", "unset")
+	rw.Rules = []Rule{r}
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	rw.ServeDNS(ctx, rec, m)
+	if rec.Msg.IsEdns0() != nil {
+		t.Errorf("Expected no EDNS0 options, got %v", rec.Msg.IsEdns0())
+	}
+}
+
+func TestRewriteEDNS0SubnetSource(t *testing.T) {
+	rw := Rewrite{
+		Next:     plugin.HandlerFunc(msgPrinter),
+		noRevert: true,
+	}
+
+	tests := []struct {
+		header string
+		md     string
+		args   []string
+		toOpts []dns.EDNS0
+	}{
+		{
+			"192.0.2.15", "",
+			[]string{"subnet", "set", "24", "56", "{http/X-Forwarded-For}"},
+			[]dns.EDNS0{&dns.EDNS0_SUBNET{Code: 0x8, Family: 0x1, SourceNetmask: 0x18, Address: []byte{192, 0, 2, 0}}},
+		},
+		{
+			// The last address is the one added by the proxy.
+			"198.51.100.7, 192.0.2.15", "",
+			[]string{"subnet", "set", "24", "56", "{http/X-Forwarded-For}"},
+			[]dns.EDNS0{&dns.EDNS0_SUBNET{Code: 0x8, Family: 0x1, SourceNetmask: 0x18, Address: []byte{192, 0, 2, 0}}},
+		},
+		{
+			"2001:db8:1:2::15", "",
+			[]string{"subnet", "set", "24", "48", "{http/X-Forwarded-For}"},
+			[]dns.EDNS0{&dns.EDNS0_SUBNET{Code: 0x8, Family: 0x2, SourceNetmask: 0x30,
+				Address: []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}}},
+		},
+		{
+			"", "192.0.2.15:1234",
+			[]string{"subnet", "set", "24", "56", "{proxy/client-ip}"},
+			[]dns.EDNS0{&dns.EDNS0_SUBNET{Code: 0x8, Family: 0x1, SourceNetmask: 0x18, Address: []byte{192, 0, 2, 0}}},
+		},
+		{
+			// Without an address in the header, no option is added.
+			"", "",
+			[]string{"subnet", "set", "24", "56", "{http/X-Forwarded-For}"},
+			[]dns.EDNS0{},
+		},
+		{
+			"not-an-address", "",
+			[]string{"subnet", "set", "24", "56", "{http/X-Forwarded-For}"},
+			[]dns.EDNS0{},
+		},
+	}
+
+	for i, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("example.com.", dns.TypeA)
+		setupEdns0Opt(m)
+
+		req, _ := http.NewRequest(http.MethodPost, "https://example.org/dns-query", nil)
+		if tc.header != "" {
+			req.Header.Set("X-Forwarded-For", tc.header)
+		}
+		ctx := context.WithValue(context.TODO(), dnsserver.HTTPRequestKey{}, req)
+		ctx = metadata.ContextWithMetadata(ctx)
+		if tc.md != "" {
+			md := tc.md
+			metadata.SetValueFunc(ctx, "proxy/client-ip", func() string { return md })
+		}
+
+		r, err := newEdns0Rule("stop", tc.args...)
+		if err != nil {
+			t.Errorf("Error creating test rule: %s", err)
+			continue
+		}
+		rw.Rules = []Rule{r}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		rw.ServeDNS(ctx, rec, m)
+
+		o := rec.Msg.IsEdns0()
+		if !optsEqual(o.Option, tc.toOpts) {
+			t.Errorf("Test %d: Expected %v but got %v", i, tc.toOpts, o)
+		}
+	}
+}
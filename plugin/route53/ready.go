@@ -0,0 +1,4 @@
+package route53
+
+// Ready implements the ready.Readiness interface.
+func (h *Route53) Ready() bool { return h.zones.Ready() }
@@ -2,14 +2,12 @@ package route53
 
 import (
 	"context"
-	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
-	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -44,9 +42,7 @@ func setup(c *caddy.Controller) error {
 		// ~/.aws/credentials with the default profile.
 		sharedProvider := &credentials.SharedCredentialsProvider{}
 		var providers []credentials.Provider
-		var fall fall.F
-
-		refresh := time.Duration(1) * time.Minute // default update frequency to 1 minute
+		opts := cloud.Options{Refresh: time.Minute} // default update frequency to 1 minute
 
 		args := c.RemainingArgs()
 
@@ -68,6 +64,12 @@ func setup(c *caddy.Controller) error {
 		}
 
 		for c.NextBlock() {
+			if ok, err := opts.Parse(c); ok {
+				if err != nil {
+					return plugin.Error("route53", err)
+				}
+				continue
+			}
 			switch c.Val() {
 			case "aws_access_key":
 				v := c.RemainingArgs()
@@ -91,25 +93,6 @@ func setup(c *caddy.Controller) error {
 				if c.NextArg() {
 					sharedProvider.Filename = c.Val()
 				}
-			case "fallthrough":
-				fall.SetZonesFromArgs(c.RemainingArgs())
-			case "refresh":
-				if c.NextArg() {
-					refreshStr := c.Val()
-					_, err := strconv.Atoi(refreshStr)
-					if err == nil {
-						refreshStr = fmt.Sprintf("%ss", c.Val())
-					}
-					refresh, err = time.ParseDuration(refreshStr)
-					if err != nil {
-						return plugin.Error("route53", c.Errf("Unable to parse duration: '%v'", err))
-					}
-					if refresh <= 0 {
-						return plugin.Error("route53", c.Errf("refresh interval must be greater than 0: %s", refreshStr))
-					}
-				} else {
-					return plugin.Error("route53", c.ArgErr())
-				}
 			default:
 				return plugin.Error("route53", c.Errf("unknown property '%s'", c.Val()))
 			}
@@ -124,15 +107,15 @@ func setup(c *caddy.Controller) error {
 			Client: ec2metadata.New(session),
 		})
 		client := f(credentials.NewChainCredentials(providers))
-		ctx := context.Background()
-		h, err := New(ctx, client, keys, refresh)
+		h, err := New(context.Background(), client, keys, opts.Refresh)
 		if err != nil {
 			return plugin.Error("route53", c.Errf("failed to create Route53 plugin: %v", err))
 		}
-		h.Fall = fall
-		if err := h.Run(ctx); err != nil {
+		h.Fall = opts.Fall
+		if err := cloud.Run(c, h.Run); err != nil {
 			return plugin.Error("route53", c.Errf("failed to initialize Route53 plugin: %v", err))
 		}
+
 		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 			h.Next = next
 			return h
@@ -0,0 +1,170 @@
+package secondary
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/file/tree"
+
+	"github.com/miekg/dns"
+)
+
+// members holds the zones that are added and removed at runtime, because they are listed in a catalog zone.
+type members struct {
+	Z     map[string]*file.Zone
+	names []string
+
+	sync.RWMutex
+}
+
+func newMembers() *members { return &members{Z: make(map[string]*file.Zone)} }
+
+// Names returns the names of all member zones.
+func (m *members) Names() []string {
+	m.RLock()
+	n := m.names
+	m.RUnlock()
+	return n
+}
+
+// Zone returns the member zone with origin name, nil when not found.
+func (m *members) Zone(name string) *file.Zone {
+	m.RLock()
+	z := m.Z[name]
+	m.RUnlock()
+	return z
+}
+
+// add adds z as a member zone, it starts retrieving the zone from its primaries and keeps it up to date.
+func (m *members) add(name string, z *file.Zone) {
+	m.Lock()
+	m.Z[name] = z
+	m.names = append(m.names, name)
+	m.Unlock()
+
+	go func() {
+		z.TransferIn()
+		z.Update()
+	}()
+}
+
+// remove removes the member zone name and stops updating it.
+func (m *members) remove(name string) {
+	m.Lock()
+	if z, ok := m.Z[name]; ok {
+		z.OnShutdown()
+	}
+	delete(m.Z, name)
+	file.DeleteZoneMetrics(name)
+
+	names := make([]string, 0, len(m.Z))
+	for n := range m.Z {
+		names = append(names, n)
+	}
+	m.names = names
+	m.Unlock()
+}
+
+// catalog is a catalog zone (RFC 9432). Each time the catalog zone is transferred the member zones it lists
+// are added as secondary zones, and zones that are no longer listed are removed. Member zones are transferred
+// from the same primaries as the catalog zone.
+type catalog struct {
+	origin  string
+	zone    *file.Zone
+	static  map[string]*file.Zone // zones configured in the Corefile, these are never added as members
+	members *members
+
+	sync.Mutex
+	owned map[string]struct{} // member zones added by this catalog
+}
+
+func newCatalog(origin string, z *file.Zone, static map[string]*file.Zone, m *members) *catalog {
+	return &catalog{origin: origin, zone: z, static: static, members: m, owned: make(map[string]struct{})}
+}
+
+// update synchronizes the member zones with the member zones listed in the catalog zone.
+func (c *catalog) update() {
+	listed, err := memberZones(c.zone, c.origin)
+	if err != nil {
+		log.Errorf("Catalog zone %q: %s", c.origin, err)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	for name := range listed {
+		if _, ok := c.owned[name]; ok {
+			continue
+		}
+		if _, ok := c.static[name]; ok {
+			log.Warningf("Catalog zone %q: member zone %q is already configured, skipping", c.origin, name)
+			continue
+		}
+		if c.members.Zone(name) != nil {
+			log.Warningf("Catalog zone %q: member zone %q is already a member of another catalog, skipping", c.origin, name)
+			continue
+		}
+
+		z := file.NewZone(name, "stdin")
+		z.TransferFrom = c.zone.TransferFrom
+		z.TransferTo = c.zone.TransferTo
+		z.Upstream = c.zone.Upstream
+		if c.zone.Journal != nil {
+			z.Journal = file.NewJournal("")
+		}
+		c.owned[name] = struct{}{}
+		c.members.add(name, z)
+		log.Infof("Catalog zone %q: adding member zone %q", c.origin, name)
+	}
+
+	for name := range c.owned {
+		if _, ok := listed[name]; ok {
+			continue
+		}
+		delete(c.owned, name)
+		c.members.remove(name)
+		log.Infof("Catalog zone %q: removing member zone %q", c.origin, name)
+	}
+}
+
+// memberZones returns the member zones listed in the catalog zone z. Only version "2" catalog zones are supported.
+func memberZones(z *file.Zone, origin string) (map[string]struct{}, error) {
+	z.RLock()
+	defer z.RUnlock()
+
+	version := ""
+	if e, _ := z.Tree.Search("version." + origin); e != nil {
+		for _, rr := range e.Type(dns.TypeTXT) {
+			if txt := rr.(*dns.TXT).Txt; len(txt) == 1 {
+				version = txt[0]
+			}
+		}
+	}
+	if version != "2" {
+		return nil, fmt.Errorf("unsupported catalog zone version %q", version)
+	}
+
+	suffix := ".zones." + origin
+	labels := dns.CountLabel(origin) + 2
+
+	listed := map[string]struct{}{}
+	z.Tree.Walk(func(e *tree.Elem, rrs map[uint16][]dns.RR) error {
+		name := e.Name()
+		// Member zones are listed as <unique-N>.zones.<catalog> PTR <member zone>, the names below
+		// <unique-N> are properties of the member zone, we don't support those.
+		if !strings.HasSuffix(name, suffix) || dns.CountLabel(name) != labels {
+			return nil
+		}
+		ptr := rrs[dns.TypePTR]
+		if len(ptr) != 1 {
+			log.Warningf("Catalog zone %q: member node %q must have exactly one PTR record, skipping", origin, name)
+			return nil
+		}
+		listed[strings.ToLower(ptr[0].(*dns.PTR).Ptr)] = struct{}{}
+		return nil
+	})
+	return listed, nil
+}
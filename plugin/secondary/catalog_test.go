@@ -0,0 +1,115 @@
+package secondary
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+const dbCatalog = `
+$ORIGIN catalog.invalid.
+@                 0 IN SOA invalid. invalid. 1 60 60 604800 0
+@                 0 IN NS  invalid.
+version           0 IN TXT "2"
+a.zones           0 IN PTR example.org.
+b.zones           0 IN PTR example.net.
+group.b.zones     0 IN TXT "ignored"
+`
+
+// primary answers SOA queries and AXFR requests for any zone.
+func primary(w dns.ResponseWriter, r *dns.Msg) {
+	zone := r.Question[0].Name
+	soa := test.SOA(fmt.Sprintf("%s 3600 IN SOA ns.%s admin.%s 1 3600 600 86400 60", zone, zone, zone))
+	m := new(dns.Msg)
+	m.SetReply(r)
+	switch r.Question[0].Qtype {
+	case dns.TypeSOA:
+		m.Answer = []dns.RR{soa}
+	case dns.TypeAXFR:
+		m.Answer = []dns.RR{soa, test.A(fmt.Sprintf("www.%s 3600 IN A 127.0.0.1", zone)), soa}
+	}
+	w.WriteMsg(m)
+}
+
+func TestMemberZones(t *testing.T) {
+	z, err := file.Parse(strings.NewReader(dbCatalog), "catalog.invalid.", "stdin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := memberZones(z, "catalog.invalid.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("Expected %d member zones, got %d", 2, len(listed))
+	}
+	for _, name := range []string{"example.org.", "example.net."} {
+		if _, ok := listed[name]; !ok {
+			t.Errorf("Expected %q to be a member zone", name)
+		}
+	}
+
+	z, _ = file.Parse(strings.NewReader(strings.Replace(dbCatalog, `"2"`, `"1"`, 1)), "catalog.invalid.", "stdin", 0)
+	if _, err := memberZones(z, "catalog.invalid."); err == nil {
+		t.Errorf("Expected error for unsupported catalog version")
+	}
+}
+
+func TestCatalogUpdate(t *testing.T) {
+	s := dnstest.NewServer(primary)
+	defer s.Close()
+
+	z, err := file.Parse(strings.NewReader(dbCatalog), "catalog.invalid.", "stdin", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z.TransferFrom = []string{s.Addr}
+
+	static := map[string]*file.Zone{"catalog.invalid.": z, "example.net.": file.NewZone("example.net.", "stdin")}
+	m := newMembers()
+	c := newCatalog("catalog.invalid.", z, static, m)
+	c.update()
+	defer func() {
+		for _, n := range m.Names() {
+			m.remove(n)
+		}
+	}()
+
+	// example.net. is configured statically.
+	if x := m.Names(); len(x) != 1 || x[0] != "example.org." {
+		t.Fatalf("Expected example.org. as only member zone, got %v", x)
+	}
+
+	sec := Secondary{File: file.File{Next: test.ErrorHandler(), Zones: file.Zones{Z: static, Names: []string{"catalog.invalid.", "example.net."}}}, members: m}
+	var rec *dnstest.Recorder
+	for i := 0; i < 20; i++ {
+		req := new(dns.Msg)
+		req.SetQuestion("www.example.org.", dns.TypeA)
+		rec = dnstest.NewRecorder(&test.ResponseWriter{})
+		sec.ServeDNS(context.TODO(), rec, req)
+		if rec.Msg != nil && len(rec.Msg.Answer) == 1 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+		t.Fatalf("Expected answer for www.example.org. from member zone, got %v", rec.Msg)
+	}
+
+	// Remove example.org. from the catalog.
+	c.zone.Lock()
+	c.zone.Tree.Delete(test.PTR("a.zones.catalog.invalid. 0 IN PTR example.org."))
+	c.zone.Unlock()
+	c.update()
+	if x := m.Names(); len(x) != 0 {
+		t.Errorf("Expected no member zones, got %v", x)
+	}
+}
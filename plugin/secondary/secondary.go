@@ -1,10 +1,56 @@
 // Package secondary implements a secondary plugin.
 package secondary
 
-import "github.com/coredns/coredns/plugin/file"
+import (
+	"context"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+var log = clog.NewWithPlugin("secondary")
 
 // Secondary implements a secondary plugin that allows CoreDNS to retrieve (via AXFR)
 // zone information from a primary server.
 type Secondary struct {
 	file.File
+	members *members // zones added from catalog zones
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (s Secondary) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+	if z, zone := s.member(state.Name()); z != nil {
+		f := file.File{Next: s.Next, Zones: file.Zones{Z: map[string]*file.Zone{zone: z}, Names: []string{zone}}}
+		return f.ServeDNS(ctx, w, r)
+	}
+	return s.File.ServeDNS(ctx, w, r)
+}
+
+// Transfer implements the transfer.Transferer interface.
+func (s Secondary) Transfer(zone string, serial uint32) (<-chan []dns.RR, error) {
+	if z, name := s.member(zone); z != nil && name == zone {
+		f := file.File{Zones: file.Zones{Z: map[string]*file.Zone{zone: z}, Names: []string{zone}}}
+		return f.Transfer(zone, serial)
+	}
+	return s.File.Transfer(zone, serial)
+}
+
+// member returns the member zone for qname, if that is a better match than the configured zones.
+func (s Secondary) member(qname string) (*file.Zone, string) {
+	if s.members == nil {
+		return nil, ""
+	}
+	zone := plugin.Zones(s.members.Names()).Matches(qname)
+	if zone == "" {
+		return nil, ""
+	}
+	if static := plugin.Zones(s.Names).Matches(qname); len(static) > len(zone) {
+		return nil, ""
+	}
+	return s.members.Zone(zone), zone
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/metrics"
 	"github.com/coredns/coredns/plugin/pkg/parse"
 	"github.com/coredns/coredns/plugin/pkg/upstream"
 
@@ -13,11 +14,22 @@ import (
 func init() { plugin.Register("secondary", setup) }
 
 func setup(c *caddy.Controller) error {
-	zones, err := secondaryParse(c)
+	zones, catalogs, err := secondaryParse(c)
 	if err != nil {
 		return plugin.Error("secondary", err)
 	}
 
+	c.OnStartup(func() error {
+		metrics.MustRegister(c, file.TransferCount, file.LastRefresh, file.SOASerial, file.ExpiredZone)
+		return nil
+	})
+
+	m := newMembers()
+	for _, n := range catalogs {
+		z := zones.Z[n]
+		z.OnTransfer = newCatalog(n, z, zones.Z, m).update
+	}
+
 	// Add startup functions to retrieve the zone and keep it up to date.
 	for _, n := range zones.Names {
 		z := zones.Z[n]
@@ -31,19 +43,27 @@ func setup(c *caddy.Controller) error {
 				})
 				return nil
 			})
+			c.OnShutdown(z.OnShutdown)
 		}
 	}
+	c.OnShutdown(func() error {
+		for _, n := range m.Names() {
+			m.remove(n)
+		}
+		return nil
+	})
 
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
-		return Secondary{file.File{Next: next, Zones: zones}}
+		return Secondary{File: file.File{Next: next, Zones: zones}, members: m}
 	})
 
 	return nil
 }
 
-func secondaryParse(c *caddy.Controller) (file.Zones, error) {
+func secondaryParse(c *caddy.Controller) (file.Zones, []string, error) {
 	z := make(map[string]*file.Zone)
 	names := []string{}
+	catalogs := []string{}
 	upstr := upstream.New()
 	for c.Next() {
 
@@ -70,13 +90,25 @@ func secondaryParse(c *caddy.Controller) (file.Zones, error) {
 				case "transfer":
 					t, f, e = parse.Transfer(c, true)
 					if e != nil {
-						return file.Zones{}, e
+						return file.Zones{}, nil, e
+					}
+				case "journal":
+					if c.NextArg() {
+						return file.Zones{}, nil, c.ArgErr()
+					}
+					for _, origin := range origins {
+						z[origin].Journal = file.NewJournal("")
+					}
+				case "catalog":
+					if c.NextArg() {
+						return file.Zones{}, nil, c.ArgErr()
 					}
+					catalogs = append(catalogs, origins...)
 				case "upstream":
 					// remove soon
 					c.RemainingArgs()
 				default:
-					return file.Zones{}, c.Errf("unknown property '%s'", c.Val())
+					return file.Zones{}, nil, c.Errf("unknown property '%s'", c.Val())
 				}
 
 				for _, origin := range origins {
@@ -91,5 +123,5 @@ func secondaryParse(c *caddy.Controller) (file.Zones, error) {
 			}
 		}
 	}
-	return file.Zones{Z: z, Names: names}, nil
+	return file.Zones{Z: z, Names: names}, catalogs, nil
 }
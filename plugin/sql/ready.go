@@ -0,0 +1,4 @@
+package sql
+
+// Ready implements the ready.Readiness interface.
+func (s *SQL) Ready() bool { return s.zones.Ready() }
@@ -0,0 +1,126 @@
+package sql
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	clog "github.com/coredns/coredns/plugin/pkg/log"
+
+	"github.com/caddyserver/caddy"
+)
+
+var log = clog.NewWithPlugin("sql")
+
+func init() { plugin.Register("sql", setup) }
+
+const (
+	defaultRefresh = 30 * time.Second
+	defaultQuery   = "SELECT name, ttl, type, content FROM records WHERE zone = ?"
+	// PostgreSQL numbers its placeholders.
+	defaultPostgresQuery = "SELECT name, ttl, type, content FROM records WHERE zone = $1"
+)
+
+type config struct {
+	zones  []string
+	driver string
+	dsn    string
+	query  string
+	opts   cloud.Options
+}
+
+func setup(c *caddy.Controller) error {
+	for c.Next() {
+		cfg, err := parse(c)
+		if err != nil {
+			return plugin.Error("sql", err)
+		}
+
+		db, err := sql.Open(cfg.driver, cfg.dsn)
+		if err != nil {
+			return plugin.Error("sql", err)
+		}
+		s := New(db, cfg.query, cfg.zones, cfg.opts.Refresh)
+		s.Fall = cfg.opts.Fall
+
+		if err := cloud.Run(c, s.Run); err != nil {
+			db.Close()
+			return plugin.Error("sql", c.Errf("failed to load zones: %v", err))
+		}
+		c.OnShutdown(func() error { return db.Close() })
+
+		dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+			s.Next = next
+			return s
+		})
+	}
+	return nil
+}
+
+func parse(c *caddy.Controller) (config, error) {
+	cfg := config{zones: c.RemainingArgs(), opts: cloud.Options{Refresh: defaultRefresh}}
+	if len(cfg.zones) == 0 {
+		return cfg, c.ArgErr()
+	}
+	for i := range cfg.zones {
+		cfg.zones[i] = plugin.Host(cfg.zones[i]).Normalize()
+	}
+
+	for c.NextBlock() {
+		if ok, err := cfg.opts.Parse(c); ok {
+			if err != nil {
+				return cfg, err
+			}
+			continue
+		}
+		switch c.Val() {
+		case "driver":
+			if !c.NextArg() {
+				return cfg, c.ArgErr()
+			}
+			cfg.driver = c.Val()
+		case "dsn":
+			if !c.NextArg() {
+				return cfg, c.ArgErr()
+			}
+			cfg.dsn = c.Val()
+		case "query":
+			if !c.NextArg() {
+				return cfg, c.ArgErr()
+			}
+			cfg.query = c.Val()
+		default:
+			return cfg, c.Errf("unknown property '%s'", c.Val())
+		}
+	}
+
+	if cfg.driver == "" || cfg.dsn == "" {
+		return cfg, c.Err("driver and dsn are required")
+	}
+	if !registered(cfg.driver) {
+		drivers := sql.Drivers()
+		sort.Strings(drivers)
+		return cfg, c.Errf("driver %q is not compiled in, available drivers: %s", cfg.driver, strings.Join(drivers, ", "))
+	}
+	if cfg.query == "" {
+		cfg.query = defaultQuery
+		if cfg.driver == "postgres" || cfg.driver == "pgx" {
+			cfg.query = defaultPostgresQuery
+		}
+	}
+	return cfg, nil
+}
+
+// registered returns true if the database driver name is registered.
+func registered(name string) bool {
+	for _, d := range sql.Drivers() {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
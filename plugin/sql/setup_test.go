@@ -0,0 +1,98 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetupSQL(t *testing.T) {
+	setRecords("TestSetupSQL", "example.org", [][]driver.Value{
+		{"@", int64(3600), "SOA", "ns1 hostmaster 1 7200 3600 1209600 300"},
+	})
+
+	tests := []struct {
+		body          string
+		expectedError bool
+	}{
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+}`, false},
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+    query "SELECT name, ttl, type, content FROM dns WHERE domain = ?"
+    refresh 90
+    fallthrough
+}`, false},
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+    refresh 5m
+}`, false},
+		{`sql {
+    driver sqltest
+    dsn TestSetupSQL
+}`, true},
+		{`sql example.org {
+    dsn TestSetupSQL
+}`, true},
+		{`sql example.org {
+    driver sqltest
+}`, true},
+		{`sql example.org {
+    driver nosuchdriver
+    dsn TestSetupSQL
+}`, true},
+		{`sql example.com {
+    driver sqltest
+    dsn TestSetupSQL
+}`, true},
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+    refresh 0
+}`, true},
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+    refresh
+}`, true},
+		{`sql example.org {
+    driver sqltest
+    dsn TestSetupSQL
+    wat
+}`, true},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.body)
+		if err := setup(c); (err == nil) == tc.expectedError {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.expectedError, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	c := caddy.NewTestController("dns", `sql Example.ORG example.net {
+    driver sqltest
+    dsn TestParse
+}`)
+	c.Next()
+	cfg, err := parse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cfg.zones) != 2 || cfg.zones[0] != "example.org." || cfg.zones[1] != "example.net." {
+		t.Errorf("Expected zones [example.org. example.net.], got %v", cfg.zones)
+	}
+	if cfg.query != defaultQuery {
+		t.Errorf("Expected query %q, got %q", defaultQuery, cfg.query)
+	}
+	if cfg.opts.Refresh != 30*time.Second {
+		t.Errorf("Expected refresh %s, got %s", 30*time.Second, cfg.opts.Refresh)
+	}
+}
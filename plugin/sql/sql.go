@@ -0,0 +1,114 @@
+// Package sql implements a plugin that serves zones from records stored in a SQL database.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cloud"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+
+	"github.com/miekg/dns"
+)
+
+// SQL is a plugin that serves zones from records in a SQL database.
+type SQL struct {
+	Next plugin.Handler
+	Fall fall.F
+
+	db    *sql.DB
+	query string
+	zones *cloud.Zones
+}
+
+// defaultTTL is the TTL of records whose TTL is NULL.
+const defaultTTL = 3600
+
+// New returns a new *SQL that serves zones from db. The records of a zone are selected with query, which is given
+// the name of the zone without the trailing dot as its only argument, and must return the name, TTL, type and content
+// of each record. The zones are reloaded every refresh.
+func New(db *sql.DB, query string, zones []string, refresh time.Duration) *SQL {
+	s := &SQL{db: db, query: query}
+	s.zones = cloud.New("sql", s, refresh)
+	for _, z := range zones {
+		z = dns.Fqdn(strings.ToLower(z))
+		s.zones.Add(z, z)
+	}
+	return s
+}
+
+// Run executes first update, spins up an update forever-loop.
+// Returns error if first update fails.
+func (s *SQL) Run(ctx context.Context) error { return s.zones.Run(ctx) }
+
+// ServeDNS implements the plugin.Handler interface.
+func (s *SQL) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	return s.zones.ServeDNS(ctx, w, r, s.Next, s.Fall)
+}
+
+// Records implements cloud.Provider, it selects the records of the zone id from the database.
+func (s *SQL) Records(ctx context.Context, id string) ([]dns.RR, error) {
+	rows, err := s.db.QueryContext(ctx, s.query, strings.TrimSuffix(id, "."))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rrs []dns.RR
+	for rows.Next() {
+		var (
+			name, typ, content string
+			ttl                sql.NullInt64
+		)
+		if err := rows.Scan(&name, &ttl, &typ, &content); err != nil {
+			return nil, err
+		}
+		if !ttl.Valid {
+			ttl.Int64 = defaultTTL
+		}
+		rr, err := parseRecord(id, name, uint32(ttl.Int64), typ, content)
+		if err != nil {
+			// Maybe unsupported record type. Log and carry on.
+			log.Warningf("Failed to parse record in zone %s: %v", id, err)
+			continue
+		}
+		rrs = append(rrs, rr)
+	}
+	return rrs, rows.Err()
+}
+
+// parseRecord returns the record with name, ttl, type and content in zone origin. The name can be fully qualified, with or
+// without the trailing dot, relative to origin, or "@" for origin itself. Names in the content are interpreted like
+// in a zone file: they must be fully qualified with a trailing dot, or are relative to origin.
+func parseRecord(origin, name string, ttl uint32, typ, content string) (dns.RR, error) {
+	name = owner(origin, name)
+	zp := dns.NewZoneParser(strings.NewReader(fmt.Sprintf("%s %d IN %s %s", name, ttl, typ, content)), origin, "")
+	rr, ok := zp.Next()
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+	if !ok || rr == nil {
+		return nil, fmt.Errorf("empty record for %s %s", name, typ)
+	}
+	return rr, nil
+}
+
+// owner returns the fully qualified owner name of a record with name in zone origin.
+func owner(origin, name string) string {
+	switch {
+	case name == "" || name == "@":
+		return origin
+	case dns.IsFqdn(name):
+		return name
+	case dns.IsSubDomain(origin, dns.Fqdn(name)):
+		return dns.Fqdn(name)
+	}
+	return name + "." + origin
+}
+
+// Name implements the Handler interface.
+func (s *SQL) Name() string { return "sql" }
@@ -0,0 +1,231 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/pkg/fall"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseRecord(t *testing.T) {
+	tests := []struct {
+		name, typ, content string
+		expected           string // empty if an error is expected
+	}{
+		{"@", "A", "1.2.3.4", "example.org.	300	IN	A	1.2.3.4"},
+		{"", "AAAA", "::1", "example.org.	300	IN	AAAA	::1"},
+		{"www", "A", "1.2.3.4", "www.example.org.	300	IN	A	1.2.3.4"},
+		{"www.example.org", "A", "1.2.3.4", "www.example.org.	300	IN	A	1.2.3.4"},
+		{"www.example.org.", "A", "1.2.3.4", "www.example.org.	300	IN	A	1.2.3.4"},
+		{"a.b", "A", "1.2.3.4", "a.b.example.org.	300	IN	A	1.2.3.4"},
+		{"*", "A", "1.2.3.4", "*.example.org.	300	IN	A	1.2.3.4"},
+		{"@", "MX", "10 mail", "example.org.	300	IN	MX	10 mail.example.org."},
+		{"@", "MX", "10 mail.example.net.", "example.org.	300	IN	MX	10 mail.example.net."},
+		{"txt", "TXT", `"hello world"`, "txt.example.org.	300	IN	TXT	\"hello world\""},
+		{"@", "YOLO", "foobar", ""},
+		{"@", "A", "not-an-address", ""},
+	}
+	for i, tc := range tests {
+		rr, err := parseRecord("example.org.", tc.name, 300, tc.typ, tc.content)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got %s", i, rr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if rr.String() != tc.expected {
+			t.Errorf("Test %d: expected %q, got %q", i, tc.expected, rr.String())
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	db := newTestDB(t, "TestSQL")
+	setRecords("TestSQL", "example.org", [][]driver.Value{
+		{"@", int64(3600), "SOA", "ns1 hostmaster 1 7200 3600 1209600 300"},
+		{"@", int64(3600), "NS", "ns1"},
+		{"ns1", int64(3600), "A", "10.0.0.53"},
+		{"www", int64(300), "A", "1.2.3.4"},
+		{"www", int64(300), "RRSIG", "A 8 3 300 20300101000000 20200101000000 12345 example.org. c2lnbmF0dXJl"},
+		{"*.wild", int64(300), "A", "1.2.3.5"},
+		{"alias", int64(300), "CNAME", "www"},
+		{"@", nil, "MX", "10 mail.example.net."},
+	})
+
+	s := New(db, defaultQuery, []string{"example.org."}, 10*time.Millisecond)
+	s.Fall = fall.Zero
+	s.Next = test.NextHandler(dns.RcodeRefused, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Ready() {
+		t.Errorf("Expected plugin to be ready")
+	}
+
+	tests := []struct {
+		qname      string
+		qtype      uint16
+		do         bool
+		wantRcode  int
+		wantAnswer []string
+	}{
+		{"www.example.org.", dns.TypeA, false, dns.RcodeSuccess, []string{"www.example.org.	300	IN	A	1.2.3.4"}},
+		{"www.example.org.", dns.TypeA, true, dns.RcodeSuccess, []string{
+			"www.example.org.	300	IN	A	1.2.3.4",
+			"www.example.org.	300	IN	RRSIG	A 8 3 300 20300101000000 20200101000000 12345 example.org. c2lnbmF0dXJl",
+		}},
+		{"foo.wild.example.org.", dns.TypeA, false, dns.RcodeSuccess, []string{"foo.wild.example.org.	300	IN	A	1.2.3.5"}},
+		{"alias.example.org.", dns.TypeA, false, dns.RcodeSuccess, []string{"alias.example.org.	300	IN	CNAME	www.example.org.", "www.example.org.	300	IN	A	1.2.3.4"}},
+		{"example.org.", dns.TypeMX, false, dns.RcodeSuccess, []string{"example.org.	3600	IN	MX	10 mail.example.net."}},
+		{"none.example.org.", dns.TypeA, false, dns.RcodeNameError, nil},
+		{"example.net.", dns.TypeA, false, dns.RcodeRefused, nil},
+	}
+	for i, tc := range tests {
+		if answer := lookup(t, s, tc.qname, tc.qtype, tc.do, tc.wantRcode); !equal(answer, tc.wantAnswer) {
+			t.Errorf("Test %d: expected answer %v, got %v", i, tc.wantAnswer, answer)
+		}
+	}
+
+	// Changes in the database go live with the next poll.
+	setRecords("TestSQL", "example.org", [][]driver.Value{
+		{"@", int64(3600), "SOA", "ns1 hostmaster 2 7200 3600 1209600 300"},
+		{"www", int64(300), "A", "1.2.3.6"},
+	})
+	want := []string{"www.example.org.	300	IN	A	1.2.3.6"}
+	for i := 0; ; i++ {
+		if equal(lookup(t, s, "www.example.org.", dns.TypeA, false, dns.RcodeSuccess), want) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected changed record to be served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// lookup returns the answer to a query for qname and qtype, and checks its rcode is rcode.
+func lookup(t *testing.T, s *SQL, qname string, qtype uint16, do bool, rcode int) []string {
+	m := new(dns.Msg)
+	m.SetQuestion(qname, qtype)
+	if do {
+		m.SetEdns0(4096, true)
+	}
+	rec := dnstest.NewRecorder(&test.ResponseWriter{})
+	code, err := s.ServeDNS(context.Background(), rec, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != nil {
+		code = rec.Msg.Rcode
+	}
+	if code != rcode {
+		t.Errorf("Expected rcode %s for %s, got %s", dns.RcodeToString[rcode], qname, dns.RcodeToString[code])
+		return nil
+	}
+	if rec.Msg == nil {
+		return nil
+	}
+	answer := []string{}
+	for _, rr := range rec.Msg.Answer {
+		answer = append(answer, rr.String())
+	}
+	return answer
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// testDriver is a database/sql driver for testing, that returns the rows set with setRecords for any query. The zone
+// is the only argument of a query. The data source name selects the set of records.
+type testDriver struct{}
+
+var testRecords = struct {
+	sync.Mutex
+	m map[string]map[string][][]driver.Value // rows by zone by data source name
+}{m: map[string]map[string][][]driver.Value{}}
+
+func init() { sql.Register("sqltest", testDriver{}) }
+
+func newTestDB(t *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("sqltest", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func setRecords(dsn, zone string, rows [][]driver.Value) {
+	testRecords.Lock()
+	defer testRecords.Unlock()
+	if testRecords.m[dsn] == nil {
+		testRecords.m[dsn] = map[string][][]driver.Value{}
+	}
+	testRecords.m[dsn][zone] = rows
+}
+
+func (testDriver) Open(dsn string) (driver.Conn, error) { return testConn{dsn}, nil }
+
+type testConn struct{ dsn string }
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) { return testStmt(c), nil }
+func (testConn) Close() error                                { return nil }
+func (testConn) Begin() (driver.Tx, error)                   { return nil, errors.New("transactions not supported") }
+
+type testStmt struct{ dsn string }
+
+func (testStmt) Close() error  { return nil }
+func (testStmt) NumInput() int { return 1 }
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec not supported")
+}
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	testRecords.Lock()
+	defer testRecords.Unlock()
+	zone, _ := args[0].(string)
+	rows, ok := testRecords.m[s.dsn][zone]
+	if !ok {
+		return nil, errors.New("no such zone: " + zone)
+	}
+	return &testRows{rows: rows}, nil
+}
+
+type testRows struct {
+	rows [][]driver.Value
+	i    int
+}
+
+func (*testRows) Columns() []string { return []string{"name", "ttl", "type", "content"} }
+func (*testRows) Close() error      { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
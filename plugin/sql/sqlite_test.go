@@ -0,0 +1,64 @@
+// +build sqlite
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/test"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/miekg/dns"
+)
+
+func TestSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file::memory:?cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"CREATE TABLE records (zone TEXT, name TEXT, ttl INTEGER, type TEXT, content TEXT)",
+		"INSERT INTO records VALUES ('example.org', '@', 3600, 'SOA', 'ns1 hostmaster 1 7200 3600 1209600 300')",
+		"INSERT INTO records VALUES ('example.org', 'www', 300, 'A', '1.2.3.4')",
+		"INSERT INTO records VALUES ('example.org', 'alias', NULL, 'CNAME', 'www')",
+		"INSERT INTO records VALUES ('example.net', 'www', 300, 'A', '5.6.7.8')",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("Failed to execute %q: %v", stmt, err)
+		}
+	}
+
+	s := New(db, defaultQuery, []string{"example.org."}, 10*time.Millisecond)
+	s.Next = test.NextHandler(dns.RcodeRefused, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if answer := lookup(t, s, "www.example.org.", dns.TypeA, false, dns.RcodeSuccess); !equal(answer, []string{"www.example.org.	300	IN	A	1.2.3.4"}) {
+		t.Errorf("Expected www.example.org. A 1.2.3.4, got %v", answer)
+	}
+	if answer := lookup(t, s, "alias.example.org.", dns.TypeCNAME, false, dns.RcodeSuccess); !equal(answer, []string{"alias.example.org.	3600	IN	CNAME	www.example.org."}) {
+		t.Errorf("Expected alias.example.org. CNAME www.example.org., got %v", answer)
+	}
+
+	if _, err := db.Exec("UPDATE records SET content = '1.2.3.5' WHERE zone = 'example.org' AND name = 'www'"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"www.example.org.	300	IN	A	1.2.3.5"}
+	for i := 0; ; i++ {
+		if equal(lookup(t, s, "www.example.org.", dns.TypeA, false, dns.RcodeSuccess), want) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("Expected changed record to be served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
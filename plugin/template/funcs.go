@@ -0,0 +1,143 @@
+package template
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	gotmpl "text/template"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+
+	"github.com/miekg/dns"
+)
+
+// maxSeq is the maximum number of elements seq returns, to bound the size of responses built from it.
+const maxSeq = 1024
+
+// funcMap holds the functions that can be used in templates, in addition to the builtin ones.
+var funcMap = gotmpl.FuncMap{
+	"parseIP":     parseIP,
+	"addIP":       addIP,
+	"inNet":       inNet,
+	"reverse":     reverse,
+	"fromReverse": fromReverse,
+	"seq":         seq,
+	"atoi":        strconv.Atoi,
+	"split":       strings.Split,
+	"join":        strings.Join,
+}
+
+// parseIP parses s as an IP address, and returns it in its canonical form. Next to the usual notations it accepts
+// dashes instead of dots or colons (10-0-0-1, 2001-db8--1) and hex (0a000001). A network (192.0.2.0/24) returns its
+// address. It returns an empty string if s isn't an address.
+func parseIP(s string) string {
+	if ip := ipOf(s); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+func ipOf(s string) net.IP {
+	if ip, _, err := net.ParseCIDR(s); err == nil {
+		return ip
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		return ip
+	}
+	if strings.Count(s, "-") == 3 {
+		if ip := net.ParseIP(strings.Replace(s, "-", ".", -1)); ip.To4() != nil {
+			return ip
+		}
+	}
+	if strings.Contains(s, "-") {
+		if ip := net.ParseIP(strings.Replace(s, "-", ":", -1)); ip != nil {
+			return ip
+		}
+	}
+	if len(s) == 2*net.IPv4len || len(s) == 2*net.IPv6len {
+		if b, err := hex.DecodeString(s); err == nil {
+			return net.IP(b)
+		}
+	}
+	return nil
+}
+
+// addIP returns the address n addresses after the address s, or before it if n is negative. It returns an empty
+// string if s isn't an address or the result isn't in the address family of s.
+func addIP(s string, n int) string {
+	ip := ipOf(s)
+	if ip == nil {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	i := new(big.Int).SetBytes(ip)
+	i.Add(i, big.NewInt(int64(n)))
+	if i.Sign() < 0 || i.BitLen() > 8*len(ip) {
+		return ""
+	}
+	b := i.Bytes()
+	sum := make(net.IP, len(ip))
+	copy(sum[len(sum)-len(b):], b)
+	return sum.String()
+}
+
+// inNet returns true if the address s is in the network cidr.
+func inNet(s, cidr string) (bool, error) {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+	ip := ipOf(s)
+	return ip != nil && n.Contains(ip), nil
+}
+
+// reverse returns the name of the address s in the reverse zones, e.g. 1.0.0.10.in-addr.arpa. for 10.0.0.1. It
+// returns an empty string if s isn't an address.
+func reverse(s string) string {
+	ip := ipOf(s)
+	if ip == nil {
+		return ""
+	}
+	name, err := dns.ReverseAddr(ip.String())
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// fromReverse returns the address of the name in the reverse zones, it's the opposite of reverse. It returns an empty
+// string if name isn't the name of an address.
+func fromReverse(name string) string {
+	return dnsutil.ExtractAddressFromReverse(dns.Fqdn(name))
+}
+
+// seq returns the integers from 1 up to and including last, or from first up to and including last when given two
+// arguments, for use with range.
+func seq(args ...int) ([]int, error) {
+	first, last := 1, 0
+	switch len(args) {
+	case 1:
+		last = args[0]
+	case 2:
+		first, last = args[0], args[1]
+	default:
+		return nil, fmt.Errorf("seq takes one or two arguments, got %d", len(args))
+	}
+	if last < first {
+		return nil, nil
+	}
+	// As unsigned integers the difference can't overflow, even between the extremes of int.
+	if uint(last)-uint(first) >= maxSeq {
+		return nil, fmt.Errorf("seq of more than %d integers", maxSeq)
+	}
+	s := make([]int, 0, last-first+1)
+	for i := 0; i <= last-first; i++ {
+		s = append(s, first+i)
+	}
+	return s, nil
+}
@@ -0,0 +1,122 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"10.0.0.1", "10.0.0.1"},
+		{"10-0-0-1", "10.0.0.1"},
+		{"0a000001", "10.0.0.1"},
+		{"192.0.2.0/24", "192.0.2.0"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"2001-db8--1", "2001:db8::1"},
+		{"20010db8000000000000000000000001", "2001:db8::1"},
+		{"10-0-0", ""},
+		{"10-0-0-256", ""},
+		{"0a0000", ""},
+		{"example", ""},
+		{"", ""},
+	}
+	for i, tc := range tests {
+		if got := parseIP(tc.in); got != tc.expected {
+			t.Errorf("Test %d: expected %q for %q, got %q", i, tc.expected, tc.in, got)
+		}
+	}
+}
+
+func TestAddIP(t *testing.T) {
+	tests := []struct {
+		in       string
+		n        int
+		expected string
+	}{
+		{"10.0.0.1", 1, "10.0.0.2"},
+		{"10-0-0-255", 1, "10.0.1.0"},
+		{"10.0.1.0", -1, "10.0.0.255"},
+		{"255.255.255.255", 1, ""},
+		{"0.0.0.0", -1, ""},
+		{"2001:db8::ffff", 1, "2001:db8::1:0"},
+		{"example", 1, ""},
+	}
+	for i, tc := range tests {
+		if got := addIP(tc.in, tc.n); got != tc.expected {
+			t.Errorf("Test %d: expected %q for %q + %d, got %q", i, tc.expected, tc.in, tc.n, got)
+		}
+	}
+}
+
+func TestInNet(t *testing.T) {
+	if ok, err := inNet("10-1-2-3", "10.0.0.0/8"); !ok || err != nil {
+		t.Errorf("Expected 10-1-2-3 in 10.0.0.0/8, got %t, %v", ok, err)
+	}
+	if ok, err := inNet("192.0.2.1", "10.0.0.0/8"); ok || err != nil {
+		t.Errorf("Expected 192.0.2.1 not in 10.0.0.0/8, got %t, %v", ok, err)
+	}
+	if ok, err := inNet("example", "10.0.0.0/8"); ok || err != nil {
+		t.Errorf("Expected example not in 10.0.0.0/8, got %t, %v", ok, err)
+	}
+	if _, err := inNet("10.0.0.1", "10.0.0.0"); err == nil {
+		t.Errorf("Expected error for an invalid network")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		ip, name string
+	}{
+		{"10.0.0.1", "1.0.0.10.in-addr.arpa."},
+		{"2001:db8::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for i, tc := range tests {
+		if got := reverse(tc.ip); got != tc.name {
+			t.Errorf("Test %d: expected %q for %q, got %q", i, tc.name, tc.ip, got)
+		}
+		if got := fromReverse(tc.name); got != tc.ip {
+			t.Errorf("Test %d: expected %q for %q, got %q", i, tc.ip, tc.name, got)
+		}
+	}
+	if got := reverse("example"); got != "" {
+		t.Errorf("Expected no name for an invalid address, got %q", got)
+	}
+	if got := fromReverse("example.org."); got != "" {
+		t.Errorf("Expected no address for a name outside of the reverse zones, got %q", got)
+	}
+}
+
+func TestSeq(t *testing.T) {
+	const maxInt = int(^uint(0) >> 1)
+	const minInt = -maxInt - 1
+	tests := []struct {
+		args     []int
+		expected []int
+		err      bool
+	}{
+		{[]int{3}, []int{1, 2, 3}, false},
+		{[]int{0}, nil, false},
+		{[]int{2, 4}, []int{2, 3, 4}, false},
+		{[]int{4, 2}, nil, false},
+		{[]int{maxSeq + 1}, nil, true},
+		{[]int{}, nil, true},
+		{[]int{1, 2, 3}, nil, true},
+		{[]int{0, maxInt}, nil, true},
+		{[]int{minInt, 0}, nil, true},
+		{[]int{minInt, maxInt}, nil, true},
+		{[]int{maxInt, minInt}, nil, false},
+		{[]int{maxInt - 1, maxInt}, []int{maxInt - 1, maxInt}, false},
+	}
+	for i, tc := range tests {
+		got, err := seq(tc.args...)
+		if (err != nil) != tc.err {
+			t.Errorf("Test %d: expected error %t, got %v", i, tc.err, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i, tc.expected, got)
+		}
+	}
+}
@@ -48,7 +48,11 @@ type templateData struct {
 	Type     string
 	Message  *dns.Msg
 	Question *dns.Question
+	Remote   string
+	ECS      string
 	md       map[string]metadata.Func
+	nxdomain bool
+	nodata   bool
 }
 
 func (data *templateData) Meta(metaName string) string {
@@ -63,6 +67,38 @@ func (data *templateData) Meta(metaName string) string {
 	return ""
 }
 
+// EDNS0 returns the data of the EDNS0 option with code in the request, or an empty string if there is none.
+func (data *templateData) EDNS0(code int) string {
+	o := data.Message.IsEdns0()
+	if o == nil {
+		return ""
+	}
+	for _, s := range o.Option {
+		if int(s.Option()) != code {
+			continue
+		}
+		if e, ok := s.(*dns.EDNS0_LOCAL); ok {
+			return string(e.Data)
+		}
+		return s.String()
+	}
+	return ""
+}
+
+// NXDomain makes the response a NXDOMAIN, without answers. It returns an empty string, so it can be called from
+// anywhere in a template.
+func (data *templateData) NXDomain() string {
+	data.nxdomain, data.nodata = true, false
+	return ""
+}
+
+// NoData makes the response a NODATA, without answers. It returns an empty string, so it can be called from
+// anywhere in a template.
+func (data *templateData) NoData() string {
+	data.nxdomain, data.nodata = false, true
+	return ""
+}
+
 // ServeDNS implements the plugin.Handler interface.
 func (h Handler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
@@ -93,33 +129,47 @@ func (h Handler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		msg.Rcode = template.rcode
 
 		for _, answer := range template.answer {
-			rr, err := executeRRTemplate(metrics.WithServer(ctx), "answer", answer, data)
+			rrs, err := executeRRTemplate(metrics.WithServer(ctx), "answer", answer, data)
 			if err != nil {
 				return dns.RcodeServerFailure, err
 			}
-			msg.Answer = append(msg.Answer, rr)
-			if template.upstream != nil && (state.QType() == dns.TypeA || state.QType() == dns.TypeAAAA) && rr.Header().Rrtype == dns.TypeCNAME {
-				up, _ := template.upstream.Lookup(ctx, state, rr.(*dns.CNAME).Target, state.QType())
-				msg.Answer = append(msg.Answer, up.Answer...)
+			for _, rr := range rrs {
+				msg.Answer = append(msg.Answer, rr)
+				if template.upstream != nil && (state.QType() == dns.TypeA || state.QType() == dns.TypeAAAA) && rr.Header().Rrtype == dns.TypeCNAME {
+					up, _ := template.upstream.Lookup(ctx, state, rr.(*dns.CNAME).Target, state.QType())
+					msg.Answer = append(msg.Answer, up.Answer...)
+				}
 			}
 		}
 		for _, additional := range template.additional {
-			rr, err := executeRRTemplate(metrics.WithServer(ctx), "additional", additional, data)
+			rrs, err := executeRRTemplate(metrics.WithServer(ctx), "additional", additional, data)
 			if err != nil {
 				return dns.RcodeServerFailure, err
 			}
-			msg.Extra = append(msg.Extra, rr)
+			msg.Extra = append(msg.Extra, rrs...)
 		}
 		for _, authority := range template.authority {
-			rr, err := executeRRTemplate(metrics.WithServer(ctx), "authority", authority, data)
+			rrs, err := executeRRTemplate(metrics.WithServer(ctx), "authority", authority, data)
 			if err != nil {
 				return dns.RcodeServerFailure, err
 			}
-			msg.Ns = append(msg.Ns, rr)
+			msg.Ns = append(msg.Ns, rrs...)
+		}
+
+		// A template decided this is a NXDOMAIN or NODATA response, only the authority section is kept.
+		rcode := template.rcode
+		if data.nxdomain || data.nodata {
+			rcode = dns.RcodeSuccess
+			if data.nxdomain {
+				rcode = dns.RcodeNameError
+			}
+			msg.Rcode = rcode
+			msg.Answer = nil
+			msg.Extra = nil
 		}
 
 		w.WriteMsg(msg)
-		return template.rcode, nil
+		return rcode, nil
 	}
 
 	return plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
@@ -128,19 +178,25 @@ func (h Handler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 // Name implements the plugin.Handler interface.
 func (h Handler) Name() string { return "template" }
 
-func executeRRTemplate(server, section string, template *gotmpl.Template, data *templateData) (dns.RR, error) {
+// executeRRTemplate executes template and parses its output, which has a resource record on each line. The output
+// may be empty.
+func executeRRTemplate(server, section string, template *gotmpl.Template, data *templateData) ([]dns.RR, error) {
 	buffer := &bytes.Buffer{}
 	err := template.Execute(buffer, data)
 	if err != nil {
 		templateFailureCount.WithLabelValues(server, data.Zone, data.Class, data.Type, section, template.Tree.Root.String()).Inc()
 		return nil, err
 	}
-	rr, err := dns.NewRR(buffer.String())
-	if err != nil {
+	var rrs []dns.RR
+	zp := dns.NewZoneParser(buffer, ".", "")
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		rrs = append(rrs, rr)
+	}
+	if err := zp.Err(); err != nil {
 		templateRRFailureCount.WithLabelValues(server, data.Zone, data.Class, data.Type, section, template.Tree.Root.String()).Inc()
-		return rr, err
+		return nil, err
 	}
-	return rr, nil
+	return rrs, nil
 }
 
 func (t template) match(ctx context.Context, state request.Request) (*templateData, bool, bool) {
@@ -169,6 +225,8 @@ func (t template) match(ctx context.Context, state request.Request) (*templateDa
 		data.Name = state.Name()
 		data.Question = &q
 		data.Message = state.Req
+		data.Remote = state.IP()
+		data.ECS = ecs(state.Req)
 		if q.Qclass != dns.ClassANY {
 			data.Class = dns.ClassToString[q.Qclass]
 		} else {
@@ -199,3 +257,18 @@ func (t template) match(ctx context.Context, state request.Request) (*templateDa
 
 	return data, false, t.fall.Through(state.Name())
 }
+
+// ecs returns the client subnet of the EDNS0 subnet option in r, e.g. 192.0.2.0/24, or an empty string if there is
+// none.
+func ecs(r *dns.Msg) string {
+	o := r.IsEdns0()
+	if o == nil {
+		return ""
+	}
+	for _, s := range o.Option {
+		if e, ok := s.(*dns.EDNS0_SUBNET); ok {
+			return e.Address.String() + "/" + strconv.Itoa(int(e.SourceNetmask))
+		}
+	}
+	return ""
+}
@@ -565,4 +565,100 @@ func TestMultiSection(t *testing.T) {
 	}
 }
 
+// TestTemplateFuncs verifies the functions and data available to templates, multiple records from one template and
+// NXDOMAIN/NODATA responses decided by a template.
+func TestTemplateFuncs(t *testing.T) {
+	config := `template IN ANY example {
+			match "^(?P<n>[0-9]+)[.]pool[.]example[.]$"
+			answer "{{ range seq (atoi .Group.n) }}{{ $.Name }} 60 IN A {{ addIP \"10.0.0.0\" . }}
+{{ end }}"
+			fallthrough
+		}
+		template IN A example {
+			match "^(?P<ip>[0-9a-f]{8})[.]hex[.]example[.]$"
+			answer "{{ .Name }} 60 IN A {{ parseIP .Group.ip }}"
+			fallthrough
+		}
+		template IN A example {
+			match "^ip-(?P<ip>[0-9-]+)[.]example[.]$"
+			answer "{{ if not (parseIP .Group.ip) }}{{ .NXDomain }}{{ else if not (inNet .Group.ip \"10.0.0.0/8\") }}{{ .NoData }}{{ else }}{{ .Name }} 60 IN A {{ parseIP .Group.ip }}{{ end }}"
+			authority "example. 60 IN SOA ns.example. hostmaster.example. (1 60 60 60 60)"
+			fallthrough
+		}
+		template IN PTR example {
+			match "^ip-(?P<ip>[0-9-]+)[.]example[.]$"
+			answer "{{ .Name }} 60 IN PTR {{ reverse .Group.ip }}"
+			fallthrough
+		}
+		template IN TXT example {
+			match "^client[.]example[.]$"
+			answer "{{ .Name }} 60 IN TXT \"{{ .Remote }}\" \"{{ .ECS }}\" \"{{ .EDNS0 0xffee }}\""
+		}`
+	c := caddy.NewTestController("dns", config)
+	handler, err := templateParse(c)
+	if err != nil {
+		t.Fatalf("Could not parse config: %v", err)
+	}
+	handler.Next = test.NextHandler(rcodeFallthrough, nil)
+
+	tests := []struct {
+		qname        string
+		qtype        uint16
+		opts         []dns.EDNS0
+		expectedCode int
+		answer       []string
+		ns           int
+	}{
+		{"3.pool.example.", dns.TypeA, nil, dns.RcodeSuccess, []string{
+			"3.pool.example.	60	IN	A	10.0.0.1",
+			"3.pool.example.	60	IN	A	10.0.0.2",
+			"3.pool.example.	60	IN	A	10.0.0.3",
+		}, 0},
+		{"0.pool.example.", dns.TypeA, nil, dns.RcodeSuccess, nil, 0},
+		{"0a0b0c0d.hex.example.", dns.TypeA, nil, dns.RcodeSuccess, []string{"0a0b0c0d.hex.example.	60	IN	A	10.11.12.13"}, 0},
+		{"ip-10-1-2-3.example.", dns.TypeA, nil, dns.RcodeSuccess, []string{"ip-10-1-2-3.example.	60	IN	A	10.1.2.3"}, 1},
+		{"ip-192-0-2-1.example.", dns.TypeA, nil, dns.RcodeSuccess, nil, 1},
+		{"ip-10-1-2.example.", dns.TypeA, nil, dns.RcodeNameError, nil, 1},
+		{"ip-10-1-2-3.example.", dns.TypePTR, nil, dns.RcodeSuccess, []string{"ip-10-1-2-3.example.	60	IN	PTR	3.2.1.10.in-addr.arpa."}, 0},
+		{"client.example.", dns.TypeTXT, []dns.EDNS0{
+			&dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: []byte{192, 0, 2, 0}},
+			&dns.EDNS0_LOCAL{Code: 0xffee, Data: []byte("abcd")},
+		}, dns.RcodeSuccess, []string{"client.example.	60	IN	TXT	\"10.240.0.1\" \"192.0.2.0/24\" \"abcd\""}, 0},
+		{"client.example.", dns.TypeTXT, nil, dns.RcodeSuccess, []string{"client.example.	60	IN	TXT	\"10.240.0.1\" \"\" \"\""}, 0},
+	}
+
+	ctx := context.TODO()
+	for i, tc := range tests {
+		req := new(dns.Msg)
+		req.SetQuestion(tc.qname, tc.qtype)
+		if tc.opts != nil {
+			req.SetEdns0(4096, false)
+			o := req.IsEdns0()
+			o.Option = tc.opts
+		}
+		rec := dnstest.NewRecorder(&test.ResponseWriter{})
+		code, err := handler.ServeDNS(ctx, rec, req)
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %v", i, err)
+			continue
+		}
+		if code != tc.expectedCode || rec.Msg == nil || rec.Msg.Rcode != tc.expectedCode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.expectedCode], dns.RcodeToString[code])
+			continue
+		}
+		if len(rec.Msg.Answer) != len(tc.answer) {
+			t.Errorf("Test %d: expected answer %v, got %v", i, tc.answer, rec.Msg.Answer)
+			continue
+		}
+		for j, rr := range rec.Msg.Answer {
+			if rr.String() != tc.answer[j] {
+				t.Errorf("Test %d: expected %q, got %q", i, tc.answer[j], rr.String())
+			}
+		}
+		if len(rec.Msg.Ns) != tc.ns {
+			t.Errorf("Test %d: expected %d authority records, got %v", i, tc.ns, rec.Msg.Ns)
+		}
+	}
+}
+
 const rcodeFallthrough = 3841 // reserved for private use, used to indicate a fallthrough
@@ -0,0 +1,147 @@
+package transfer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Cataloger may be implemented by plugins that serve a set of zones that changes at runtime. These zones
+// are listed as member zones in the catalog zones (RFC 9432) the transfer plugin generates.
+type Cataloger interface {
+	// CatalogZones returns the zones the plugin is currently authoritative for.
+	CatalogZones() []string
+}
+
+// catalog generates a catalog zone that lists the zones of the Catalogers as its members. Each
+// time the set of member zones changes the serial is increased.
+type catalog struct {
+	origin string
+
+	sync.Mutex
+	members []string
+	serial  uint32
+}
+
+func newCatalog(origin string) *catalog {
+	return &catalog{origin: origin, serial: uint32(time.Now().Unix())}
+}
+
+// records returns the records of the catalog zone listing the zones from cs. The SOA record is the first record.
+func (c *catalog) records(cs []Cataloger) []dns.RR {
+	seen := map[string]struct{}{}
+	members := []string{}
+	for _, ca := range cs {
+		for _, z := range ca.CatalogZones() {
+			z = strings.ToLower(dns.Fqdn(z))
+			if _, ok := seen[z]; ok {
+				continue
+			}
+			seen[z] = struct{}{}
+			members = append(members, z)
+		}
+	}
+	sort.Strings(members)
+
+	c.Lock()
+	if !equal(members, c.members) {
+		c.members = members
+		c.serial++
+	}
+	serial := c.serial
+	c.Unlock()
+
+	hdr := func(name string, rrtype uint16) dns.RR_Header {
+		return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: 0}
+	}
+	rrs := []dns.RR{
+		&dns.SOA{Hdr: hdr(c.origin, dns.TypeSOA), Ns: "invalid.", Mbox: "invalid.", Serial: serial, Refresh: 60, Retry: 60, Expire: 604800, Minttl: 0},
+		&dns.NS{Hdr: hdr(c.origin, dns.TypeNS), Ns: "invalid."},
+		&dns.TXT{Hdr: hdr("version."+c.origin, dns.TypeTXT), Txt: []string{"2"}},
+	}
+	for _, m := range members {
+		rrs = append(rrs, &dns.PTR{Hdr: hdr(memberLabel(m)+".zones."+c.origin, dns.TypePTR), Ptr: m})
+	}
+	return rrs
+}
+
+// Transfer returns the catalog zone as if it was returned by a Transferer.
+func (c *catalog) Transfer(cs []Cataloger, serial uint32) <-chan []dns.RR {
+	rrs := c.records(cs)
+	ch := make(chan []dns.RR, 1)
+	if serial != 0 && serial == rrs[0].(*dns.SOA).Serial {
+		rrs = rrs[:1]
+	}
+	ch <- rrs
+	close(ch)
+	return ch
+}
+
+// ServeDNS answers regular queries for the catalog zone. Secondaries need at least the SOA record
+// to see if the catalog has changed.
+func (c *catalog) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, cs []Cataloger) (int, error) {
+	state := request.Request{W: w, Req: r}
+	qname, qtype := state.Name(), state.QType()
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	rrs := c.records(cs)
+	exists := false
+	for _, rr := range rrs {
+		if !dns.IsSubDomain(qname, rr.Header().Name) {
+			continue
+		}
+		exists = true
+		if rr.Header().Name == qname && (rr.Header().Rrtype == qtype || qtype == dns.TypeANY) {
+			m.Answer = append(m.Answer, rr)
+		}
+	}
+	if len(m.Answer) == 0 {
+		m.Ns = []dns.RR{rrs[0]}
+		if !exists {
+			m.Rcode = dns.RcodeNameError
+		}
+	}
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// memberLabel returns the unique label that is used for the member zone in the catalog. The label
+// is derived from the name of the zone, so it stays the same for as long as the zone is a member.
+func memberLabel(zone string) string {
+	h := sha1.Sum([]byte(zone))
+	return hex.EncodeToString(h[:])
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// catalogFor returns the xfr whose catalog zone contains name, or nil if name is not in any catalog zone.
+func (t Transfer) catalogFor(name string) *xfr {
+	for _, x := range t.xfrs {
+		if x.catalog != nil && plugin.Name(x.catalog.origin).Matches(name) {
+			return x
+		}
+	}
+	return nil
+}
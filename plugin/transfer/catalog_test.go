@@ -0,0 +1,111 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+)
+
+type catalogerPlugin []string
+
+func (c catalogerPlugin) CatalogZones() []string { return c }
+
+func newCatalogTransfer(zones ...string) (Transfer, *catalogerPlugin) {
+	cp := catalogerPlugin(zones)
+	return Transfer{
+		Catalogers: []Cataloger{&cp},
+		xfrs: []*xfr{
+			{
+				Zones:   []string{"catalog.invalid."},
+				to:      []string{"*"},
+				catalog: newCatalog("catalog.invalid."),
+			},
+		},
+		Next: terminatingPlugin{},
+	}, &cp
+}
+
+func TestCatalogAXFR(t *testing.T) {
+	transfer, _ := newCatalogTransfer("example.org.", "example.net")
+
+	w := dnstest.NewMultiRecorder(&test.ResponseWriter{})
+	m := new(dns.Msg)
+	m.SetAxfr("catalog.invalid.")
+	if _, err := transfer.ServeDNS(context.TODO(), w, m); err != nil {
+		t.Fatal(err)
+	}
+
+	ptrs := map[string]bool{}
+	version := false
+	n := 0
+	for _, m := range w.Msgs {
+		for _, rr := range m.Answer {
+			n++
+			switch x := rr.(type) {
+			case *dns.PTR:
+				ptrs[x.Ptr] = true
+			case *dns.TXT:
+				version = x.Hdr.Name == "version.catalog.invalid." && x.Txt[0] == "2"
+			}
+		}
+	}
+	// SOA, NS, version TXT, 2 members and closing SOA.
+	if n != 6 {
+		t.Errorf("Expected %d records, got %d", 6, n)
+	}
+	if !version {
+		t.Errorf("Expected version record")
+	}
+	if !ptrs["example.org."] || !ptrs["example.net."] {
+		t.Errorf("Expected example.org. and example.net. as members, got %v", ptrs)
+	}
+}
+
+func TestCatalogSerial(t *testing.T) {
+	transfer, cp := newCatalogTransfer("example.org.")
+	c := transfer.xfrs[0].catalog
+
+	serial := c.records(transfer.Catalogers)[0].(*dns.SOA).Serial
+	if x := c.records(transfer.Catalogers)[0].(*dns.SOA).Serial; x != serial {
+		t.Errorf("Expected serial to stay %d, got %d", serial, x)
+	}
+	*cp = append(*cp, "example.net.")
+	if x := c.records(transfer.Catalogers)[0].(*dns.SOA).Serial; x != serial+1 {
+		t.Errorf("Expected serial to be %d, got %d", serial+1, x)
+	}
+}
+
+func TestCatalogQuery(t *testing.T) {
+	transfer, _ := newCatalogTransfer("example.org.")
+
+	tests := []struct {
+		qname  string
+		qtype  uint16
+		rcode  int
+		answer int
+	}{
+		{"catalog.invalid.", dns.TypeSOA, dns.RcodeSuccess, 1},
+		{"version.catalog.invalid.", dns.TypeTXT, dns.RcodeSuccess, 1},
+		{"zones.catalog.invalid.", dns.TypeTXT, dns.RcodeSuccess, 0},
+		{"nope.catalog.invalid.", dns.TypeA, dns.RcodeNameError, 0},
+		{"example.org.", dns.TypeA, dns.RcodeNameError, 0}, // handled by next plugin
+	}
+	for i, tc := range tests {
+		w := dnstest.NewRecorder(&test.ResponseWriter{})
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, tc.qtype)
+		if _, err := transfer.ServeDNS(context.TODO(), w, m); err != nil {
+			t.Fatal(err)
+		}
+		if w.Msg.Rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %d, got %d", i, tc.rcode, w.Msg.Rcode)
+		}
+		if len(w.Msg.Answer) != tc.answer {
+			t.Errorf("Test %d: expected %d answers, got %d", i, tc.answer, len(w.Msg.Answer))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/coredns/coredns/plugin/pkg/rcode"
+
+	"github.com/miekg/dns"
+)
+
+// Notify sends notifies for zone to the remote servers in to, "*" entries are skipped. It will try up to three times
+// before giving up on a specific remote. We will sequentially loop through "to" until they all have replied (or have 3
+// failed attempts).
+func Notify(zone string, to []string) {
+	m := new(dns.Msg)
+	m.SetNotify(zone)
+	c := new(dns.Client)
+
+	for _, t := range to {
+		if t == "*" {
+			continue
+		}
+		if err := notifyAddr(c, m, t); err != nil {
+			log.Error(err.Error())
+		}
+	}
+	log.Infof("Sent notifies for zone %q to %v", zone, to)
+}
+
+func notifyAddr(c *dns.Client, m *dns.Msg, s string) error {
+	var err error
+
+	code := dns.RcodeServerFailure
+	for i := 0; i < 3; i++ {
+		var ret *dns.Msg
+		ret, _, err = c.Exchange(m, s)
+		if err != nil {
+			continue
+		}
+		code = ret.Rcode
+		if code == dns.RcodeSuccess {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("notify for zone %q was not accepted by %q: %q", m.Question[0].Name, s, err)
+	}
+	return fmt.Errorf("notify for zone %q was not accepted by %q: rcode was %q", m.Question[0].Name, s, rcode.ToString(code))
+}
@@ -0,0 +1,47 @@
+package transfer
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+
+	"github.com/miekg/dns"
+)
+
+func TestNotify(t *testing.T) {
+	notifies := make(chan string, 1)
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		if r.Opcode == dns.OpcodeNotify {
+			notifies <- r.Question[0].Name
+		}
+		m := new(dns.Msg)
+		m.SetReply(r)
+		w.WriteMsg(m)
+	})
+	defer s.Close()
+
+	Notify("example.org.", []string{"*", s.Addr})
+	select {
+	case zone := <-notifies:
+		if zone != "example.org." {
+			t.Errorf("Expected notify for %s, got %s", "example.org.", zone)
+		}
+	default:
+		t.Fatal("Expected a notify")
+	}
+}
+
+func TestNotifyAddrRefused(t *testing.T) {
+	s := dnstest.NewServer(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeRefused)
+		w.WriteMsg(m)
+	})
+	defer s.Close()
+
+	m := new(dns.Msg)
+	m.SetNotify("example.org.")
+	if err := notifyAddr(new(dns.Client), m, s.Addr); err == nil {
+		t.Fatal("Expected an error for a refused notify")
+	}
+}
@@ -32,6 +32,9 @@ func setup(c *caddy.Controller) error {
 		// find all plugins that implement Transferer and add them to Transferers
 		plugins := dnsserver.GetConfig(c).Handlers()
 		for _, pl := range plugins {
+			if ca, ok := pl.(Cataloger); ok {
+				t.Catalogers = append(t.Catalogers, ca)
+			}
 			tr, ok := pl.(Transferer)
 			if !ok {
 				continue
@@ -89,6 +92,17 @@ func parse(c *caddy.Controller) (*Transfer, error) {
 					}
 					x.to = append(x.to, normalized)
 				}
+			case "catalog":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				origin, err := plugin.Host(args[0]).MustNormalize()
+				if err != nil {
+					return nil, err
+				}
+				x.catalog = newCatalog(origin)
+				x.Zones = append(x.Zones, origin)
 			default:
 				return nil, plugin.Error("transfer", c.Errf("unknown property '%s'", c.Val()))
 			}
@@ -29,6 +29,18 @@ func TestParse(t *testing.T) {
 				}},
 			},
 		},
+		{`transfer example.net {
+			to *
+			catalog catalog.invalid
+		 }`,
+			false,
+			&Transfer{
+				xfrs: []*xfr{{
+					Zones: []string{"example.net.", "catalog.invalid."},
+					to:    []string{"*"},
+				}},
+			},
+		},
 		// errors
 		{`transfer example.net example.org {
 		 }`,
@@ -41,6 +53,13 @@ func TestParse(t *testing.T) {
 			true,
 			nil,
 		},
+		{`transfer example.net {
+			to *
+			catalog
+		 }`,
+			true,
+			nil,
+		},
 	}
 	for i, tc := range tests {
 		c := caddy.NewTestController("dns", tc.input)
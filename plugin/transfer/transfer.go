@@ -19,13 +19,15 @@ var log = clog.NewWithPlugin("transfer")
 // Transfer is a plugin that handles zone transfers.
 type Transfer struct {
 	Transferers []Transferer // the list of plugins that implement Transferer
+	Catalogers  []Cataloger  // the list of plugins that implement Cataloger
 	xfrs        []*xfr
 	Next        plugin.Handler // the next plugin in the chain
 }
 
 type xfr struct {
-	Zones []string
-	to    []string
+	Zones   []string
+	to      []string
+	catalog *catalog // catalog zone generated from the Catalogers, may be nil
 }
 
 // Transferer may be implemented by plugins to enable zone transfers
@@ -41,7 +43,9 @@ type Transferer interface {
 	// If serial is not 0, handle as an IXFR request. If the serial is equal to or greater (newer) than
 	// the current serial for the zone, send a single SOA record to the channel.
 	// If the serial is less (older) than the current serial for the zone, perform an AXFR fallback
-	// by proceeding as if an AXFR was requested (as above).
+	// by proceeding as if an AXFR was requested (as above). Plugins that keep a history of the zone
+	// may instead send the differences since serial, as described in RFC 1995 Section 4: the current SOA
+	// followed by the sequences of differences. The closing SOA is added by the transfer plugin.
 	Transfer(zone string, serial uint32) (<-chan []dns.RR, error)
 }
 
@@ -54,6 +58,9 @@ var (
 func (t Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
 	if state.QType() != dns.TypeAXFR && state.QType() != dns.TypeIXFR {
+		if x := t.catalogFor(state.Name()); x != nil {
+			return x.catalog.ServeDNS(ctx, w, r, t.Catalogers)
+		}
 		return plugin.NextOrFailure(t.Name(), t.Next, ctx, w, r)
 	}
 
@@ -88,7 +95,13 @@ func (t Transfer) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 
 	// Get a receiving channel from the first Transferer plugin that returns one
 	var fromPlugin <-chan []dns.RR
+	if x.catalog != nil && state.Name() == x.catalog.origin {
+		fromPlugin = x.catalog.Transfer(t.Catalogers, serial)
+	}
 	for _, p := range t.Transferers {
+		if fromPlugin != nil {
+			break
+		}
 		var err error
 		fromPlugin, err = p.Transfer(state.QName(), serial)
 		if err == ErrNotAuthoritative {
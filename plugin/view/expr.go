@@ -0,0 +1,261 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/pkg/replacer"
+	"github.com/coredns/coredns/request"
+)
+
+// expr is a boolean expression that is evaluated for each request. Expressions compare operands with
+// ==, != (equality), =~ and !~ (regular expression match) and are combined with &&, || and !. An operand
+// is a placeholder as supported by the replacer, i.e. {name}, {remote} or a metadata label: {/geoip/country},
+// a string quoted with single quotes, or a bare word.
+type expr interface {
+	eval(ctx context.Context, state request.Request) bool
+}
+
+type operand interface {
+	value(ctx context.Context, state request.Request) string
+}
+
+type literal string
+
+func (l literal) value(ctx context.Context, state request.Request) string { return string(l) }
+
+type placeholder string
+
+var repl = replacer.New()
+
+func (p placeholder) value(ctx context.Context, state request.Request) string {
+	return repl.Replace(ctx, state, nil, string(p))
+}
+
+type compare struct {
+	op   string
+	a, b operand
+	re   *regexp.Regexp // compiled b, for =~ and !~ when b is a literal
+}
+
+func (c compare) eval(ctx context.Context, state request.Request) bool {
+	a := c.a.value(ctx, state)
+	switch c.op {
+	case "==":
+		return a == c.b.value(ctx, state)
+	case "!=":
+		return a != c.b.value(ctx, state)
+	}
+	re := c.re
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(c.b.value(ctx, state)); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(a) == (c.op == "=~")
+}
+
+type and struct{ a, b expr }
+
+func (e and) eval(ctx context.Context, state request.Request) bool {
+	return e.a.eval(ctx, state) && e.b.eval(ctx, state)
+}
+
+type or struct{ a, b expr }
+
+func (e or) eval(ctx context.Context, state request.Request) bool {
+	return e.a.eval(ctx, state) || e.b.eval(ctx, state)
+}
+
+type not struct{ e expr }
+
+func (e not) eval(ctx context.Context, state request.Request) bool { return !e.e.eval(ctx, state) }
+
+// parseExpr parses s into an expression.
+func parseExpr(s string) (expr, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q in expression", p.toks[p.pos].val)
+	}
+	return e, nil
+}
+
+type tokenType int
+
+const (
+	tokOp tokenType = iota // operators and parentheses
+	tokLiteral
+	tokPlaceholder
+)
+
+type token struct {
+	typ tokenType
+	val string
+}
+
+var operators = []string{"&&", "||", "==", "!=", "=~", "!~", "!", "(", ")"}
+
+func lex(s string) ([]token, error) {
+	toks := []token{}
+Loop:
+	for len(s) > 0 {
+		switch c := s[0]; {
+		case c == ' ' || c == '\t':
+			s = s[1:]
+			continue
+		case c == '\'':
+			i := strings.IndexByte(s[1:], '\'')
+			if i < 0 {
+				return nil, fmt.Errorf("unterminated string in expression")
+			}
+			toks = append(toks, token{tokLiteral, s[1 : i+1]})
+			s = s[i+2:]
+			continue
+		case c == '{':
+			i := strings.IndexByte(s, '}')
+			if i < 0 {
+				return nil, fmt.Errorf("unterminated placeholder in expression")
+			}
+			toks = append(toks, token{tokPlaceholder, s[:i+1]})
+			s = s[i+1:]
+			continue
+		}
+		for _, op := range operators {
+			if strings.HasPrefix(s, op) {
+				toks = append(toks, token{tokOp, op})
+				s = s[len(op):]
+				continue Loop
+			}
+		}
+		// Bare word, up to the next space or operator.
+		i := 0
+		for i < len(s) && !strings.ContainsAny(s[i:i+1], " \t'{()&|=!") {
+			i++
+		}
+		if i == 0 {
+			return nil, fmt.Errorf("unexpected %q in expression", s[:1])
+		}
+		toks = append(toks, token{tokLiteral, s[:i]})
+		s = s[i:]
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek(op string) bool {
+	return p.pos < len(p.toks) && p.toks[p.pos].typ == tokOp && p.toks[p.pos].val == op
+}
+
+func (p *parser) or() (expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.pos++
+		b, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		e = or{e, b}
+	}
+	return e, nil
+}
+
+func (p *parser) and() (expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.pos++
+		b, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		e = and{e, b}
+	}
+	return e, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	switch {
+	case p.peek("!"):
+		p.pos++
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return not{e}, nil
+	case p.peek("("):
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing ) in expression")
+		}
+		p.pos++
+		return e, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (expr, error) {
+	a, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.toks) || p.toks[p.pos].typ != tokOp {
+		return nil, fmt.Errorf("expected comparison operator in expression")
+	}
+	op := p.toks[p.pos].val
+	switch op {
+	case "==", "!=", "=~", "!~":
+	default:
+		return nil, fmt.Errorf("expected comparison operator in expression, got %q", op)
+	}
+	p.pos++
+	b, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	c := compare{op: op, a: a, b: b}
+	if l, ok := b.(literal); ok && (op == "=~" || op == "!~") {
+		if c.re, err = regexp.Compile(string(l)); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	switch t.typ {
+	case tokLiteral:
+		return literal(t.val), nil
+	case tokPlaceholder:
+		return placeholder(t.val), nil
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", t.val)
+}
@@ -0,0 +1,113 @@
+package view
+
+import (
+	"net"
+	"strings"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/caddyserver/caddy"
+	"github.com/infobloxopen/go-trees/iptree"
+)
+
+func init() { plugin.Register("view", setup) }
+
+func setup(c *caddy.Controller) error {
+	v, err := parse(c)
+	if err != nil {
+		return plugin.Error("view", err)
+	}
+
+	config := dnsserver.GetConfig(c)
+	if config.ViewName != "" {
+		return plugin.Error("view", plugin.ErrOnce)
+	}
+	config.ViewName = v.Name
+	config.FilterFuncs = append(config.FilterFuncs, v.Filter)
+
+	return nil
+}
+
+func parse(c *caddy.Controller) (*View, error) {
+	v := &View{}
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, plugin.ErrOnce
+		}
+		i++
+
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return nil, c.ArgErr()
+		}
+		v.Name = args[0]
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "net":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				if v.nets == nil {
+					v.nets = iptree.NewTree()
+				}
+				for _, a := range args {
+					_, source, err := net.ParseCIDR(normalize(a))
+					if err != nil {
+						return nil, c.Errf("illegal CIDR notation %q", a)
+					}
+					v.nets.InplaceInsertNet(source, struct{}{})
+				}
+
+			case "proto":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				if v.protos == nil {
+					v.protos = map[string]struct{}{}
+				}
+				for _, a := range args {
+					a = strings.ToLower(a)
+					if a != "udp" && a != "tcp" {
+						return nil, c.Errf("unknown protocol %q, expect 'udp' or 'tcp'", a)
+					}
+					v.protos[a] = struct{}{}
+				}
+
+			case "expr":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				e, err := parseExpr(strings.Join(args, " "))
+				if err != nil {
+					return nil, c.Err(err.Error())
+				}
+				v.exprs = append(v.exprs, e)
+
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	if v.nets == nil && v.protos == nil && len(v.exprs) == 0 {
+		return nil, c.Errf("view %q has no conditions", v.Name)
+	}
+	return v, nil
+}
+
+// normalize appends '/32' for any single IPv4 address and '/128' for IPv6.
+func normalize(rawNet string) string {
+	if strings.Contains(rawNet, "/") {
+		return rawNet
+	}
+	if strings.Contains(rawNet, ":") {
+		return rawNet + "/128"
+	}
+	return rawNet + "/32"
+}
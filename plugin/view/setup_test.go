@@ -0,0 +1,67 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/caddyserver/caddy"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+	}{
+		{`view internal {
+			net 10.0.0.0/8 192.168.0.1
+		}`, false},
+		{`view tcp {
+			proto tcp
+		}`, false},
+		{`view nl {
+			expr {/geoip/country} == NL
+			expr {type} != 'AXFR'
+		}`, false},
+		// fails
+		{`view`, true},
+		{`view a b {
+			proto tcp
+		}`, true},
+		{`view empty`, true},
+		{`view internal {
+			net 10.0.0.0/33
+		}`, true},
+		{`view internal {
+			proto sctp
+		}`, true},
+		{`view internal {
+			expr {name} ==
+		}`, true},
+		{`view internal {
+			unknown
+		}`, true},
+		{`view a {
+			proto tcp
+		}
+		view b {
+			proto tcp
+		}`, true},
+	}
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		err := setup(c)
+		if tc.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error but found none for input %s", i, tc.input)
+		}
+		if !tc.shouldErr && err != nil {
+			t.Errorf("Test %d: expected no error but found one for input %s, got: %v", i, tc.input, err)
+		}
+		if !tc.shouldErr {
+			config := dnsserver.GetConfig(c)
+			if config.ViewName == "" || len(config.FilterFuncs) != 1 {
+				t.Errorf("Test %d: expected view and filter to be set in config", i)
+			}
+		}
+	}
+}
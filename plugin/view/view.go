@@ -0,0 +1,44 @@
+// Package view implements the view plugin, it selects the server block that handles a query.
+package view
+
+import (
+	"context"
+	"net"
+
+	"github.com/coredns/coredns/request"
+
+	"github.com/infobloxopen/go-trees/iptree"
+)
+
+// View holds the conditions a request must match for the server block to be used.
+type View struct {
+	Name string
+
+	nets   *iptree.Tree // client networks, nil if not configured
+	protos map[string]struct{}
+	exprs  []expr
+}
+
+// Filter implements dnsserver.FilterFunc, it returns true if the request matches all conditions of the view.
+func (v *View) Filter(ctx context.Context, state *request.Request) bool {
+	if v.nets != nil {
+		ip := net.ParseIP(state.IP())
+		if ip == nil {
+			return false
+		}
+		if _, ok := v.nets.GetByIP(ip); !ok {
+			return false
+		}
+	}
+	if len(v.protos) > 0 {
+		if _, ok := v.protos[state.Proto()]; !ok {
+			return false
+		}
+	}
+	for _, e := range v.exprs {
+		if !e.eval(ctx, *state) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package view
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/metadata"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/caddyserver/caddy"
+	"github.com/miekg/dns"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		view  string
+		ip    string
+		tcp   bool
+		qname string
+		match bool
+	}{
+		{"net 10.0.0.0/8", "10.1.2.3", false, "example.org.", true},
+		{"net 10.0.0.0/8", "192.168.1.1", false, "example.org.", false},
+		{"net 10.0.0.0/8 2001:db8::/32", "2001:db8::1", false, "example.org.", true},
+		{"proto tcp", "10.1.2.3", true, "example.org.", true},
+		{"proto tcp", "10.1.2.3", false, "example.org.", false},
+		{"net 10.0.0.0/8\nproto tcp", "10.1.2.3", false, "example.org.", false},
+		{"expr {name} == a.example.org.", "10.1.2.3", false, "a.example.org.", true},
+		{"expr {name} =~ '^a\\.'", "10.1.2.3", false, "b.example.org.", false},
+		{"expr {name} !~ '^a\\.' && {type} == A", "10.1.2.3", false, "b.example.org.", true},
+		{"expr !({remote} == 10.1.2.3 || {proto} == tcp)", "10.1.2.4", false, "example.org.", true},
+		{"expr {/test/country} == NL", "10.1.2.3", false, "example.org.", true},
+		{"expr {/test/country} != NL", "10.1.2.3", false, "example.org.", false},
+		{"expr {/test/missing} == ''", "10.1.2.3", false, "example.org.", false},
+	}
+
+	ctx := metadata.ContextWithMetadata(context.TODO())
+	metadata.SetValueFunc(ctx, "test/country", func() string { return "NL" })
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", "view test {\n"+tc.view+"\n}")
+		v, err := parse(c)
+		if err != nil {
+			t.Fatalf("Test %d: failed to parse view: %s", i, err)
+		}
+
+		m := new(dns.Msg)
+		m.SetQuestion(tc.qname, dns.TypeA)
+		state := &request.Request{W: &test.ResponseWriter{RemoteIP: tc.ip, TCP: tc.tcp}, Req: m}
+		if x := v.Filter(ctx, state); x != tc.match {
+			t.Errorf("Test %d: expected %t for %q, got %t", i, tc.match, tc.view, x)
+		}
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		expr      string
+		shouldErr bool
+	}{
+		{"{name} == a", false},
+		{"({name} == a || {name} == b) && !({type} == AAAA)", false},
+		{"{name} =~ 'a(b|c)'", false},
+		{"{name} =~ 'a(b'", true},
+		{"{name}", true},
+		{"{name} == ", true},
+		{"{name} == a)", true},
+		{"({name} == a", true},
+		{"{name == a", true},
+		{"{name} == 'a", true},
+		{"{name} && a", true},
+	}
+	for i, tc := range tests {
+		_, err := parseExpr(tc.expr)
+		if tc.shouldErr && err == nil {
+			t.Errorf("Test %d: expected error for %q, got none", i, tc.expr)
+		}
+		if !tc.shouldErr && err != nil {
+			t.Errorf("Test %d: expected no error for %q, got %s", i, tc.expr, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestView(t *testing.T) {
+	corefile := `example.org:0 {
+		view tcp {
+			proto tcp
+		}
+		template IN A {
+			answer "{{ .Name }} 60 IN A 10.0.0.1"
+		}
+	}
+	example.org:0 {
+		template IN A {
+			answer "{{ .Name }} 60 IN A 192.168.0.1"
+		}
+	}`
+
+	i, udp, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	tests := []struct {
+		net    string
+		addr   string
+		expect string
+	}{
+		{"udp", udp, "192.168.0.1"},
+		{"tcp", tcp, "10.0.0.1"},
+	}
+	for _, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("a.example.org.", dns.TypeA)
+		c := &dns.Client{Net: tc.net}
+		resp, _, err := c.Exchange(m, tc.addr)
+		if err != nil {
+			t.Fatalf("Expected to receive reply over %s, but didn't: %v", tc.net, err)
+		}
+		if len(resp.Answer) != 1 {
+			t.Fatalf("Expected 1 answer over %s, got %d", tc.net, len(resp.Answer))
+		}
+		if a := resp.Answer[0].(*dns.A).A.String(); a != tc.expect {
+			t.Errorf("Expected %s over %s, got %s", tc.expect, tc.net, a)
+		}
+	}
+}